@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+var (
+	auditSince  string
+	auditFormat string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "查看和校验审计日志",
+	Long: `执行的语句以及导入、导出操作都会记录到配置目录下的 audit/audit.jsonl，
+每条记录包含时间、操作系统用户、配置名、数据库、屏蔽了字面值的语句、耗时、行数和错误。
+每条记录包含上一条记录的哈希，修改或删除记录后 audit verify 会报告错误。`,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "显示审计日志",
+	Long: `显示审计日志，--since 只显示指定时间之后的记录。
+
+示例:
+  datamgr-cli audit show --since 2h
+  datamgr-cli audit show --since "2024-05-01 08:00:00" --format csv`,
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !output.IsValidFormat(auditFormat) {
+			return withExitCode(ExitUsage, fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", auditFormat, strings.Join(output.Formats(), ", ")))
+		}
+		var since time.Time
+		if auditSince != "" {
+			t, err := handler.ParseSince(auditSince)
+			if err != nil {
+				return withExitCode(ExitUsage, err)
+			}
+			since = t
+		}
+		entries, err := audit.Load(since)
+		if err != nil {
+			return err
+		}
+		stdout := cmd.OutOrStdout()
+		opts := handler.RenderOptions()
+		if isTerminal(stdout) {
+			opts = handler.DisplayOptions()
+		}
+		return output.Render(stdout, auditFormat, handler.AuditResult(entries), opts)
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:           "verify",
+	Short:         "校验审计日志是否被修改",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count, err := audit.Verify()
+		if err != nil {
+			return withExitCode(ExitFailure, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "审计日志完整，共校验 %d 条记录\n", count)
+		return nil
+	},
+}
+
+func init() {
+	auditShowCmd.Flags().StringVar(&auditSince, "since", "", "只显示该时间之后的记录，可以是时长如 2h、7d 或时间")
+	auditShowCmd.Flags().StringVar(&auditFormat, "format", output.FormatTable, formatFlagUsage())
+	auditCmd.AddCommand(auditShowCmd, auditVerifyCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+var browseFlags connFlags
+
+var browseCmd = &cobra.Command{
+	Use:           "browse <表名>",
+	Short:         "全屏浏览和编辑表数据",
+	Long:          `以全屏方式浏览表数据，支持键盘滚动、按列排序和过滤、查看记录详情，并可按主键编辑单元格。`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := connectWithFlags(cmd, &browseFlags); err != nil {
+			return err
+		}
+		defer disconnectQuietly()
+		return handler.HandleBrowse(args[0])
+	},
+}
+
+func init() {
+	addConnFlags(browseCmd, &browseFlags)
+}
@@ -1,8 +1,9 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yuanpli/datamgr-cli/db"
@@ -19,17 +20,19 @@ var (
 	configUser  string
 	configPwd   string
 	configDbName string
+	configOptions []string
 )
 
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "管理默认连接配置",
-	Long:  `管理数据库默认连接配置，支持保存、查看、修改和清除操作。`,
+	Long: `管理数据库默认连接配置，支持保存、查看、修改和清除操作。
+使用 --profile <名称> 操作指定名称的配置，config profile 管理所有命名配置。`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 根据标志执行不同的操作
 		if clearFlag {
 			// 清除配置
-			if err := utils.ClearConfig(); err != nil {
+			if err := clearProfile(profileName); err != nil {
 				fmt.Printf("清除配置失败: %v\n", err)
 				return
 			}
@@ -38,9 +41,9 @@ var configCmd = &cobra.Command{
 		}
 
 		if showFlag || (!saveFlag && configType == "" && configHost == "" && 
-			configPort == "" && configUser == "" && configPwd == "" && configDbName == "") {
+			configPort == "" && configUser == "" && configPwd == "" && configDbName == "" && len(configOptions) == 0) {
 			// 显示配置
-			_, err := utils.DisplayConfig()
+			_, err := utils.DisplayProfile(profileName)
 			if err != nil {
 				fmt.Printf("加载配置失败: %v\n", err)
 				return
@@ -64,9 +67,11 @@ var configCmd = &cobra.Command{
 				User:     currentConfig.User,
 				Password: currentConfig.Password,
 				DbName:   currentConfig.DbName,
+				Options:  currentConfig.Options,
+				ReadOnly: currentConfig.ReadOnly,
 			}
 
-			if err := utils.SaveConfig(config); err != nil {
+			if err := utils.SaveProfile(profileName, config); err != nil {
 				fmt.Printf("保存配置失败: %v\n", err)
 				return
 			}
@@ -78,45 +83,37 @@ var configCmd = &cobra.Command{
 		// 修改配置
 		// 先尝试加载现有配置
 		var config *utils.Config
-		existingConfig, err := utils.LoadConfig()
+		existingConfig, err := utils.LoadStoredProfile(profileName)
 		if err == nil {
 			// 有现有配置，以它为基础修改
 			config = existingConfig
 		} else {
-			// 没有现有配置，创建新的
-			config = &utils.Config{
-				Type: "dameng", // 默认使用达梦数据库
-				Port: 5236,     // 默认端口
-			}
+			// 没有现有配置，使用默认数据库类型及其默认端口
+			config = utils.NewConfig()
 		}
 
-		// 更新指定的字段
-		if configType != "" {
-			config.Type = configType
-		}
-		if configHost != "" {
-			config.Host = configHost
+		// 更新指定的字段和连接选项
+		values := make(map[string]string)
+		for _, field := range utils.ConfigFields {
+			if value, _ := cmd.Flags().GetString(field); value != "" {
+				values[field] = value
+			}
 		}
-		if configPort != "" {
-			port, err := strconv.Atoi(configPort)
-			if err != nil {
-				fmt.Printf("端口格式错误: %v\n", err)
+		for _, opt := range configOptions {
+			name, value, ok := strings.Cut(opt, "=")
+			if !ok {
+				fmt.Printf("连接选项格式错误: %s，应为 名称=值\n", opt)
 				return
 			}
-			config.Port = port
-		}
-		if configUser != "" {
-			config.User = configUser
+			values[strings.TrimSpace(name)] = strings.TrimSpace(value)
 		}
-		if configPwd != "" {
-			config.Password = configPwd
-		}
-		if configDbName != "" {
-			config.DbName = configDbName
+		if err := config.Apply(values); err != nil {
+			fmt.Println(err)
+			return
 		}
 
 		// 保存修改后的配置
-		if err := utils.SaveConfig(config); err != nil {
+		if err := utils.SaveProfile(profileName, config); err != nil {
 			fmt.Printf("保存配置失败: %v\n", err)
 			return
 		}
@@ -125,6 +122,140 @@ var configCmd = &cobra.Command{
 	},
 }
 
+var (
+	lockWithKeyFile bool
+	resolveFlags    connFlags
+)
+
+var configResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "显示合并后的连接配置及每个值的来源",
+	Long: fmt.Sprintf(`按 默认值 < 用户配置 < 项目配置 < 环境变量 < 命令行参数 的顺序合并连接配置，
+显示最终使用的值及其来源。项目配置为当前目录或上级目录中的 %s，
+环境变量为 %sTYPE、%sHOST 等，%s 选择使用的配置名。`,
+		utils.ProjectConfigFileName, "DATAMGR_", "DATAMGR_", utils.ProfileEnv),
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flagValues, err := changedConnFlags(cmd)
+		if err != nil {
+			return err
+		}
+		res, err := utils.ResolveSources(profileName, flagValues)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		utils.PrintResolution(cmd.OutOrStdout(), res)
+		return nil
+	},
+}
+
+var configLockCmd = &cobra.Command{
+	Use:   "lock [--key-file [路径]]",
+	Short: "加密配置文件中保存的密码",
+	Long: fmt.Sprintf(`加密配置文件中所有明文保存的密码，之后保存的密码也会加密。
+默认由主密码派生密钥，主密码在需要时提示输入，也可通过环境变量 %s 提供；
+使用 --key-file 时改用权限为 0600 的密钥文件（不存在时自动生成，默认为配置目录下的 master.key）。
+密码也可以写成 ${env:变量名} 或 ${file:/路径}，连接时从环境变量或文件读取，不保存在配置中。`, utils.MasterPasswordEnv),
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyFile, err := lockKeyFile(lockWithKeyFile, args)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		count, err := utils.LockConfig(keyFile)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "已加密 %d 个配置的密码\n", count)
+		return nil
+	},
+}
+
+var configUnlockCmd = &cobra.Command{
+	Use:           "unlock",
+	Short:         "解密配置文件中的密码并以明文保存",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count, err := utils.UnlockConfig()
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "已解密 %d 个配置的密码，密码现以明文保存\n", count)
+		return nil
+	},
+}
+
+var configTrustCmd = &cobra.Command{
+	Use:   "trust [路径]",
+	Short: "信任项目配置文件",
+	Long: fmt.Sprintf(`信任项目配置文件 %s，默认为从当前目录向上找到的文件。
+用户配置中保存了密码时，只有信任的项目配置才能修改同名配置的 type、host、port 和 dbname，
+以免仓库中的文件将密码发送到其他服务器。文件内容变化后需要重新信任。`, utils.ProjectConfigFileName),
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return trustProject(cmd, args, true)
+	},
+}
+
+var configUntrustCmd = &cobra.Command{
+	Use:           "untrust [路径]",
+	Short:         "取消信任项目配置文件",
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return trustProject(cmd, args, false)
+	},
+}
+
+// trustProject 信任或取消信任参数指定的项目配置文件
+func trustProject(cmd *cobra.Command, args []string, trusted bool) error {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	}
+	path, err := utils.TrustProject(path, trusted)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if trusted {
+		fmt.Fprintf(cmd.OutOrStdout(), "已信任项目配置 %s\n", path)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "已取消信任项目配置 %s\n", path)
+	}
+	return nil
+}
+
+// lockKeyFile 返回 config lock 使用的密钥文件，不使用密钥文件时为空
+func lockKeyFile(useKeyFile bool, args []string) (string, error) {
+	if !useKeyFile {
+		if len(args) > 0 {
+			return "", errors.New("指定密钥文件路径时需要使用 --key-file")
+		}
+		return "", nil
+	}
+	if len(args) > 0 {
+		return utils.KeyFilePath(args[0])
+	}
+	return utils.KeyFilePath("")
+}
+
+// clearProfile 清除 --profile 指定的配置，未指定时清除默认配置
+func clearProfile(name string) error {
+	if name == "" {
+		return utils.ClearConfig()
+	}
+	return utils.RemoveProfile(name)
+}
+
 func init() {
 	configCmd.Flags().BoolVar(&saveFlag, "save", false, "保存当前连接为默认配置")
 	configCmd.Flags().BoolVar(&clearFlag, "clear", false, "清除默认配置")
@@ -135,4 +266,9 @@ func init() {
 	configCmd.Flags().StringVar(&configUser, "user", "", "设置用户名")
 	configCmd.Flags().StringVar(&configPwd, "password", "", "设置密码")
 	configCmd.Flags().StringVar(&configDbName, "dbname", "", "设置数据库名")
+	configCmd.Flags().StringArrayVar(&configOptions, "option", nil, "设置驱动的连接选项，格式为 名称=值，值为空时删除该选项")
+
+	configLockCmd.Flags().BoolVar(&lockWithKeyFile, "key-file", false, "使用密钥文件而不是主密码")
+	addConnFlags(configResolveCmd, &resolveFlags)
+	configCmd.AddCommand(configLockCmd, configUnlockCmd, configResolveCmd, configTrustCmd, configUntrustCmd)
 } 
\ No newline at end of file
@@ -1,71 +1,87 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yuanpli/datamgr-cli/db"
 	"github.com/yuanpli/datamgr-cli/pkg/handler"
 	"github.com/yuanpli/datamgr-cli/pkg/prompt"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
 )
 
-var (
-	dbType   string
-	host     string
-	port     int
-	user     string
-	password string
-	dbName   string
-)
+var connectFlags connFlags
 
 var connectCmd = &cobra.Command{
-	Use:   "connect",
+	Use:   "connect [@配置名]",
 	Short: "连接到数据库",
-	Long:  `连接到指定的数据库。支持达梦、MySQL、SQLite、PostgreSQL、Oracle、MS SQL Server等。`,
+	Long: fmt.Sprintf(`连接到指定的数据库，支持的数据库类型: %s。
+未指定 --type 时使用 %s，未指定端口时使用该类型的默认端口。
+使用 @<配置名> 或 --profile <配置名> 连接保存的命名配置。`, strings.Join(db.DriverNames(), ", "), db.DefaultDriver),
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// 如果没有提供命令行参数，则启动交互式连接向导
-		if !cmd.Flags().Changed("host") && !cmd.Flags().Changed("user") && 
-		   !cmd.Flags().Changed("password") && !cmd.Flags().Changed("dbname") {
-			// 使用交互式连接向导
-			if err := handler.HandleInteractiveConnect(); err != nil {
-				fmt.Printf("连接失败: %v\n", err)
-				return
-			}
-			// 成功连接后启动交互式命令行
-			prompt.Start()
+		name := profileName
+		if len(args) > 0 {
+			name = strings.TrimPrefix(args[0], "@")
+		}
+		flagValues, err := changedConnFlags(cmd)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		// 既没有指定配置也没有提供命令行参数时，启动交互式连接向导
+		if name == "" && len(flagValues) == 0 {
+			connectInteractively()
+			return
+		}
+
+		// 与非交互命令相同地逐层合并默认值、用户配置、项目配置、环境变量和命令行参数
+		res, err := utils.ResolveConfig(name, flagValues)
+		if errors.Is(err, utils.ErrNoDefaultConfig) {
+			fmt.Println("连接参数不完整，将启动交互式连接向导...")
+			connectInteractively()
+			return
+		}
+		if err != nil {
+			fmt.Printf("连接失败: %v\n", err)
+			return
+		}
+		config := res.Config.DbConfig()
+		// 检查数据库类型要求的参数
+		if err := validateConfig(config); err != nil {
+			fmt.Println(err)
+			fmt.Println("将启动交互式连接向导...")
+			connectInteractively()
+			return
+		}
+
+		if err := db.ConnectConfig(config); err != nil {
+			fmt.Printf("连接失败: %v\n", err)
+			return
+		}
+		if res.Profile != "" {
+			fmt.Printf("已使用配置 %s 连接到 %s 数据库: %s\n", res.Profile, config.Type, config.DbName)
 		} else {
-			// 检查必要参数
-			if host == "" || user == "" || password == "" || dbName == "" {
-				fmt.Println("连接参数不完整，请提供主机、用户名、密码和数据库名")
-				fmt.Println("将启动交互式连接向导...")
-				if err := handler.HandleInteractiveConnect(); err != nil {
-					fmt.Printf("连接失败: %v\n", err)
-					return
-				}
-				// 成功连接后启动交互式命令行
-				prompt.Start()
-				return
-			}
-			
-			// 使用命令行参数连接
-			if err := db.Connect(dbType, host, port, user, password, dbName); err != nil {
-				fmt.Printf("连接失败: %v\n", err)
-				return
-			}
-			fmt.Printf("已成功连接到 %s 数据库: %s\n", dbType, dbName)
-			
-			// 成功连接后启动交互式命令行
-			prompt.Start()
+			fmt.Printf("已成功连接到 %s 数据库: %s\n", config.Type, config.DbName)
 		}
+		handler.AfterConnect(res.Profile)
+
+		// 成功连接后启动交互式命令行
+		prompt.Start()
 	},
 }
 
+// connectInteractively 使用交互式连接向导连接，成功后启动交互式命令行
+func connectInteractively() {
+	if err := handler.HandleInteractiveConnect(); err != nil {
+		fmt.Printf("连接失败: %v\n", err)
+		return
+	}
+	prompt.Start()
+}
+
 func init() {
-	// 默认为达梦数据库
-	connectCmd.Flags().StringVar(&dbType, "type", "dameng", "数据库类型 (dameng, mysql, sqlite, postgresql, oracle, mssql)")
-	connectCmd.Flags().StringVarP(&host, "host", "H", "", "数据库主机地址")
-	connectCmd.Flags().IntVarP(&port, "port", "P", 5236, "数据库端口")
-	connectCmd.Flags().StringVarP(&user, "user", "u", "", "数据库用户名")
-	connectCmd.Flags().StringVarP(&password, "password", "p", "", "数据库密码")
-	connectCmd.Flags().StringVarP(&dbName, "dbname", "D", "", "数据库名称")
-} 
\ No newline at end of file
+	addConnFlags(connectCmd, &connectFlags)
+}
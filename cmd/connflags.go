@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// connFlags 非交互命令的连接参数
+type connFlags struct {
+	dbType   string
+	host     string
+	port     int
+	user     string
+	password string
+	dbName   string
+	options  []string
+}
+
+// addConnFlags 为命令注册连接参数
+func addConnFlags(cmd *cobra.Command, flags *connFlags) {
+	cmd.Flags().StringVar(&flags.dbType, "type", "", typeFlagUsage())
+	cmd.Flags().StringVarP(&flags.host, "host", "H", "", "数据库主机地址")
+	cmd.Flags().IntVarP(&flags.port, "port", "P", 0, "数据库端口，默认为数据库类型的默认端口")
+	cmd.Flags().StringVarP(&flags.user, "user", "u", "", "数据库用户名")
+	cmd.Flags().StringVarP(&flags.password, "password", "p", "", "数据库密码")
+	cmd.Flags().StringVarP(&flags.dbName, "dbname", "D", "", "数据库名称")
+	cmd.Flags().StringArrayVar(&flags.options, "option", nil, "驱动的连接选项，格式为 名称=值，可多次指定")
+}
+
+// changedConnFlags 返回命令行中指定的连接参数和 --option 连接选项，键为配置字段名或选项名
+func changedConnFlags(cmd *cobra.Command) (map[string]string, error) {
+	changed := make(map[string]string)
+	for _, field := range utils.ConfigFields {
+		if flag := cmd.Flags().Lookup(field); flag != nil && flag.Changed {
+			changed[field] = flag.Value.String()
+		}
+	}
+	options, _ := cmd.Flags().GetStringArray("option")
+	for _, opt := range options {
+		name, value, ok := strings.Cut(opt, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("连接选项格式错误: %s，应为 名称=值", opt))
+		}
+		changed[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return changed, nil
+}
+
+// connectWithFlags 按默认值、用户配置、项目配置、环境变量和命令行参数逐层合并连接信息后连接数据库
+func connectWithFlags(cmd *cobra.Command, flags *connFlags) error {
+	flagValues, err := changedConnFlags(cmd)
+	if err != nil {
+		return err
+	}
+	res, err := utils.ResolveConfig(profileName, flagValues)
+	if errors.Is(err, utils.ErrNoDefaultConfig) {
+		return withExitCode(ExitUsage, errors.New("连接参数不完整，请提供主机、用户名和数据库名，或先保存默认配置"))
+	}
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	config := res.Config.DbConfig()
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+	if err := db.ConnectConfig(config); err != nil {
+		return withExitCode(ExitConnect, fmt.Errorf("连接失败: %v", err))
+	}
+	handler.AfterConnect(res.Profile)
+	return nil
+}
+
+// validateConfig 检查连接配置是否满足数据库类型的要求，不满足时以参数错误退出
+func validateConfig(config *db.DbConfig) error {
+	driver, err := db.LookupDriver(config.Type)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if err := driver.Validate(config); err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	return nil
+}
+
+// typeFlagUsage 返回 --type 参数的说明，列出已注册的数据库类型
+func typeFlagUsage() string {
+	return fmt.Sprintf("数据库类型 (%s)", strings.Join(db.DriverNames(), ", "))
+}
+
+// disconnectQuietly 断开连接，非交互命令结束时调用
+func disconnectQuietly() {
+	if db.GetCurrentConnection() == nil {
+		return
+	}
+	if err := db.Disconnect(); err != nil {
+		fmt.Fprintln(os.Stderr, "断开连接失败:", err)
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+var (
+	execFlags    connFlags
+	execSQL      []string
+	execFile     string
+	execFormat   string
+	execContinue bool
+	execYes      bool
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec",
+	Short: "非交互方式执行SQL语句",
+	Long: `非交互方式执行SQL语句并将结果输出到标准输出，适用于定时任务和CI。
+连接信息默认取自已保存的配置，可用命令行参数覆盖。
+
+示例:
+  datamgr-cli exec -e "select * from users" --format json
+  echo "select count(*) from users" | datamgr-cli exec -e -
+  datamgr-cli exec -f report.sql --format csv > report.csv
+
+没有 WHERE 条件的 UPDATE、DELETE 以及 DROP、TRUNCATE 需要指定 --yes 才会执行。
+
+退出码: 0 成功, 1 语句执行失败, 2 参数错误, 3 无法连接数据库`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		script, err := readExecScript(cmd.InOrStdin())
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		statements := handler.SplitStatements(script)
+		if len(statements) == 0 {
+			return withExitCode(ExitUsage, errors.New("没有需要执行的语句，请使用 -e 或 -f 指定SQL"))
+		}
+		return runStatements(cmd, &execFlags, execFormat, statements, execContinue, execYes)
+	},
+}
+
+// readExecScript 汇总 -e 与 -f 指定的SQL，-e - 表示从标准输入读取
+func readExecScript(stdin io.Reader) (string, error) {
+	var parts []string
+	for _, sql := range execSQL {
+		if sql == "-" {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return "", fmt.Errorf("读取标准输入失败: %v", err)
+			}
+			sql = string(data)
+		}
+		parts = append(parts, sql)
+	}
+	if execFile != "" {
+		data, err := os.ReadFile(execFile)
+		if err != nil {
+			return "", fmt.Errorf("读取SQL文件失败: %v", err)
+		}
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, ";\n"), nil
+}
+
+// runStatements 连接数据库后依次执行语句，查询结果按指定格式写到标准输出，
+// force 为 true 时执行安全模式需要确认的语句
+func runStatements(cmd *cobra.Command, flags *connFlags, format string, statements []string, continueOnError, force bool) error {
+	if !output.IsValidFormat(format) {
+		return withExitCode(ExitUsage, fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", format, strings.Join(output.Formats(), ", ")))
+	}
+	if err := connectWithFlags(cmd, flags); err != nil {
+		return err
+	}
+	defer disconnectQuietly()
+
+	conn := db.GetCurrentConnection()
+	stdout := cmd.OutOrStdout()
+	stderr := cmd.ErrOrStderr()
+
+	var errs []error
+	for _, stmt := range statements {
+		err := runStatement(conn, stmt, nil, format, force, stdout, stderr)
+		if err == nil {
+			continue
+		}
+		errs = append(errs, err)
+		if !continueOnError {
+			break
+		}
+	}
+	return withExitCode(ExitFailure, errors.Join(errs...))
+}
+
+// formatFlagUsage 返回 --format 参数的说明
+func formatFlagUsage() string {
+	return fmt.Sprintf("输出格式 (%s)", strings.Join(output.Formats(), ", "))
+}
+
+// runStatement 执行单条语句并写入审计日志，args 为语句中占位符对应的参数。
+// 非交互方式无法确认，force 为 false 时拒绝安全模式需要确认的语句
+func runStatement(conn db.Connection, stmt string, args []interface{}, format string, force bool, stdout, stderr io.Writer) (err error) {
+	fields := strings.Fields(stmt)
+	switch strings.ToLower(fields[0]) {
+	case "import":
+		return handler.HandleImport(stmt)
+	case "export":
+		return handler.HandleExport(stmt)
+	}
+
+	start := time.Now()
+	var rows int64
+	defer func() { handler.RecordAudit(stmt, start, rows, err) }()
+
+	if err := handler.CheckWritable(stmt); err != nil {
+		return err
+	}
+	if impact, ok := handler.AnalyzeWrite(stmt); ok && !force {
+		if reasons := impact.Reasons(); len(reasons) > 0 {
+			return fmt.Errorf("%s，确认执行请指定 --yes", strings.Join(reasons, "；"))
+		}
+	}
+	if !handler.IsQueryStatement(stmt) {
+		var affected int64
+		var err error
+		if len(args) > 0 {
+			affected, err = conn.ExecuteWithParams(stmt, args...)
+		} else {
+			affected, err = conn.Execute(stmt)
+		}
+		if err != nil {
+			return handler.AnnotateSQLError(stmt, err)
+		}
+		rows = affected
+		fmt.Fprintf(stderr, "操作成功，影响了 %d 行数据\n", affected)
+		return nil
+	}
+
+	result, err := handler.QueryResultWithParams(conn, stmt, args...)
+	if err != nil {
+		return handler.AnnotateSQLError(stmt, err)
+	}
+	rows = int64(len(result.Rows))
+	if len(result.Columns) == 0 && output.Normalize(format) == output.FormatTable {
+		fmt.Fprintln(stderr, "查询没有返回结果")
+		return nil
+	}
+	// 只有输出到终端时才按终端宽度截断并使用颜色
+	opts := handler.RenderOptions()
+	if isTerminal(stdout) {
+		opts = handler.DisplayOptions()
+	}
+	return output.Render(stdout, format, result, opts)
+}
+
+func init() {
+	addConnFlags(execCmd, &execFlags)
+	execCmd.Flags().StringArrayVarP(&execSQL, "execute", "e", nil, "要执行的SQL语句，多条语句以分号分隔，- 表示从标准输入读取")
+	execCmd.Flags().StringVarP(&execFile, "file", "f", "", "从文件读取SQL语句")
+	execCmd.Flags().StringVar(&execFormat, "format", output.FormatTable, formatFlagUsage())
+	execCmd.Flags().BoolVar(&execContinue, "continue-on-error", false, "语句执行失败时继续执行后续语句")
+	execCmd.Flags().BoolVarP(&execYes, "yes", "y", false, "执行没有 WHERE 条件的 UPDATE、DELETE 以及 DROP、TRUNCATE 语句")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import "errors"
+
+// 退出码
+const (
+	ExitOK      = 0 // 执行成功
+	ExitFailure = 1 // 语句执行失败
+	ExitUsage   = 2 // 参数错误
+	ExitConnect = 3 // 无法连接数据库
+)
+
+// ExitError 带退出码的错误
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode 返回错误对应的进程退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
+
+// withExitCode 为错误附加退出码
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+var (
+	profileFlags connFlags
+	// profileReadOnly profile add --readonly 添加只读配置
+	profileReadOnly bool
+)
+
+var configProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "管理命名的连接配置",
+	Long: `配置文件中可以保存多个命名的连接配置，其中一个为默认配置。
+使用 connect @<名称> 或任意命令的 --profile <名称> 选择要使用的配置。`,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <名称>",
+	Short: "添加连接配置",
+	Example: `  datamgr-cli config profile add prod-dm --type dameng -H 10.0.0.5 -u SYSDBA -p secret -D PROD
+  datamgr-cli config profile add test-mysql --type mysql -H 127.0.0.1 -P 3306 -u root -D test`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 未指定类型和端口时使用默认数据库类型及该类型的默认端口
+		config := utils.NewConfig()
+		flagValues, err := changedConnFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if err := config.Apply(flagValues); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		config.ReadOnly = profileReadOnly
+		if err := validateConfig(config.DbConfig()); err != nil {
+			return err
+		}
+		if err := utils.AddProfile(args[0], config); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "已添加配置 %s\n", args[0])
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "列出连接配置",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := utils.LoadConfigFile()
+		if err != nil {
+			return err
+		}
+		printProfiles(cmd, file)
+		return nil
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:           "remove <名称>",
+	Aliases:       []string{"rm"},
+	Short:         "删除连接配置",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.RemoveProfile(args[0]); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "已删除配置 %s\n", args[0])
+		return nil
+	},
+}
+
+var profileRenameCmd = &cobra.Command{
+	Use:           "rename <原名称> <新名称>",
+	Short:         "重命名连接配置",
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.RenameProfile(args[0], args[1]); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "已将配置 %s 重命名为 %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var profileDefaultCmd = &cobra.Command{
+	Use:           "default <名称>",
+	Short:         "设置默认连接配置",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.SetDefaultProfile(args[0]); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "默认配置已设置为 %s\n", args[0])
+		return nil
+	},
+}
+
+var profileReadOnlyCmd = &cobra.Command{
+	Use:   "readonly <名称> [on|off]",
+	Short: "将连接配置设为只读",
+	Long: `使用只读配置连接时拒绝执行 INSERT、UPDATE、DELETE 等写入语句、DDL 和导入，
+查询中包含 INTO、FOR UPDATE 等写入或加锁的关键字时同样拒绝。省略 on|off 时为 on。`,
+	Args:          cobra.RangeArgs(1, 2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value := ""
+		if len(args) == 2 {
+			value = args[1]
+		}
+		readOnly, err := utils.ParseOnOff(value)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		if err := utils.SetProfileReadOnly(args[0], readOnly); err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		if readOnly {
+			fmt.Fprintf(cmd.OutOrStdout(), "配置 %s 已设为只读\n", args[0])
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "配置 %s 已取消只读\n", args[0])
+		}
+		return nil
+	},
+}
+
+// printProfiles 列出所有配置，默认配置以 * 标记
+func printProfiles(cmd *cobra.Command, file *utils.ConfigFile) {
+	out := cmd.OutOrStdout()
+	if len(file.Profiles) == 0 {
+		fmt.Fprintln(out, "没有保存的连接配置，可使用 'config profile add' 添加")
+		return
+	}
+	for _, name := range file.Names() {
+		marker := " "
+		if name == file.Default {
+			marker = "*"
+		}
+		fmt.Fprintf(out, "%s %-16s %s\n", marker, name, file.Profiles[name].Summary())
+	}
+}
+
+func init() {
+	addConnFlags(profileAddCmd, &profileFlags)
+	profileAddCmd.Flags().BoolVar(&profileReadOnly, "readonly", false, "添加为只读配置，拒绝执行写入语句、DDL 和导入")
+	configProfileCmd.AddCommand(profileAddCmd, profileListCmd, profileRemoveCmd, profileRenameCmd, profileDefaultCmd, profileReadOnlyCmd)
+	configCmd.AddCommand(configProfileCmd)
+}
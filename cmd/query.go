@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/queries"
+	"golang.org/x/term"
+)
+
+var (
+	queryFlags       connFlags
+	queryFormat      string
+	queryOutput      string
+	queryDescription string
+	queryYes         bool
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "管理和执行保存的查询",
+	Long: fmt.Sprintf(`管理保存在查询目录中的命名查询，每个查询是一个 <名称>.sql 文件。
+查询目录默认为配置目录下的 queries，可通过环境变量 %s 指向团队共享的目录。
+查询中可使用 :name 占位符（作为参数绑定）或 Go 模板 {{.name}}（按文本展开），
+文件开头以 "-- description:" 开始的注释为查询说明。`, queries.DirEnv),
+}
+
+var querySaveCmd = &cobra.Command{
+	Use:           "save <名称> <SQL语句>",
+	Short:         "保存查询",
+	Args:          cobra.MinimumNArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := queries.Save(args[0], queryDescription, strings.Join(args[1:], " "))
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "查询已保存到 %s\n", path)
+		return nil
+	},
+}
+
+var queryListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "列出保存的查询",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, err := queries.List()
+		if err != nil {
+			return err
+		}
+		out := cmd.OutOrStdout()
+		for _, q := range list {
+			fmt.Fprintf(out, "%s\t%s\t%s\n", q.Name, strings.Join(q.Params(), ","), q.Description)
+		}
+		return nil
+	},
+}
+
+var queryDeleteCmd = &cobra.Command{
+	Use:           "delete <名称>",
+	Short:         "删除保存的查询",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return queries.Delete(args[0])
+	},
+}
+
+var queryRunCmd = &cobra.Command{
+	Use:   "run <名称> [参数=值 ...]",
+	Short: "执行保存的查询",
+	Long: `连接数据库后执行保存的查询，查询结果按指定格式写到标准输出或 -o 指定的文件。
+未指定 --format 时按输出文件的扩展名选择格式。
+
+示例:
+  datamgr-cli query run monthly_report month=2024-05 --format xlsx -o out.xlsx`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q, err := queries.Load(args[0])
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		params, err := queries.ParseParams(args[1:])
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+		sql, err := q.Render(params)
+		if err != nil {
+			return withExitCode(ExitUsage, err)
+		}
+
+		format := queryFormat
+		if !cmd.Flags().Changed("format") && queryOutput != "" {
+			if ext := strings.TrimPrefix(filepath.Ext(queryOutput), "."); output.IsValidFormat(ext) {
+				format = ext
+			}
+		}
+		if !output.IsValidFormat(format) {
+			return withExitCode(ExitUsage, fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", format, strings.Join(output.Formats(), ", ")))
+		}
+
+		if queryOutput == "" && output.IsBinaryFormat(format) && isTerminal(cmd.OutOrStdout()) {
+			return withExitCode(ExitUsage, fmt.Errorf("%s 格式不能输出到终端，请使用 -o 指定输出文件", format))
+		}
+		return runQuery(cmd, sql, params, format, queryOutput)
+	},
+}
+
+// runQuery 连接数据库后依次执行查询中的语句，参数以占位符绑定，outputPath 不为空时结果写入该文件
+func runQuery(cmd *cobra.Command, sql string, params map[string]string, format, outputPath string) error {
+	statements := handler.SplitStatements(sql)
+	if len(statements) == 0 {
+		return withExitCode(ExitUsage, errors.New("查询内容为空"))
+	}
+	if err := connectWithFlags(cmd, &queryFlags); err != nil {
+		return err
+	}
+	defer disconnectQuietly()
+
+	stdout := cmd.OutOrStdout()
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return withExitCode(ExitUsage, fmt.Errorf("无法创建输出文件: %v", err))
+		}
+		defer file.Close()
+		stdout = file
+	}
+
+	conn := db.GetCurrentConnection()
+	dbType := db.GetCurrentConfig().Type
+	for _, stmt := range statements {
+		bound, args := handler.BindVariables(stmt, dbType, params)
+		if err := runStatement(conn, bound, args, format, queryYes, stdout, cmd.ErrOrStderr()); err != nil {
+			return withExitCode(ExitFailure, err)
+		}
+	}
+	return nil
+}
+
+// isTerminal 判断输出是否为终端
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+func init() {
+	querySaveCmd.Flags().StringVarP(&queryDescription, "desc", "d", "", "查询说明")
+	addConnFlags(queryRunCmd, &queryFlags)
+	queryRunCmd.Flags().StringVar(&queryFormat, "format", output.FormatTable, formatFlagUsage())
+	queryRunCmd.Flags().StringVarP(&queryOutput, "output", "o", "", "将结果写入文件")
+	queryRunCmd.Flags().BoolVarP(&queryYes, "yes", "y", false, "执行没有 WHERE 条件的 UPDATE、DELETE 以及 DROP、TRUNCATE 语句")
+	queryCmd.AddCommand(querySaveCmd, queryListCmd, queryDeleteCmd, queryRunCmd)
+}
@@ -6,13 +6,24 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
 	"github.com/yuanpli/datamgr-cli/pkg/prompt"
 )
 
+var (
+	// profileName --profile 指定的连接配置名，为空时使用默认配置
+	profileName string
+	// noRC --norc 连接后不执行启动脚本
+	noRC bool
+)
+
 var rootCmd = &cobra.Command{
 	Use:   filepath.Base(os.Args[0]),
 	Short: "通用CLI数据管理工具",
 	Long:  `一个支持多种数据库的通用数据管理命令行工具，提供统一的表管理操作接口。`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		handler.DisableRC(noRC)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// 启动交互式命令行
 		prompt.Start()
@@ -31,7 +42,14 @@ func ExecuteContext(ctx context.Context) error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "使用指定名称的连接配置")
+	rootCmd.PersistentFlags().BoolVar(&noRC, "norc", false, "连接后不执行启动脚本 rc.sql 和配置的启动脚本")
 	rootCmd.AddCommand(connectCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(browseCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(sqlCommands...)
 } 
\ No newline at end of file
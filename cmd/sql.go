@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+// sqlCommandFlags SQL子命令共用的参数
+type sqlCommandFlags struct {
+	conn   connFlags
+	format string
+}
+
+// newSQLCommand 创建以SQL关键字开头的非交互子命令，参数拼接为完整语句执行
+func newSQLCommand(keyword, short, example string, minArgs int) *cobra.Command {
+	flags := &sqlCommandFlags{}
+	cmd := &cobra.Command{
+		Use:           keyword + " <语句...>",
+		Short:         short,
+		Example:       example,
+		Args:          cobra.MinimumNArgs(minArgs),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stmt := keyword + " " + strings.Join(args, " ")
+			return runStatements(cmd, &flags.conn, flags.format, []string{stmt}, false, false)
+		},
+	}
+	addConnFlags(cmd, &flags.conn)
+	cmd.Flags().StringVar(&flags.format, "format", output.FormatTable, formatFlagUsage())
+	return cmd
+}
+
+var sqlCommands = []*cobra.Command{
+	newSQLCommand("select", "非交互方式执行查询", `  datamgr-cli select "*" from users where id = 1 --format json`, 1),
+	newSQLCommand("show", "非交互方式列出所有表", "  datamgr-cli show tables --format csv", 1),
+	newSQLCommand("desc", "非交互方式显示表结构", "  datamgr-cli desc table users --format json", 1),
+	newSQLCommand("import", "非交互方式导入数据", "  datamgr-cli import users from users.csv format csv", 3),
+	newSQLCommand("export", "非交互方式导出数据", "  datamgr-cli export users where status=1 users.xlsx", 2),
+}
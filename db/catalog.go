@@ -0,0 +1,76 @@
+package db
+
+import "fmt"
+
+// 目录查询类型
+const (
+	CatalogIndexes   = "indexes"   // 索引
+	CatalogViews     = "views"     // 视图
+	CatalogSchemas   = "schemas"   // 模式
+	CatalogDatabases = "databases" // 数据库
+)
+
+// oracleCatalog Oracle 和达梦共用的数据字典查询
+var oracleCatalog = map[string]string{
+	CatalogIndexes: `SELECT i.TABLE_NAME, i.INDEX_NAME,
+		LISTAGG(c.COLUMN_NAME, ',') WITHIN GROUP (ORDER BY c.COLUMN_POSITION) AS COLUMNS, i.UNIQUENESS
+		FROM USER_INDEXES i JOIN USER_IND_COLUMNS c ON i.INDEX_NAME = c.INDEX_NAME
+		GROUP BY i.TABLE_NAME, i.INDEX_NAME, i.UNIQUENESS
+		ORDER BY i.TABLE_NAME, i.INDEX_NAME`,
+	CatalogViews:     `SELECT VIEW_NAME FROM USER_VIEWS ORDER BY VIEW_NAME`,
+	CatalogSchemas:   `SELECT USERNAME AS SCHEMA_NAME FROM ALL_USERS ORDER BY USERNAME`,
+	CatalogDatabases: `SELECT SYS_CONTEXT('USERENV', 'DB_NAME') AS DATABASE_NAME FROM DUAL`,
+}
+
+// catalogQueries 各内置驱动列出索引、视图、模式和数据库的查询，注册驱动时引用
+var catalogQueries = map[string]map[string]string{
+	"mysql": {
+		CatalogIndexes: `SELECT TABLE_NAME, INDEX_NAME,
+			GROUP_CONCAT(COLUMN_NAME ORDER BY SEQ_IN_INDEX) AS COLUMNS,
+			CASE NON_UNIQUE WHEN 0 THEN 'UNIQUE' ELSE 'NONUNIQUE' END AS UNIQUENESS
+			FROM INFORMATION_SCHEMA.STATISTICS WHERE TABLE_SCHEMA = DATABASE()
+			GROUP BY TABLE_NAME, INDEX_NAME, NON_UNIQUE
+			ORDER BY TABLE_NAME, INDEX_NAME`,
+		CatalogViews:     `SELECT TABLE_NAME AS VIEW_NAME FROM INFORMATION_SCHEMA.VIEWS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME`,
+		CatalogSchemas:   `SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA ORDER BY SCHEMA_NAME`,
+		CatalogDatabases: `SELECT SCHEMA_NAME AS DATABASE_NAME FROM INFORMATION_SCHEMA.SCHEMATA ORDER BY SCHEMA_NAME`,
+	},
+	"postgresql": {
+		CatalogIndexes: `SELECT tablename AS table_name, indexname AS index_name, indexdef AS definition
+			FROM pg_indexes WHERE schemaname = current_schema() ORDER BY tablename, indexname`,
+		CatalogViews: `SELECT table_name AS view_name FROM information_schema.views
+			WHERE table_schema = current_schema() ORDER BY table_name`,
+		CatalogSchemas: `SELECT schema_name FROM information_schema.schemata
+			WHERE schema_name NOT LIKE 'pg\_%' AND schema_name <> 'information_schema' ORDER BY schema_name`,
+		CatalogDatabases: `SELECT datname AS database_name FROM pg_database WHERE NOT datistemplate ORDER BY datname`,
+	},
+	"mssql": {
+		CatalogIndexes: `SELECT t.name AS table_name, i.name AS index_name, i.type_desc,
+			CASE i.is_unique WHEN 1 THEN 'UNIQUE' ELSE 'NONUNIQUE' END AS uniqueness
+			FROM sys.indexes i JOIN sys.tables t ON i.object_id = t.object_id
+			WHERE i.name IS NOT NULL ORDER BY t.name, i.name`,
+		CatalogViews:     `SELECT name AS view_name FROM sys.views ORDER BY name`,
+		CatalogSchemas:   `SELECT name AS schema_name FROM sys.schemas ORDER BY name`,
+		CatalogDatabases: `SELECT name AS database_name FROM sys.databases ORDER BY name`,
+	},
+	"oracle": oracleCatalog,
+	"dameng": {
+		CatalogIndexes:   oracleCatalog[CatalogIndexes],
+		CatalogViews:     oracleCatalog[CatalogViews],
+		CatalogSchemas:   `SELECT NAME AS SCHEMA_NAME FROM SYSOBJECTS WHERE TYPE$ = 'SCH' ORDER BY NAME`,
+		CatalogDatabases: `SELECT NAME AS DATABASE_NAME FROM V$DATABASE`,
+	},
+}
+
+// CatalogQuery 返回指定数据库类型列出索引、视图、模式或数据库的查询语句
+func CatalogQuery(dbType, kind string) (string, error) {
+	driver, err := LookupDriver(dbType)
+	if err != nil {
+		return "", err
+	}
+	query, ok := driver.Catalog[kind]
+	if !ok {
+		return "", fmt.Errorf("%s 数据库不支持列出%s", dbType, kind)
+	}
+	return query, nil
+}
@@ -1,10 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
 	"strings"
-	"time"
 
 	_ "gitee.com/chunanyong/dm"
 )
@@ -15,6 +16,25 @@ type DamengConnection struct {
 	db     *sql.DB
 }
 
+func init() {
+	Register(&Driver{
+		Name:        "dameng",
+		Title:       "DaMeng",
+		DefaultPort: 5236,
+		Required:    []string{FieldHost, FieldUser, FieldDbName},
+		Optional:    []string{FieldPort, FieldPassword},
+		Options: []Option{
+			{Name: "charset", Description: "客户端字符集", Default: "utf8"},
+			{Name: "schema", Description: "连接后使用的模式"},
+		},
+		Features: []string{FeatureStream, FeatureCatalog},
+		Catalog:  catalogQueries["dameng"],
+		New: func(config *DbConfig) (Connection, error) {
+			return NewDamengConnection(config)
+		},
+	})
+}
+
 // NewDamengConnection 创建达梦数据库连接
 func NewDamengConnection(config *DbConfig) (*DamengConnection, error) {
 	return &DamengConnection{
@@ -24,8 +44,13 @@ func NewDamengConnection(config *DbConfig) (*DamengConnection, error) {
 
 // Connect 连接到达梦数据库
 func (d *DamengConnection) Connect() error {
-	connectionString := fmt.Sprintf("dm://%s:%s@%s:%d/%s?charset=utf8",
-		d.config.User, d.config.Password, d.config.Host, d.config.Port, d.config.DbName)
+	params := url.Values{}
+	params.Set("charset", d.config.Option("charset"))
+	if schema := d.config.Option("schema"); schema != "" {
+		params.Set("schema", schema)
+	}
+	connectionString := fmt.Sprintf("dm://%s:%s@%s:%d/%s?%s",
+		d.config.User, d.config.Password, d.config.Host, d.config.Port, d.config.DbName, params.Encode())
 
 	db, err := sql.Open("dm", connectionString)
 	if err != nil {
@@ -49,36 +74,6 @@ func (d *DamengConnection) Disconnect() error {
 	return nil
 }
 
-// formatDateTime 格式化时间为标准格式，只保留到秒
-func formatDateTime(val interface{}) interface{} {
-	if val == nil {
-		return val
-	}
-	
-	// 如果是字符串，处理成标准格式
-	if strVal, ok := val.(string); ok && (strings.Contains(strVal, "-") || strings.Contains(strVal, ":")) {
-		// 处理带时区的格式
-		if strings.Contains(strVal, "+") {
-			parts := strings.Split(strVal, " ")
-			if len(parts) >= 2 {
-				strVal = parts[0] + " " + parts[1]
-				if strings.Contains(strVal, ".") {
-					timeParts := strings.Split(strVal, ".")
-					strVal = timeParts[0]  // 只保留到秒
-				}
-			}
-		}
-		return strVal
-	}
-	
-	// 如果是time.Time类型，格式化为标准格式
-	if timeVal, ok := val.(time.Time); ok {
-		return timeVal.Format("2006-01-02 15:04:05")
-	}
-	
-	return val
-}
-
 // Query 执行查询语句
 func (d *DamengConnection) Query(query string) ([]map[string]interface{}, error) {
 	if d.db == nil {
@@ -95,6 +90,7 @@ func (d *DamengConnection) Query(query string) ([]map[string]interface{}, error)
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -112,13 +108,7 @@ func (d *DamengConnection) Query(query string) ([]map[string]interface{}, error)
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatDateTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -146,6 +136,7 @@ func (d *DamengConnection) QueryWithParams(query string, args ...interface{}) ([
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -163,13 +154,7 @@ func (d *DamengConnection) QueryWithParams(query string, args ...interface{}) ([
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatDateTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -181,6 +166,28 @@ func (d *DamengConnection) QueryWithParams(query string, args ...interface{}) ([
 	return results, nil
 }
 
+// BeginTx 开始事务
+func (d *DamengConnection) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if d.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	return d.db.BeginTx(ctx, nil)
+}
+
+// QueryStream 执行可取消的查询，逐行读取结果
+func (d *DamengConnection) QueryStream(ctx context.Context, query string, args ...interface{}) (*RowIterator, error) {
+	if d.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRowIterator(rows)
+}
+
 // Execute 执行更新/插入/删除语句
 func (d *DamengConnection) Execute(query string) (int64, error) {
 	if d.db == nil {
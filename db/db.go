@@ -2,7 +2,6 @@ package db
 
 import (
 	"errors"
-	"fmt"
 	"sync"
 )
 
@@ -14,6 +13,10 @@ type DbConfig struct {
 	User     string
 	Password string
 	DbName   string
+	// Options 驱动专用的连接选项，见 Driver.Options
+	Options map[string]string
+	// ReadOnly 只读连接，拒绝执行写入语句、DDL 和导入
+	ReadOnly bool
 }
 
 // Connection 数据库连接接口
@@ -35,45 +38,39 @@ var (
 	mu                sync.Mutex
 )
 
-// Connect 连接到数据库
+// Connect 连接到数据库，不使用连接选项
 func Connect(dbType, host string, port int, user, password, dbName string) error {
-	mu.Lock()
-	defer mu.Unlock()
-
-	config := &DbConfig{
+	return ConnectConfig(&DbConfig{
 		Type:     dbType,
 		Host:     host,
 		Port:     port,
 		User:     user,
 		Password: password,
 		DbName:   dbName,
-	}
+	})
+}
 
-	var conn Connection
-	var err error
+// ConnectConfig 使用已注册的驱动连接到数据库，连接前检查驱动要求的参数和连接选项，
+// 未指定端口时使用驱动的默认端口
+func ConnectConfig(config *DbConfig) error {
+	mu.Lock()
+	defer mu.Unlock()
 
-	// 根据数据库类型创建不同的连接实例
-	switch dbType {
-	case "dameng":
-		conn, err = NewDamengConnection(config)
-	case "postgresql":
-		conn, err = NewPostgresConnection(config)
-	case "mysql":
-		conn, err = NewMySQLConnection(config)
-	case "oracle":
-		conn, err = NewOracleConnection(config)
-	case "mssql":
-		conn, err = NewMSSQLConnection(config)
-	case "sqlite":
-		return errors.New("SQLite 数据库支持尚未实现")
-	default:
-		return fmt.Errorf("不支持的数据库类型: %s", dbType)
+	driver, err := LookupDriver(config.Type)
+	if err != nil {
+		return err
+	}
+	if err := driver.Validate(config); err != nil {
+		return err
+	}
+	if config.Port == 0 {
+		config.Port = driver.DefaultPort
 	}
 
+	conn, err := driver.New(config)
 	if err != nil {
 		return err
 	}
-
 	if err := conn.Connect(); err != nil {
 		return err
 	}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// mysqlNearPattern MySQL语法错误中的出错位置，near之后为语句从出错处开始的内容
+	mysqlNearPattern = regexp.MustCompile(`(?s)near '(.*)' at line (\d+)`)
+	// oraclePositionPattern Oracle错误信息中的出错位置
+	oraclePositionPattern = regexp.MustCompile(`ORA-\d+.*error occur at position: (\d+)`)
+)
+
+// ErrorPosition 从数据库返回的错误中解析出错位置，返回在query中的字节偏移
+func ErrorPosition(err error, query string) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	// PostgreSQL 返回从1开始的字符位置
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		pos, convErr := strconv.Atoi(pqErr.Position)
+		if convErr != nil || pos <= 0 {
+			return 0, false
+		}
+		return runeOffset(query, pos-1)
+	}
+
+	// Oracle 返回从0开始的字符位置，0通常表示没有位置信息
+	var oraErr interface{ ErrPos() int }
+	if errors.As(err, &oraErr) {
+		if pos := oraErr.ErrPos(); pos > 0 {
+			return runeOffset(query, pos)
+		}
+		return 0, false
+	}
+	if m := oraclePositionPattern.FindStringSubmatch(err.Error()); m != nil {
+		if pos, _ := strconv.Atoi(m[1]); pos > 0 {
+			return runeOffset(query, pos)
+		}
+		return 0, false
+	}
+
+	// MySQL 返回出错处开始的语句内容及行号
+	if m := mysqlNearPattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		return nearOffset(query, m[1], line)
+	}
+	return 0, false
+}
+
+// runeOffset 将字符位置转换为字节偏移
+func runeOffset(query string, n int) (int, bool) {
+	offset := 0
+	for i := 0; i < n; i++ {
+		if offset >= len(query) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(query[offset:])
+		offset += size
+	}
+	return offset, offset <= len(query)
+}
+
+// nearOffset 在第line行及之后查找near的内容，near为空表示语句末尾
+func nearOffset(query, near string, line int) (int, bool) {
+	if near == "" {
+		return len(strings.TrimRight(query, " \t\r\n;")), true
+	}
+	start := 0
+	for i := 1; i < line; i++ {
+		j := strings.IndexByte(query[start:], '\n')
+		if j < 0 {
+			break
+		}
+		start += j + 1
+	}
+	if i := strings.Index(query[start:], near); i >= 0 {
+		return start + i, true
+	}
+	// MySQL 会截断过长的内容，只按首行查找
+	first := strings.SplitN(near, "\n", 2)[0]
+	if i := strings.Index(query[start:], first); i >= 0 {
+		return start + i, true
+	}
+	return 0, false
+}
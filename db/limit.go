@@ -0,0 +1,28 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LimitQuery 为指定数据库类型的 SELECT 语句加上最多返回 n 行的限制，query 不能已有 LIMIT 等子句
+func LimitQuery(dbType, query string, n int) string {
+	if driver, err := LookupDriver(dbType); err == nil && driver.Limit != nil {
+		return driver.Limit(query, n)
+	}
+	// 达梦、MySQL、PostgreSQL 等使用 LIMIT
+	return fmt.Sprintf("%s LIMIT %d", query, n)
+}
+
+// rownumLimit Oracle 用 ROWNUM 限制行数，子查询保留其中的 ORDER BY
+func rownumLimit(query string, n int) string {
+	return fmt.Sprintf("SELECT * FROM (%s) WHERE ROWNUM <= %d", query, n)
+}
+
+// fetchLimit MS SQL Server 的 OFFSET ... FETCH 需要 ORDER BY，没有时按 (SELECT NULL) 排序
+func fetchLimit(query string, n int) string {
+	if !strings.Contains(strings.ToUpper(query), "ORDER BY") {
+		query += " ORDER BY (SELECT NULL)"
+	}
+	return fmt.Sprintf("%s OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", query, n)
+}
@@ -1,10 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
 	"strings"
-	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -15,6 +16,26 @@ type MySQLConnection struct {
 	db     *sql.DB
 }
 
+func init() {
+	Register(&Driver{
+		Name:        "mysql",
+		Title:       "MySQL",
+		DefaultPort: 3306,
+		Required:    []string{FieldHost, FieldUser, FieldDbName},
+		Optional:    []string{FieldPort, FieldPassword},
+		Options: []Option{
+			{Name: "charset", Description: "连接字符集，如 utf8mb4"},
+			{Name: "tls", Description: "是否使用 TLS 连接", Values: []string{"false", "true", "skip-verify", "preferred"}},
+			{Name: "timeout", Description: "建立连接的超时时间，如 5s"},
+		},
+		Features: []string{FeatureStream, FeatureCatalog, FeatureErrorPosition},
+		Catalog:  catalogQueries["mysql"],
+		New: func(config *DbConfig) (Connection, error) {
+			return NewMySQLConnection(config)
+		},
+	})
+}
+
 // NewMySQLConnection 创建MySQL数据库连接
 func NewMySQLConnection(config *DbConfig) (*MySQLConnection, error) {
 	return &MySQLConnection{
@@ -26,6 +47,14 @@ func NewMySQLConnection(config *DbConfig) (*MySQLConnection, error) {
 func (m *MySQLConnection) Connect() error {
 	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
 		m.config.User, m.config.Password, m.config.Host, m.config.Port, m.config.DbName)
+	// 时间列扫描为 time.Time，DATETIME 按本地时区解释，显示时由设置统一格式化
+	params := url.Values{"parseTime": {"true"}, "loc": {"Local"}}
+	for _, name := range []string{"charset", "tls", "timeout"} {
+		if value := m.config.Option(name); value != "" {
+			params.Set(name, value)
+		}
+	}
+	connectionString += "?" + params.Encode()
 
 	db, err := sql.Open("mysql", connectionString)
 	if err != nil {
@@ -49,39 +78,6 @@ func (m *MySQLConnection) Disconnect() error {
 	return nil
 }
 
-// formatMySQLTime 格式化MySQL时间为标准格式
-func formatMySQLTime(val interface{}) interface{} {
-	if val == nil {
-		return val
-	}
-	
-	// 如果是字符串，处理成标准格式
-	if strVal, ok := val.(string); ok && (strings.Contains(strVal, "-") || strings.Contains(strVal, ":")) {
-		// 处理带时区的格式
-		if strings.Contains(strVal, "+") || strings.Contains(strVal, "Z") {
-			parts := strings.Fields(strVal)
-			if len(parts) >= 1 {
-				strVal = parts[0]
-				if len(parts) >= 2 {
-					strVal += " " + parts[1]
-				}
-				if strings.Contains(strVal, ".") {
-					timeParts := strings.Split(strVal, ".")
-					strVal = timeParts[0]  // 只保留到秒
-				}
-			}
-		}
-		return strVal
-	}
-	
-	// 如果是time.Time类型，格式化为标准格式
-	if timeVal, ok := val.(time.Time); ok {
-		return timeVal.Format("2006-01-02 15:04:05")
-	}
-	
-	return val
-}
-
 // Query 执行查询语句
 func (m *MySQLConnection) Query(query string) ([]map[string]interface{}, error) {
 	if m.db == nil {
@@ -98,6 +94,7 @@ func (m *MySQLConnection) Query(query string) ([]map[string]interface{}, error)
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -115,13 +112,7 @@ func (m *MySQLConnection) Query(query string) ([]map[string]interface{}, error)
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatMySQLTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -149,6 +140,7 @@ func (m *MySQLConnection) QueryWithParams(query string, args ...interface{}) ([]
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -166,13 +158,7 @@ func (m *MySQLConnection) QueryWithParams(query string, args ...interface{}) ([]
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatMySQLTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -184,6 +170,28 @@ func (m *MySQLConnection) QueryWithParams(query string, args ...interface{}) ([]
 	return results, nil
 }
 
+// BeginTx 开始事务
+func (m *MySQLConnection) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if m.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	return m.db.BeginTx(ctx, nil)
+}
+
+// QueryStream 执行可取消的查询，逐行读取结果
+func (m *MySQLConnection) QueryStream(ctx context.Context, query string, args ...interface{}) (*RowIterator, error) {
+	if m.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRowIterator(rows)
+}
+
 // Execute 执行更新/插入/删除语句
 func (m *MySQLConnection) Execute(query string) (int64, error) {
 	if m.db == nil {
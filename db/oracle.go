@@ -1,10 +1,9 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"strings"
-	"time"
 
 	_ "github.com/sijms/go-ora/v2"
 )
@@ -15,6 +14,21 @@ type OracleConnection struct {
 	db     *sql.DB
 }
 
+func init() {
+	Register(&Driver{
+		Name:        "oracle",
+		Title:       "Oracle",
+		DefaultPort: 1521,
+		Required:    []string{FieldHost, FieldUser, FieldDbName},
+		Optional:    []string{FieldPort, FieldPassword},
+		Features:    []string{FeatureStream, FeatureCatalog, FeatureErrorPosition},
+		Catalog:     catalogQueries["oracle"],
+		Placeholder: colonPlaceholder,
+		Limit:       rownumLimit,
+		New:         NewOracleConnection,
+	})
+}
+
 // NewOracleConnection 创建Oracle数据库连接
 func NewOracleConnection(config *DbConfig) (Connection, error) {
 	return &OracleConnection{
@@ -52,39 +66,6 @@ func (o *OracleConnection) Disconnect() error {
 	return nil
 }
 
-// formatOracleTime 格式化Oracle时间为标准格式
-func formatOracleTime(val interface{}) interface{} {
-	if val == nil {
-		return nil
-	}
-	
-	// 如果是字符串，处理成标准格式
-	if strVal, ok := val.(string); ok && (strings.Contains(strVal, "-") || strings.Contains(strVal, "/")) {
-		// 处理日期格式
-		if strings.Contains(strVal, "+") || strings.Contains(strVal, "Z") {
-			parts := strings.Fields(strVal)
-			if len(parts) >= 1 {
-				strVal = parts[0]
-				if len(parts) >= 2 {
-					strVal += " " + parts[1]
-				}
-				if strings.Contains(strVal, ".") {
-					timeParts := strings.Split(strVal, ".")
-					strVal = timeParts[0]  // 只保留到秒
-				}
-			}
-		}
-		return strVal
-	}
-	
-	// 如果是time.Time类型，格式化为标准格式
-	if timeVal, ok := val.(time.Time); ok {
-		return timeVal.Format("2006-01-02 15:04:05")
-	}
-	
-	return val
-}
-
 // Query 执行查询语句
 func (o *OracleConnection) Query(query string) ([]map[string]interface{}, error) {
 	if o.db == nil {
@@ -101,6 +82,7 @@ func (o *OracleConnection) Query(query string) ([]map[string]interface{}, error)
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -118,13 +100,7 @@ func (o *OracleConnection) Query(query string) ([]map[string]interface{}, error)
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatOracleTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -152,6 +128,7 @@ func (o *OracleConnection) QueryWithParams(query string, args ...interface{}) ([
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -169,13 +146,7 @@ func (o *OracleConnection) QueryWithParams(query string, args ...interface{}) ([
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatOracleTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -187,6 +158,28 @@ func (o *OracleConnection) QueryWithParams(query string, args ...interface{}) ([
 	return results, nil
 }
 
+// BeginTx 开始事务
+func (o *OracleConnection) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if o.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	return o.db.BeginTx(ctx, nil)
+}
+
+// QueryStream 执行可取消的查询，逐行读取结果
+func (o *OracleConnection) QueryStream(ctx context.Context, query string, args ...interface{}) (*RowIterator, error) {
+	if o.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRowIterator(rows)
+}
+
 // Execute 执行更新/插入/删除语句
 func (o *OracleConnection) Execute(query string) (int64, error) {
 	if o.db == nil {
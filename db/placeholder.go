@@ -0,0 +1,27 @@
+package db
+
+import "fmt"
+
+// Placeholder 返回指定数据库类型第n个(从1开始)参数的占位符
+func Placeholder(dbType string, n int) string {
+	if driver, err := LookupDriver(dbType); err == nil && driver.Placeholder != nil {
+		return driver.Placeholder(n)
+	}
+	// 达梦、MySQL等使用问号占位符
+	return "?"
+}
+
+// dollarPlaceholder PostgreSQL 的 $1、$2 占位符
+func dollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// colonPlaceholder Oracle 的 :1、:2 占位符
+func colonPlaceholder(n int) string {
+	return fmt.Sprintf(":%d", n)
+}
+
+// atPlaceholder MS SQL Server 的 @p1、@p2 占位符
+func atPlaceholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
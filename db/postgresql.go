@@ -1,10 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
-	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -15,6 +15,27 @@ type PostgresConnection struct {
 	db     *sql.DB
 }
 
+func init() {
+	Register(&Driver{
+		Name:        "postgresql",
+		Title:       "PostgreSQL",
+		DefaultPort: 5432,
+		Required:    []string{FieldHost, FieldUser, FieldDbName},
+		Optional:    []string{FieldPort, FieldPassword},
+		Options: []Option{
+			{Name: "sslmode", Description: "SSL 模式", Default: "disable",
+				Values: []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}},
+			{Name: "connect_timeout", Description: "建立连接的超时秒数"},
+		},
+		Features:    []string{FeatureStream, FeatureCatalog, FeatureErrorPosition},
+		Catalog:     catalogQueries["postgresql"],
+		Placeholder: dollarPlaceholder,
+		New: func(config *DbConfig) (Connection, error) {
+			return NewPostgresConnection(config)
+		},
+	})
+}
+
 // NewPostgresConnection 创建PostgreSQL数据库连接
 func NewPostgresConnection(config *DbConfig) (*PostgresConnection, error) {
 	return &PostgresConnection{
@@ -24,8 +45,11 @@ func NewPostgresConnection(config *DbConfig) (*PostgresConnection, error) {
 
 // Connect 连接到PostgreSQL数据库
 func (p *PostgresConnection) Connect() error {
-	connectionString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		p.config.Host, p.config.Port, p.config.User, p.config.Password, p.config.DbName)
+	connectionString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		p.config.Host, p.config.Port, p.config.User, p.config.Password, p.config.DbName, p.config.Option("sslmode"))
+	if timeout := p.config.Option("connect_timeout"); timeout != "" {
+		connectionString += " connect_timeout=" + timeout
+	}
 
 	db, err := sql.Open("postgres", connectionString)
 	if err != nil {
@@ -49,36 +73,6 @@ func (p *PostgresConnection) Disconnect() error {
 	return nil
 }
 
-// formatPostgresTime 格式化PostgreSQL时间为标准格式
-func formatPostgresTime(val interface{}) interface{} {
-	if val == nil {
-		return val
-	}
-	
-	// 如果是字符串，处理成标准格式
-	if strVal, ok := val.(string); ok && (strings.Contains(strVal, "-") || strings.Contains(strVal, ":")) {
-		// 处理带时区的格式
-		if strings.Contains(strVal, "+") || strings.Contains(strVal, "-") {
-			parts := strings.Fields(strVal)
-			if len(parts) >= 2 {
-				strVal = parts[0] + " " + parts[1]
-				if strings.Contains(strVal, ".") {
-					timeParts := strings.Split(strVal, ".")
-					strVal = timeParts[0]  // 只保留到秒
-				}
-			}
-		}
-		return strVal
-	}
-	
-	// 如果是time.Time类型，格式化为标准格式
-	if timeVal, ok := val.(time.Time); ok {
-		return timeVal.Format("2006-01-02 15:04:05")
-	}
-	
-	return val
-}
-
 // Query 执行查询语句
 func (p *PostgresConnection) Query(query string) ([]map[string]interface{}, error) {
 	if p.db == nil {
@@ -95,6 +89,7 @@ func (p *PostgresConnection) Query(query string) ([]map[string]interface{}, erro
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -112,13 +107,7 @@ func (p *PostgresConnection) Query(query string) ([]map[string]interface{}, erro
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatPostgresTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -146,6 +135,7 @@ func (p *PostgresConnection) QueryWithParams(query string, args ...interface{})
 	if err != nil {
 		return nil, err
 	}
+	binary := binaryColumns(rows)
 
 	results := make([]map[string]interface{}, 0)
 	values := make([]interface{}, len(columns))
@@ -163,13 +153,7 @@ func (p *PostgresConnection) QueryWithParams(query string, args ...interface{})
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				// 格式化时间类型值
-				row[col] = formatPostgresTime(val)
-			}
+			row[col] = scanValue(values[i], binary[i])
 		}
 		results = append(results, row)
 	}
@@ -181,6 +165,28 @@ func (p *PostgresConnection) QueryWithParams(query string, args ...interface{})
 	return results, nil
 }
 
+// BeginTx 开始事务
+func (p *PostgresConnection) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	if p.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	return p.db.BeginTx(ctx, nil)
+}
+
+// QueryStream 执行可取消的查询，逐行读取结果
+func (p *PostgresConnection) QueryStream(ctx context.Context, query string, args ...interface{}) (*RowIterator, error) {
+	if p.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRowIterator(rows)
+}
+
 // Execute 执行更新/插入/删除语句
 func (p *PostgresConnection) Execute(query string) (int64, error) {
 	if p.db == nil {
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultDriver 未指定数据库类型时使用的驱动
+const DefaultDriver = "dameng"
+
+// 连接参数字段，与配置文件中的字段名相同
+const (
+	FieldHost     = "host"
+	FieldPort     = "port"
+	FieldUser     = "user"
+	FieldPassword = "password"
+	FieldDbName   = "dbname"
+)
+
+// Fields 所有连接参数字段，按连接向导的提问顺序排列
+var Fields = []string{FieldHost, FieldPort, FieldUser, FieldPassword, FieldDbName}
+
+// fieldLabels 连接参数字段的显示名称
+var fieldLabels = map[string]string{
+	FieldHost:     "主机地址",
+	FieldPort:     "端口",
+	FieldUser:     "用户名",
+	FieldPassword: "密码",
+	FieldDbName:   "数据库名",
+}
+
+// FieldLabel 返回连接参数字段的显示名称
+func FieldLabel(field string) string {
+	if label, ok := fieldLabels[field]; ok {
+		return label
+	}
+	return field
+}
+
+// 驱动支持的功能
+const (
+	FeatureStream        = "stream"  // 逐行读取结果，执行中可按 Ctrl+C 取消
+	FeatureCatalog       = "catalog" // 列出索引、视图、模式和数据库（\di、\dv、\dn、\l）
+	FeatureErrorPosition = "errpos"  // 语法错误时标出出错位置
+)
+
+// Option 驱动专用的连接选项，如 PostgreSQL 的 sslmode
+type Option struct {
+	Name        string
+	Description string
+	Default     string
+	// Values 允许的取值，为空时不限制
+	Values []string
+}
+
+// Driver 数据库驱动的描述，内置驱动和第三方驱动都通过 Register 注册
+type Driver struct {
+	// Name 数据库类型，即 --type 和配置文件中使用的名称
+	Name string
+	// Title 显示名称
+	Title string
+	// DefaultPort 默认端口，为 0 时不使用端口
+	DefaultPort int
+	// Required 必须提供的连接参数
+	Required []string
+	// Optional 可以提供的连接参数，未列出的字段在连接向导中不会询问
+	Optional []string
+	// Options 驱动专用的连接选项
+	Options []Option
+	// Features 支持的功能
+	Features []string
+	// Catalog 列出索引、视图、模式和数据库的查询，键为 CatalogIndexes 等
+	Catalog map[string]string
+	// Placeholder 返回第 n 个(从1开始)参数的占位符，为空时使用问号
+	Placeholder func(n int) string
+	// Limit 为 SELECT 语句加上最多返回 n 行的限制，为空时使用 LIMIT n
+	Limit func(query string, n int) string
+	// New 创建连接实例
+	New func(config *DbConfig) (Connection, error)
+}
+
+var (
+	drivers   = make(map[string]*Driver)
+	driversMu sync.RWMutex
+)
+
+// Register 注册数据库驱动，名称为空、缺少 New 或重复注册时 panic，通常在 init 中调用
+func Register(driver *Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil || driver.Name == "" || driver.New == nil {
+		panic("db: Register 需要驱动名称和 New 函数")
+	}
+	if _, dup := drivers[driver.Name]; dup {
+		panic("db: 重复注册驱动 " + driver.Name)
+	}
+	drivers[driver.Name] = driver
+}
+
+// LookupDriver 返回指定数据库类型的驱动
+func LookupDriver(name string) (*Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	if driver, ok := drivers[name]; ok {
+		return driver, nil
+	}
+	return nil, fmt.Errorf("不支持的数据库类型: %s，支持的类型为: %s", name, strings.Join(driverNames(), ", "))
+}
+
+// Drivers 返回所有已注册的驱动，默认驱动在前，其余按名称排序
+func Drivers() []*Driver {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	list := make([]*Driver, 0, len(drivers))
+	for _, name := range driverNames() {
+		list = append(list, drivers[name])
+	}
+	return list
+}
+
+// DriverNames 返回所有已注册的数据库类型
+func DriverNames() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return driverNames()
+}
+
+func driverNames() []string {
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == DefaultDriver) != (names[j] == DefaultDriver) {
+			return names[i] == DefaultDriver
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Fields 返回驱动使用的连接参数，按连接向导的提问顺序排列
+func (d *Driver) Fields() []string {
+	var fields []string
+	for _, field := range Fields {
+		if d.IsRequired(field) || contains(d.Optional, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// IsRequired 判断连接参数是否必须提供
+func (d *Driver) IsRequired(field string) bool {
+	return contains(d.Required, field)
+}
+
+// UsesField 判断驱动是否使用该连接参数
+func (d *Driver) UsesField(field string) bool {
+	return d.IsRequired(field) || contains(d.Optional, field)
+}
+
+// HasFeature 判断驱动是否支持指定功能
+func (d *Driver) HasFeature(feature string) bool {
+	return contains(d.Features, feature)
+}
+
+// Option 返回指定名称的连接选项
+func (d *Driver) Option(name string) (*Option, bool) {
+	for i := range d.Options {
+		if d.Options[i].Name == name {
+			return &d.Options[i], true
+		}
+	}
+	return nil, false
+}
+
+// OptionNames 返回驱动的所有连接选项名称
+func (d *Driver) OptionNames() []string {
+	names := make([]string, len(d.Options))
+	for i, opt := range d.Options {
+		names[i] = opt.Name
+	}
+	return names
+}
+
+// ValidateOption 检查连接选项的名称和取值
+func (d *Driver) ValidateOption(name, value string) error {
+	opt, ok := d.Option(name)
+	if !ok {
+		if len(d.Options) == 0 {
+			return fmt.Errorf("%s 数据库没有连接选项 %s", d.Name, name)
+		}
+		return fmt.Errorf("%s 数据库没有连接选项 %s，可用的选项为: %s", d.Name, name, strings.Join(d.OptionNames(), ", "))
+	}
+	if value != "" && len(opt.Values) > 0 && !contains(opt.Values, value) {
+		return fmt.Errorf("选项 %s 的值无效: %s，可选值为: %s", name, value, strings.Join(opt.Values, ", "))
+	}
+	return nil
+}
+
+// Validate 检查连接配置是否提供了驱动要求的参数，连接选项是否有效
+func (d *Driver) Validate(config *DbConfig) error {
+	var missing []string
+	for _, field := range d.Required {
+		if config.Field(field) == "" {
+			missing = append(missing, FieldLabel(field))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("连接参数不完整，%s 数据库需要提供%s", d.Name, strings.Join(missing, "、"))
+	}
+	for name, value := range config.Options {
+		if err := d.ValidateOption(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary 返回驱动的单行说明，用于帮助信息
+func (d *Driver) Summary() string {
+	var parts []string
+	if d.DefaultPort > 0 {
+		parts = append(parts, fmt.Sprintf("默认端口 %d", d.DefaultPort))
+	}
+	if len(d.Options) > 0 {
+		parts = append(parts, "选项: "+strings.Join(d.OptionNames(), ", "))
+	}
+	if len(d.Features) > 0 {
+		parts = append(parts, "功能: "+strings.Join(d.Features, ", "))
+	}
+	return fmt.Sprintf("%-12s %-16s %s", d.Name, d.Title, strings.Join(parts, "；"))
+}
+
+// Field 返回连接参数字段的值
+func (c *DbConfig) Field(field string) string {
+	switch field {
+	case FieldHost:
+		return c.Host
+	case FieldPort:
+		if c.Port == 0 {
+			return ""
+		}
+		return fmt.Sprint(c.Port)
+	case FieldUser:
+		return c.User
+	case FieldPassword:
+		return c.Password
+	case FieldDbName:
+		return c.DbName
+	}
+	return ""
+}
+
+// Option 返回连接选项的值，未设置时为驱动声明的默认值
+func (c *DbConfig) Option(name string) string {
+	if value, ok := c.Options[name]; ok && value != "" {
+		return value
+	}
+	if driver, err := LookupDriver(c.Type); err == nil {
+		if opt, ok := driver.Option(name); ok {
+			return opt.Default
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// StreamQuerier 支持取消和逐行读取结果的连接
+type StreamQuerier interface {
+	QueryStream(ctx context.Context, query string, args ...interface{}) (*RowIterator, error)
+}
+
+// RowIterator 逐行读取查询结果，列顺序与查询结果一致
+type RowIterator struct {
+	rows     *sql.Rows
+	columns  []string
+	binary   []bool // 各列是否为二进制类型
+	values   []interface{}
+	scanArgs []interface{}
+}
+
+// NewRowIterator 创建行迭代器，第三方驱动实现 StreamQuerier 时也可以使用
+func NewRowIterator(rows *sql.Rows) (*RowIterator, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	it := &RowIterator{
+		rows:     rows,
+		columns:  columns,
+		binary:   binaryColumns(rows),
+		values:   make([]interface{}, len(columns)),
+		scanArgs: make([]interface{}, len(columns)),
+	}
+	for i := range it.values {
+		it.scanArgs[i] = &it.values[i]
+	}
+	return it, nil
+}
+
+// Columns 返回结果列名
+func (it *RowIterator) Columns() []string {
+	return it.columns
+}
+
+// Next 读取下一行，没有更多数据时ok为false
+func (it *RowIterator) Next() (row map[string]interface{}, ok bool, err error) {
+	if !it.rows.Next() {
+		return nil, false, it.rows.Err()
+	}
+	if err := it.rows.Scan(it.scanArgs...); err != nil {
+		return nil, false, err
+	}
+
+	row = make(map[string]interface{}, len(it.columns))
+	for i, col := range it.columns {
+		row[col] = scanValue(it.values[i], it.binary[i])
+	}
+	return row, true, nil
+}
+
+// Close 关闭结果集
+func (it *RowIterator) Close() error {
+	return it.rows.Close()
+}
+
+// binaryTypes 值保持为 []byte 的二进制列类型，其余类型的 []byte 转为字符串
+var binaryTypes = map[string]bool{
+	"BINARY": true, "VARBINARY": true, "LONGVARBINARY": true, "IMAGE": true, "BYTEA": true,
+	"BLOB": true, "TINYBLOB": true, "MEDIUMBLOB": true, "LONGBLOB": true,
+	"RAW": true, "LONG RAW": true, "BFILE": true,
+}
+
+// binaryColumns 根据驱动报告的列类型判断各列是否为二进制类型，无法获取类型时都不是
+func binaryColumns(rows *sql.Rows) []bool {
+	columns, _ := rows.Columns()
+	binary := make([]bool, len(columns))
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return binary
+	}
+	for i, t := range types {
+		if i < len(binary) {
+			binary[i] = binaryTypes[strings.ToUpper(t.DatabaseTypeName())]
+		}
+	}
+	return binary
+}
+
+// scanValue 转换驱动扫描出的值：二进制列保持为 []byte，其他列的 []byte 转为字符串，
+// 时间保持为 time.Time，包括小数秒和时区，显示和导出时由 output.FormatTime 按设置统一格式化
+func scanValue(val interface{}, binary bool) interface{} {
+	if b, ok := val.([]byte); ok && !binary {
+		return string(b)
+	}
+	return val
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TxBeginner 支持事务的连接
+type TxBeginner interface {
+	BeginTx(ctx context.Context) (*sql.Tx, error)
+}
+
+// CountInTx 在事务中执行 COUNT 查询并返回结果，事务总是回滚，不影响数据
+func CountInTx(ctx context.Context, conn Connection, query string, args ...interface{}) (int64, error) {
+	beginner, ok := conn.(TxBeginner)
+	if !ok {
+		return 0, fmt.Errorf("当前连接不支持事务")
+	}
+	tx, err := beginner.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var count int64
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// QueryInTx 在事务中执行查询并逐行读取结果
+func QueryInTx(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (*RowIterator, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return NewRowIterator(rows)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -20,6 +21,14 @@ func main() {
 	// 确保在程序结束时关闭readline
 	defer handler.Close()
 	
+	// 应用配置文件中保存的全局设置，连接后再应用所用配置的设置
+	if err := handler.LoadSettings(""); err != nil {
+		fmt.Fprintln(os.Stderr, "加载设置失败:", err)
+	}
+
+	// 自动连接在解析命令行参数之前进行，需要先处理 --norc
+	handler.DisableRC(hasFlag(os.Args[1:], "--norc"))
+
 	// 仅当需要时才连接数据库
 	shouldAutoConnect := shouldConnectDatabase()
 	if shouldAutoConnect {
@@ -29,7 +38,7 @@ func main() {
 	// 执行主程序逻辑
 	if err := cmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, "执行出错:", err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
 
@@ -48,6 +57,15 @@ func shouldConnectDatabase() bool {
 		"version": true,
 		"connect": true, // connect命令会自己处理连接
 		"config":  true, // config命令通常不需要连接
+		// 非交互命令根据配置和参数自行建立连接
+		"exec":   true,
+		"select": true,
+		"show":   true,
+		"desc":   true,
+		"import": true,
+		"export": true,
+		"browse": true,
+		"query":  true,
 		"-h":      true,
 		"--help":  true,
 	}
@@ -78,18 +96,45 @@ func shouldConnectDatabase() bool {
 	return true
 }
 
-// tryAutoConnect 尝试使用默认配置自动连接数据库
+// hasFlag 判断命令行中是否有指定的开关参数
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag || arg == flag+"=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// profileArg 返回命令行中 --profile 指定的配置名
+func profileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--profile" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--profile="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// tryAutoConnect 尝试使用 --profile 指定的配置或默认配置自动连接数据库
 func tryAutoConnect() {
-	// 尝试加载默认配置
-	defaultConfig, err := utils.LoadConfig()
+	// 尝试加载配置
+	name := profileArg(os.Args[1:])
+	res, err := utils.ResolveConfig(name, nil)
 	if err != nil {
+		if !errors.Is(err, utils.ErrNoDefaultConfig) {
+			fmt.Printf("加载配置失败: %v\n", err)
+		}
 		// 没有默认配置，使用程序的普通流程
 		return
 	}
 
 	// 使用默认配置尝试连接
-	err = db.Connect(defaultConfig.Type, defaultConfig.Host, defaultConfig.Port, 
-		defaultConfig.User, defaultConfig.Password, defaultConfig.DbName)
+	defaultConfig := res.Config
+	err = db.ConnectConfig(defaultConfig.DbConfig())
 	if err != nil {
 		fmt.Printf("使用默认配置连接失败: %v\n", err)
 		fmt.Println("请使用 'connect' 命令手动连接数据库")
@@ -97,4 +142,5 @@ func tryAutoConnect() {
 	}
 
 	fmt.Printf("已使用默认配置连接到 %s 数据库: %s\n", defaultConfig.Type, defaultConfig.DbName)
+	handler.AfterConnect(res.Profile)
 } 
\ No newline at end of file
@@ -0,0 +1,456 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+const (
+	// MaxFileSize 审计日志文件的最大字节数，超过时轮转为带时间戳的文件
+	MaxFileSize = 10 << 20
+	// MaxFiles 最多保留的轮转文件数，超过时删除最早的文件
+	MaxFiles = 10
+
+	auditDirName    = "audit"
+	currentFileName = "audit.jsonl"
+	rotatedPrefix   = "audit-"
+	rotatedLayout   = "20060102T150405.000000000"
+	headFileName    = "head.json"
+	keyFileName     = "audit.key"
+	maskedValue     = "?"
+)
+
+// KeyFileEnv 指定审计日志密钥文件的环境变量，默认为配置目录下的 audit.key。
+// 密钥不放在审计日志目录中，可以指向其他用户无法修改的位置
+const KeyFileEnv = "DATAMGR_AUDIT_KEY_FILE"
+
+var (
+	mu sync.Mutex
+	// profile 当前连接使用的配置名，记录在每条审计记录中
+	profile string
+)
+
+// Entry 一条审计记录。Seq 为从 0 开始连续的序号，Prev 为上一条记录的 Hash，
+// Hash 为以密钥计算的本记录其余字段的 HMAC-SHA256，修改、删除或插入任何一条记录都会使校验失败
+type Entry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	Profile  string    `json:"profile,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	Server   string    `json:"server,omitempty"`
+	Database string    `json:"database,omitempty"`
+	DbUser   string    `json:"db_user,omitempty"`
+	// Statement 执行的语句，字符串和数字字面值已替换为 ?
+	Statement string `json:"statement"`
+	// DurationMs 执行耗时，毫秒
+	DurationMs float64 `json:"duration_ms"`
+	// Rows 影响或返回的行数
+	Rows  int64  `json:"rows"`
+	Error string `json:"error,omitempty"`
+	Seq   int64  `json:"seq"`
+	Prev  string `json:"prev"`
+	Hash  string `json:"hash"`
+}
+
+// SetProfile 设置之后的审计记录中的配置名
+func SetProfile(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	profile = name
+}
+
+// MaskStatement 将语句中的字符串和数字字面值替换为 ?，避免在审计日志中记录数据和密码
+func MaskStatement(sql string) string {
+	var sb strings.Builder
+	for _, tok := range sqllex.Lex(sql) {
+		switch tok.Kind {
+		case sqllex.String, sqllex.Number:
+			sb.WriteString(maskedValue)
+		default:
+			sb.WriteString(tok.Text)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// Log 记录一条在当前连接上执行的语句，rows 为影响或返回的行数，err 为执行结果
+func Log(statement string, start time.Time, rows int64, err error) error {
+	entry := &Entry{
+		Time:       start,
+		User:       osUser(),
+		Statement:  MaskStatement(statement),
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Rows:       rows,
+	}
+	if config := db.GetCurrentConfig(); config != nil {
+		entry.Type = config.Type
+		entry.Server = fmt.Sprintf("%s:%d", config.Host, config.Port)
+		entry.Database = config.DbName
+		entry.DbUser = config.User
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return Append(entry)
+}
+
+// osUser 返回当前操作系统用户名
+func osUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// Dir 返回审计日志目录
+func Dir() (string, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, auditDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// key 返回计算记录 HMAC 的密钥，create 为 true 时密钥文件不存在则生成
+func key(create bool) ([]byte, error) {
+	path := os.Getenv(KeyFileEnv)
+	if path == "" {
+		configDir, err := utils.GetConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(configDir, keyFileName)
+	}
+	return utils.OpenKeyFile(path, create)
+}
+
+// head 审计日志的最新状态，用于发现末尾的记录或整个文件被删除
+type head struct {
+	// First 最早保留的记录的序号，轮转删除最早的文件后增加
+	First int64 `json:"first"`
+	// Next 下一条记录的序号，即写入过的记录总数
+	Next int64 `json:"next"`
+	// Hash 最后一条记录的 Hash
+	Hash string `json:"hash"`
+	// MAC 以上字段的 HMAC
+	MAC string `json:"mac"`
+}
+
+// mac 计算头记录除 MAC 以外各字段的 HMAC
+func (h *head) mac(key []byte) string {
+	unsigned := *h
+	unsigned.MAC = ""
+	return sign(key, &unsigned)
+}
+
+// sign 返回值的 JSON 形式的 HMAC-SHA256
+func sign(key []byte, v interface{}) string {
+	data, _ := json.Marshal(v)
+	m := hmac.New(sha256.New, key)
+	m.Write(data)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// loadHead 读取并校验头记录。头记录不存在时，没有审计日志文件则返回初始状态，否则视为被修改
+func loadHead(dir string, key []byte, files []string) (*head, error) {
+	data, err := os.ReadFile(filepath.Join(dir, headFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		if len(files) > 0 {
+			return nil, fmt.Errorf("%w: 头记录 %s 丢失", ErrTampered, headFileName)
+		}
+		return &head{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	h := &head{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("%w: 头记录格式错误: %v", ErrTampered, err)
+	}
+	if !hmac.Equal([]byte(h.MAC), []byte(h.mac(key))) {
+		return nil, fmt.Errorf("%w: 头记录与密钥不符", ErrTampered)
+	}
+	return h, nil
+}
+
+// save 签名后写入头记录，先写临时文件再改名以免写入一半
+func (h *head) save(dir string, key []byte) error {
+	h.MAC = h.mac(key)
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, headFileName+".tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, headFileName))
+}
+
+// Append 将记录链接到上一条记录后追加到审计日志，文件超过 MaxFileSize 时先轮转。
+// 记录的 Hash 为以密钥计算的 HMAC，没有密钥无法在修改日志后重新计算
+func Append(entry *Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	key, err := key(true)
+	if err != nil {
+		return err
+	}
+	files, err := logFiles(dir)
+	if err != nil {
+		return err
+	}
+	h, err := loadHead(dir, key, files)
+	if err != nil {
+		return err
+	}
+	if entry.Profile == "" {
+		entry.Profile = profile
+	}
+	entry.Seq, entry.Prev = h.Next, h.Hash
+	entry.Hash = entry.mac(key)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	current := filepath.Join(dir, currentFileName)
+	if info, err := os.Stat(current); err == nil && info.Size() > 0 && info.Size()+int64(len(line))+1 > MaxFileSize {
+		if err := rotate(dir, current, h); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(current, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	h.Next, h.Hash = entry.Seq+1, entry.Hash
+	return h.save(dir, key)
+}
+
+// mac 计算除 Hash 以外各字段的 HMAC
+func (e *Entry) mac(key []byte) string {
+	unsigned := *e
+	unsigned.Hash = ""
+	return sign(key, &unsigned)
+}
+
+// rotate 将当前文件改名为带时间戳的文件，删除超出 MaxFiles 的最早的文件，并在头记录中记下最早保留的记录
+func rotate(dir, current string, h *head) error {
+	rotated := filepath.Join(dir, rotatedPrefix+time.Now().Format(rotatedLayout)+".jsonl")
+	if err := os.Rename(current, rotated); err != nil {
+		return err
+	}
+	files, err := rotatedFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) <= MaxFiles {
+		return nil
+	}
+	for len(files) > MaxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	first, err := firstEntry(files[0])
+	if err != nil {
+		return err
+	}
+	h.First = first.Seq
+	return nil
+}
+
+// firstEntry 返回文件中的第一条记录
+func firstEntry(file string) (*Entry, error) {
+	var first *Entry
+	errStop := errors.New("stop")
+	err := walkFile(file, func(file string, line int, entry *Entry) error {
+		first = entry
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	if first == nil {
+		return nil, fmt.Errorf("审计日志 %s 为空", filepath.Base(file))
+	}
+	return first, nil
+}
+
+// rotatedFiles 返回轮转后的文件，按时间从早到晚排列
+func rotatedFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, rotatedPrefix+"*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// logFiles 返回所有审计日志文件，按时间从早到晚排列，当前文件在最后
+func logFiles(dir string) ([]string, error) {
+	files, err := rotatedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	current := filepath.Join(dir, currentFileName)
+	if _, err := os.Stat(current); err == nil {
+		files = append(files, current)
+	}
+	return files, nil
+}
+
+// Load 读取 since 之后的审计记录，按时间顺序排列，since 为零值时读取全部记录
+func Load(since time.Time) ([]*Entry, error) {
+	var entries []*Entry
+	err := walk(func(file string, line int, entry *Entry) error {
+		if since.IsZero() || !entry.Time.Before(since) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// walk 按顺序读取所有审计记录
+func walk(fn func(file string, line int, entry *Entry) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	files, err := logFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := walkFile(file, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkFile 读取一个审计日志文件中的记录
+func walkFile(file string, fn func(file string, line int, entry *Entry) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		entry := &Entry{}
+		if err := json.Unmarshal([]byte(text), entry); err != nil {
+			return fmt.Errorf("%s 第 %d 行格式错误: %v", filepath.Base(file), n, err)
+		}
+		if err := fn(file, n, entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ErrTampered 审计日志被修改
+var ErrTampered = errors.New("审计日志已被修改")
+
+// Verify 用密钥校验所有记录及其哈希链，并与头记录比较，返回校验过的记录数。
+// 记录被修改、删除或插入，末尾的记录或轮转文件被删除，或者整个日志被重写时返回 ErrTampered
+func Verify() (int, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+	mu.Lock()
+	files, err := logFiles(dir)
+	mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, headFileName)); errors.Is(err, os.ErrNotExist) && len(files) == 0 {
+		return 0, nil
+	}
+	key, err := key(false)
+	if err != nil {
+		return 0, err
+	}
+	h, err := loadHead(dir, key, files)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var last *Entry
+	lastFile := ""
+	err = walk(func(file string, line int, entry *Entry) error {
+		where := fmt.Sprintf("%s 第 %d 行", filepath.Base(file), line)
+		if !hmac.Equal([]byte(entry.Hash), []byte(entry.mac(key))) {
+			return fmt.Errorf("%w: %s的内容与哈希不符", ErrTampered, where)
+		}
+		switch {
+		case last == nil && entry.Seq != h.First:
+			return fmt.Errorf("%w: %s之前的 %d 条记录被删除", ErrTampered, where, entry.Seq-h.First)
+		case last == nil && entry.Seq == 0 && entry.Prev != "":
+			return fmt.Errorf("%w: %s之前的记录被删除", ErrTampered, where)
+		case last != nil && entry.Seq != last.Seq+1 && file != lastFile:
+			return fmt.Errorf("%w: %s之前缺少 %d 条记录，轮转的审计日志文件可能被删除", ErrTampered, where, entry.Seq-last.Seq-1)
+		case last != nil && (entry.Seq != last.Seq+1 || entry.Prev != last.Hash):
+			return fmt.Errorf("%w: %s之前的记录被删除或插入", ErrTampered, where)
+		}
+		last, lastFile = entry, file
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	if int64(count) != h.Next-h.First || (last != nil && last.Hash != h.Hash) {
+		return count, fmt.Errorf("%w: 应有 %d 条记录，实际为 %d 条，末尾的记录可能被删除", ErrTampered, h.Next-h.First, count)
+	}
+	return count, nil
+}
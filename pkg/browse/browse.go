@@ -0,0 +1,355 @@
+package browse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"golang.org/x/term"
+)
+
+// DefaultRowLimit 浏览时最多加载的行数
+const DefaultRowLimit = 5000
+
+// nullInput 编辑单元格时表示NULL的输入
+const nullInput = `\N`
+
+// Update 编辑单元格生成的 UPDATE 语句，按主键修改一行的一列
+type Update struct {
+	Table  string
+	Column string
+	// Keys 定位行的主键列
+	Keys []string
+	// SQL 完整的 UPDATE 语句，Args 为其参数，第一个参数为新值
+	SQL  string
+	Args []interface{}
+	// Where 按主键定位行的条件，占位符从 1 开始编号，WhereArgs 为其参数
+	Where     string
+	WhereArgs []interface{}
+	// Warning 已保存但需要提示的问题，如无法写入撤销日志
+	Warning string
+}
+
+// Writer 执行编辑单元格的 UPDATE 语句，返回错误时数据没有被修改
+type Writer func(u *Update) error
+
+// Browser 全屏表格浏览器
+type Browser struct {
+	conn      db.Connection
+	write     Writer
+	dbType    string
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	widths    []int
+	pkColumns []int // 主键列在columns中的下标
+	truncated bool
+
+	view      []int // 经过过滤和排序后的行下标
+	cursor    int   // 当前行在view中的位置
+	offset    int   // 首个可见行在view中的位置
+	col       int   // 当前列
+	colOffset int   // 首个可见列
+
+	sortCol    int // 排序列，-1表示不排序
+	sortDesc   bool
+	filterCol  int
+	filterText string
+
+	message string
+}
+
+// Run 以全屏方式浏览表数据，limit为最多加载的行数，编辑单元格时调用 write 保存
+func Run(conn db.Connection, dbType, table string, limit int, write Writer) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("browse 需要在终端中运行")
+	}
+	b, err := Open(conn, dbType, table, limit, write)
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("无法切换终端模式: %v", err)
+	}
+	restoreConsole := enableVirtualTerminal()
+	// 进入备用屏幕并隐藏光标，退出时恢复
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		restoreConsole()
+		term.Restore(int(os.Stdin.Fd()), state)
+	}()
+
+	return b.loop()
+}
+
+// Open 读取表的主键和按主键排序的前limit行数据，limit不大于0时使用 DefaultRowLimit。
+// 编辑单元格时调用 write 保存。无法确定主键时仍可浏览，但编辑被禁用
+func Open(conn db.Connection, dbType, table string, limit int, write Writer) (*Browser, error) {
+	if limit <= 0 {
+		limit = DefaultRowLimit
+	}
+	b := &Browser{conn: conn, write: write, dbType: dbType, table: table, sortCol: -1}
+	keys, keyErr := primaryKey(conn, table)
+	if err := b.load(keys, limit); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		for i, column := range b.columns {
+			if strings.EqualFold(column, key) {
+				b.pkColumns = append(b.pkColumns, i)
+			}
+		}
+	}
+	if keyErr == nil && len(b.pkColumns) == 0 {
+		keyErr = errors.New("表没有主键")
+	}
+	if keyErr != nil {
+		b.message = fmt.Sprintf("无法获取主键，编辑已禁用: %v", keyErr)
+	}
+	return b, nil
+}
+
+// selectQuery 返回读取表数据的查询，按主键排序以保证每次加载的行相同，多读一行用于判断是否截断
+func selectQuery(dbType, table string, keys []string, limit int) string {
+	query := fmt.Sprintf("SELECT * FROM %s", table)
+	if len(keys) > 0 {
+		query += " ORDER BY " + strings.Join(keys, ", ")
+	}
+	return db.LimitQuery(dbType, query, limit+1)
+}
+
+// load 读取表数据，keys为主键列名
+func (b *Browser) load(keys []string, limit int) error {
+	query := selectQuery(b.dbType, b.table, keys, limit)
+
+	var records []map[string]interface{}
+	if streamer, ok := b.conn.(db.StreamQuerier); ok {
+		it, err := streamer.QueryStream(context.Background(), query)
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		b.columns = it.Columns()
+		for {
+			row, ok, err := it.Next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			records = append(records, row)
+		}
+	} else {
+		rows, err := b.conn.Query(query)
+		if err != nil {
+			return err
+		}
+		b.columns, err = b.conn.GetTableColumns(b.table)
+		if err != nil {
+			return err
+		}
+		records = rows
+	}
+	if len(records) > limit {
+		records = records[:limit]
+		b.truncated = true
+	}
+
+	if len(b.columns) == 0 {
+		return fmt.Errorf("表 %s 没有字段", b.table)
+	}
+	b.rows = output.NewResult(b.columns, records).Rows
+	b.computeWidths()
+	b.applyView()
+	return nil
+}
+
+// primaryKey 根据表结构返回主键列名
+func primaryKey(conn db.Connection, table string) ([]string, error) {
+	info, err := conn.DescribeTable(table)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, col := range info {
+		name := fieldString(col, "COLUMN_NAME")
+		constraint := fieldString(col, "CONSTRAINT_TYPE")
+		if name != "" && strings.Contains(strings.ToUpper(constraint), "PRIMARY KEY") {
+			keys = append(keys, name)
+		}
+	}
+	return keys, nil
+}
+
+// Columns 返回表的列名
+func (b *Browser) Columns() []string {
+	return b.columns
+}
+
+// Rows 返回经过过滤和排序后的可见行
+func (b *Browser) Rows() [][]interface{} {
+	rows := make([][]interface{}, len(b.view))
+	for i, index := range b.view {
+		rows[i] = b.rows[index]
+	}
+	return rows
+}
+
+// Truncated 表的行数是否超过了加载的行数
+func (b *Browser) Truncated() bool {
+	return b.truncated
+}
+
+// SetSort 按第col列排序，col为-1时不排序
+func (b *Browser) SetSort(col int, desc bool) {
+	b.sortCol, b.sortDesc = col, desc
+	b.applyView()
+}
+
+// SetFilter 只显示第col列包含text的行，不区分大小写，text为空时显示所有行
+func (b *Browser) SetFilter(col int, text string) {
+	b.filterCol, b.filterText = col, text
+	b.cursor, b.offset = 0, 0
+	b.applyView()
+}
+
+// fieldString 读取表结构字段，兼容大小写不同的列名
+func fieldString(row map[string]interface{}, name string) string {
+	if val, ok := row[name]; ok && val != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	if val, ok := row[strings.ToLower(name)]; ok && val != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return ""
+}
+
+// cellText 返回单元格的单行显示文本
+func cellText(val interface{}) string {
+	text, ok := output.FormatValue(val)
+	if !ok {
+		return "NULL"
+	}
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`).Replace(text)
+}
+
+// applyView 按过滤条件和排序重建可见行
+func (b *Browser) applyView() {
+	b.view = b.view[:0]
+	filter := strings.ToLower(b.filterText)
+	for i, row := range b.rows {
+		if filter != "" && !strings.Contains(strings.ToLower(cellText(row[b.filterCol])), filter) {
+			continue
+		}
+		b.view = append(b.view, i)
+	}
+
+	if b.sortCol >= 0 {
+		sort.SliceStable(b.view, func(i, j int) bool {
+			c := CompareValues(b.rows[b.view[i]][b.sortCol], b.rows[b.view[j]][b.sortCol])
+			if b.sortDesc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	if b.cursor >= len(b.view) {
+		b.cursor = max(len(b.view)-1, 0)
+	}
+}
+
+// CompareValues 比较两个单元格，NULL最小，数字按数值比较
+func CompareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	as, bs := cellText(a), cellText(b)
+	af, aerr := strconv.ParseFloat(as, 64)
+	bf, berr := strconv.ParseFloat(bs, 64)
+	if aerr == nil && berr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(as, bs)
+}
+
+// toggleSort 依次切换当前列的升序、降序和不排序
+func (b *Browser) toggleSort() {
+	switch {
+	case b.sortCol != b.col:
+		b.SetSort(b.col, false)
+	case !b.sortDesc:
+		b.SetSort(b.col, true)
+	default:
+		b.SetSort(-1, false)
+	}
+}
+
+// UpdateCell 将第rowIndex行(按加载顺序)单元格的新值以UPDATE语句保存，按主键定位行，
+// 输入 \N 表示NULL。保存有需要提示的问题时设置到状态栏
+func (b *Browser) UpdateCell(rowIndex, col int, input string) error {
+	if b.write == nil {
+		return errors.New("不支持编辑")
+	}
+	if len(b.pkColumns) == 0 {
+		return errors.New("表没有主键，无法编辑")
+	}
+	for _, pk := range b.pkColumns {
+		if pk == col {
+			return errors.New("不允许修改主键列")
+		}
+	}
+
+	var value interface{} = input
+	if input == nullInput {
+		value = nil
+	}
+
+	row := b.rows[rowIndex]
+	u := &Update{Table: b.table, Column: b.columns[col], Args: []interface{}{value}}
+	var conditions, where []string
+	for _, pk := range b.pkColumns {
+		if row[pk] == nil {
+			return errors.New("主键值为空，无法定位行")
+		}
+		u.Keys = append(u.Keys, b.columns[pk])
+		u.Args = append(u.Args, row[pk])
+		u.WhereArgs = append(u.WhereArgs, row[pk])
+		conditions = append(conditions, fmt.Sprintf("%s = %s", b.columns[pk], db.Placeholder(b.dbType, len(u.Args))))
+		where = append(where, fmt.Sprintf("%s = %s", b.columns[pk], db.Placeholder(b.dbType, len(u.WhereArgs))))
+	}
+	u.Where = strings.Join(where, " AND ")
+	u.SQL = fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s",
+		b.table, b.columns[col], db.Placeholder(b.dbType, 1), strings.Join(conditions, " AND "))
+	if err := b.write(u); err != nil {
+		return err
+	}
+
+	row[col] = value
+	b.message = u.Warning
+	return nil
+}
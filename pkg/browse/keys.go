@@ -0,0 +1,95 @@
+package browse
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// 按键类型
+const (
+	keyRune = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyCtrlC
+	keyUnknown
+)
+
+// key 一次按键
+type key struct {
+	code int
+	r    rune
+}
+
+// escapeKeys 转义序列与按键的对应关系
+var escapeKeys = map[string]int{
+	"[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+	"OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+	"[5~": keyPageUp, "[6~": keyPageDown,
+	"[H": keyHome, "[F": keyEnd, "OH": keyHome, "OF": keyEnd,
+	"[1~": keyHome, "[4~": keyEnd, "[7~": keyHome, "[8~": keyEnd,
+}
+
+// readKeys 读取一次输入并解析为按键序列，粘贴的多个字符会一并返回
+func readKeys(r io.Reader) ([]key, error) {
+	buf := make([]byte, 256)
+	n, err := r.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeys(buf[:n]), nil
+}
+
+// parseKeys 将原始输入解析为按键
+func parseKeys(data []byte) []key {
+	var keys []key
+	for len(data) > 0 {
+		switch b := data[0]; {
+		case b == 0x1b:
+			if len(data) == 1 {
+				keys = append(keys, key{code: keyEsc})
+				data = data[1:]
+				continue
+			}
+			matched := false
+			for seq, code := range escapeKeys {
+				if len(data) > len(seq) && string(data[1:1+len(seq)]) == seq {
+					keys = append(keys, key{code: code})
+					data = data[1+len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// 未识别的转义序列整体忽略
+				keys = append(keys, key{code: keyUnknown})
+				data = data[len(data):]
+			}
+		case b == '\r' || b == '\n':
+			keys = append(keys, key{code: keyEnter})
+			data = data[1:]
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			data = data[1:]
+		case b == 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+			data = data[1:]
+		case b < 0x20:
+			keys = append(keys, key{code: keyUnknown})
+			data = data[1:]
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, key{code: keyRune, r: r})
+			data = data[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,357 @@
+package browse
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// maxColumnWidth 浏览时单列的最大显示宽度
+const maxColumnWidth = 40
+
+// helpLine 表格视图底部的按键说明
+const helpLine = "↑↓←→ 移动  PgUp/PgDn 翻页  s 排序  / 过滤  Enter 详情  e 编辑  q 退出"
+
+// screenSize 返回终端尺寸
+func screenSize() (int, int) {
+	if width, height, ok := utils.TerminalSize(); ok {
+		return width, height
+	}
+	return 80, 24
+}
+
+// computeWidths 根据列名和数据计算列宽
+func (b *Browser) computeWidths() {
+	b.widths = make([]int, len(b.columns))
+	for i, col := range b.columns {
+		b.widths[i] = runewidth.StringWidth(col)
+	}
+	for _, row := range b.rows {
+		for i, val := range row {
+			b.widths[i] = max(b.widths[i], runewidth.StringWidth(cellText(val)))
+		}
+	}
+	for i := range b.widths {
+		b.widths[i] = min(max(b.widths[i], 4), maxColumnWidth)
+	}
+}
+
+// fitCell 将文本截断或补齐到指定宽度
+func fitCell(text string, width int) string {
+	if runewidth.StringWidth(text) > width {
+		text = runewidth.Truncate(text, width, "…")
+	}
+	return runewidth.FillRight(text, width)
+}
+
+// pageSize 返回可显示的数据行数
+func (b *Browser) pageSize() int {
+	_, height := screenSize()
+	// 标题、表头、分隔线、状态栏各占一行
+	return max(height-4, 1)
+}
+
+// visibleColumns 返回从colOffset开始能够显示的列
+func (b *Browser) visibleColumns(width int) []int {
+	var cols []int
+	used := 0
+	for i := b.colOffset; i < len(b.columns); i++ {
+		need := b.widths[i] + 3
+		if used+need > width && len(cols) > 0 {
+			break
+		}
+		cols = append(cols, i)
+		used += need
+	}
+	return cols
+}
+
+// scrollIntoView 调整滚动位置，保证当前行和当前列可见
+func (b *Browser) scrollIntoView() {
+	page := b.pageSize()
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+page {
+		b.offset = b.cursor - page + 1
+	}
+
+	width, _ := screenSize()
+	if b.col < b.colOffset {
+		b.colOffset = b.col
+	}
+	for {
+		cols := b.visibleColumns(width)
+		if len(cols) == 0 || b.col <= cols[len(cols)-1] {
+			break
+		}
+		b.colOffset++
+	}
+}
+
+// render 绘制表格视图
+func (b *Browser) render() {
+	b.scrollIntoView()
+	width, _ := screenSize()
+	cols := b.visibleColumns(width)
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+
+	// 标题行
+	title := fmt.Sprintf(" %s  第 %d/%d 行", b.table, min(b.cursor+1, len(b.view)), len(b.view))
+	if b.truncated {
+		title += fmt.Sprintf("（仅加载前 %d 行）", len(b.rows))
+	}
+	if b.sortCol >= 0 {
+		order := "升序"
+		if b.sortDesc {
+			order = "降序"
+		}
+		title += fmt.Sprintf("  排序: %s %s", b.columns[b.sortCol], order)
+	}
+	if b.filterText != "" {
+		title += fmt.Sprintf("  过滤: %s ~ %q", b.columns[b.filterCol], b.filterText)
+	}
+	sb.WriteString("\x1b[1m" + fitCell(title, width) + "\x1b[0m\r\n")
+
+	// 表头
+	for _, c := range cols {
+		cell := " " + fitCell(b.columns[c], b.widths[c]) + " "
+		if c == b.col {
+			cell = "\x1b[7m" + cell + "\x1b[0m"
+		}
+		sb.WriteString(cell + "│")
+	}
+	sb.WriteString("\r\n")
+	for _, c := range cols {
+		sb.WriteString(strings.Repeat("─", b.widths[c]+2) + "┼")
+	}
+	sb.WriteString("\r\n")
+
+	// 数据行
+	page := b.pageSize()
+	for i := b.offset; i < len(b.view) && i < b.offset+page; i++ {
+		row := b.rows[b.view[i]]
+		var line strings.Builder
+		for _, c := range cols {
+			cell := " " + fitCell(cellText(row[c]), b.widths[c]) + " "
+			if i == b.cursor && c == b.col {
+				cell = "\x1b[4m" + cell + "\x1b[24m"
+			} else if row[c] == nil {
+				cell = "\x1b[2m" + cell + "\x1b[22m"
+			}
+			line.WriteString(cell + "│")
+		}
+		if i == b.cursor {
+			sb.WriteString("\x1b[7m" + line.String() + "\x1b[0m\r\n")
+		} else {
+			sb.WriteString(line.String() + "\r\n")
+		}
+	}
+	for i := len(b.view) - b.offset; i < page; i++ {
+		sb.WriteString("\r\n")
+	}
+
+	sb.WriteString(b.statusLine(width, helpLine))
+	os.Stdout.WriteString(sb.String())
+}
+
+// statusLine 返回底部状态栏，有提示信息时优先显示提示信息
+func (b *Browser) statusLine(width int, help string) string {
+	text := help
+	if b.message != "" {
+		text = b.message
+		b.message = ""
+	}
+	return "\x1b[7m" + fitCell(" "+text, width) + "\x1b[0m"
+}
+
+// readLine 在底部状态栏读取一行输入，Esc取消
+func (b *Browser) readLine(prompt, initial string) (string, bool) {
+	input := []rune(initial)
+	width, height := screenSize()
+	fmt.Print("\x1b[?25h")
+	defer fmt.Print("\x1b[?25l")
+
+	for {
+		line := prompt + string(input)
+		// 输入过长时只显示末尾部分
+		for runewidth.StringWidth(line) > width-1 && len(line) > 0 {
+			_, size := utf8.DecodeRuneInString(line)
+			line = line[size:]
+		}
+		fmt.Printf("\x1b[%d;1H\x1b[2K%s", height, line)
+
+		keys, err := readKeys(os.Stdin)
+		if err != nil {
+			return "", false
+		}
+		for _, k := range keys {
+			switch k.code {
+			case keyEnter:
+				return string(input), true
+			case keyEsc, keyCtrlC:
+				return "", false
+			case keyBackspace:
+				if len(input) > 0 {
+					input = input[:len(input)-1]
+				}
+			case keyRune:
+				input = append(input, k.r)
+			}
+		}
+	}
+}
+
+// loop 处理表格视图的按键
+func (b *Browser) loop() error {
+	for {
+		b.render()
+		keys, err := readKeys(os.Stdin)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if quit := b.handleKey(k); quit {
+				return nil
+			}
+		}
+	}
+}
+
+// handleKey 处理表格视图中的单个按键，返回是否退出
+func (b *Browser) handleKey(k key) bool {
+	page := b.pageSize()
+	switch {
+	case k.code == keyCtrlC, k.code == keyEsc, k.code == keyRune && k.r == 'q':
+		return true
+	case k.code == keyUp, k.code == keyRune && k.r == 'k':
+		b.cursor = max(b.cursor-1, 0)
+	case k.code == keyDown, k.code == keyRune && k.r == 'j':
+		b.cursor = max(min(b.cursor+1, len(b.view)-1), 0)
+	case k.code == keyLeft, k.code == keyRune && k.r == 'h':
+		b.col = max(b.col-1, 0)
+	case k.code == keyRight, k.code == keyRune && k.r == 'l':
+		b.col = min(b.col+1, len(b.columns)-1)
+	case k.code == keyPageUp:
+		b.cursor = max(b.cursor-page, 0)
+	case k.code == keyPageDown, k.code == keyRune && k.r == ' ':
+		b.cursor = max(min(b.cursor+page, len(b.view)-1), 0)
+	case k.code == keyHome, k.code == keyRune && k.r == 'g':
+		b.cursor = 0
+	case k.code == keyEnd, k.code == keyRune && k.r == 'G':
+		b.cursor = max(len(b.view)-1, 0)
+	case k.code == keyRune && k.r == 's':
+		b.toggleSort()
+	case k.code == keyRune && k.r == '/':
+		text, ok := b.readLine(fmt.Sprintf("过滤 %s (留空清除): ", b.columns[b.col]), "")
+		if ok {
+			b.SetFilter(b.col, text)
+		}
+	case k.code == keyEnter:
+		if len(b.view) > 0 {
+			b.detail(b.view[b.cursor])
+		}
+	case k.code == keyRune && k.r == 'e':
+		if len(b.view) > 0 {
+			b.edit(b.view[b.cursor], b.col)
+		}
+	}
+	return false
+}
+
+// edit 编辑单元格并保存
+func (b *Browser) edit(rowIndex, col int) {
+	if len(b.pkColumns) == 0 {
+		b.message = "表没有主键，无法编辑"
+		return
+	}
+	initial := ""
+	if val := b.rows[rowIndex][col]; val != nil {
+		initial = cellText(val)
+	}
+	text, ok := b.readLine(fmt.Sprintf("%s = ", b.columns[col]), initial)
+	if !ok {
+		b.message = "已取消编辑"
+		return
+	}
+
+	display := text
+	if text == nullInput {
+		display = "NULL"
+	}
+	confirm, ok := b.readLine(fmt.Sprintf("将 %s 更新为 %q，确认保存? (y/n): ", b.columns[col], display), "")
+	if !ok || !strings.HasPrefix(strings.ToLower(confirm), "y") {
+		b.message = "已取消编辑"
+		return
+	}
+
+	if err := b.UpdateCell(rowIndex, col, text); err != nil {
+		b.message = fmt.Sprintf("保存失败: %v", err)
+		return
+	}
+	b.computeWidths()
+	if b.message == "" {
+		b.message = fmt.Sprintf("已保存 %s", b.columns[col])
+	}
+}
+
+// detail 以纵向形式显示一行的所有字段，可选择字段进行编辑
+func (b *Browser) detail(rowIndex int) {
+	selected, offset := 0, 0
+	nameWidth := 0
+	for _, col := range b.columns {
+		nameWidth = max(nameWidth, runewidth.StringWidth(col))
+	}
+
+	for {
+		width, height := screenSize()
+		page := max(height-2, 1)
+		if selected < offset {
+			offset = selected
+		}
+		if selected >= offset+page {
+			offset = selected - page + 1
+		}
+
+		var sb strings.Builder
+		sb.WriteString("\x1b[H\x1b[2J")
+		sb.WriteString("\x1b[1m" + fitCell(fmt.Sprintf(" %s 记录详情", b.table), width) + "\x1b[0m\r\n")
+		row := b.rows[rowIndex]
+		for i := offset; i < len(b.columns) && i < offset+page; i++ {
+			line := runewidth.FillRight(b.columns[i], nameWidth) + " │ " + cellText(row[i])
+			line = fitCell(line, width)
+			if i == selected {
+				line = "\x1b[7m" + line + "\x1b[0m"
+			}
+			sb.WriteString(line + "\r\n")
+		}
+		for i := len(b.columns) - offset; i < page; i++ {
+			sb.WriteString("\r\n")
+		}
+		sb.WriteString(b.statusLine(width, "↑↓ 选择字段  e 编辑  Esc/q 返回"))
+		os.Stdout.WriteString(sb.String())
+
+		keys, err := readKeys(os.Stdin)
+		if err != nil {
+			return
+		}
+		for _, k := range keys {
+			switch {
+			case k.code == keyEsc, k.code == keyCtrlC, k.code == keyEnter, k.code == keyRune && k.r == 'q':
+				return
+			case k.code == keyUp, k.code == keyRune && k.r == 'k':
+				selected = max(selected-1, 0)
+			case k.code == keyDown, k.code == keyRune && k.r == 'j':
+				selected = min(selected+1, len(b.columns)-1)
+			case k.code == keyRune && k.r == 'e':
+				b.edit(rowIndex, selected)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package browse
+
+// enableVirtualTerminal 类Unix终端默认支持ANSI控制序列
+func enableVirtualTerminal() (restore func()) {
+	return func() {}
+}
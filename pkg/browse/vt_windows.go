@@ -0,0 +1,22 @@
+//go:build windows
+
+package browse
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal 为Windows控制台开启ANSI控制序列支持
+func enableVirtualTerminal() (restore func()) {
+	handle := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return func() {}
+	}
+	windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	return func() {
+		windows.SetConsoleMode(handle, mode)
+	}
+}
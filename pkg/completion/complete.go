@@ -0,0 +1,292 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// WordSeparators 分隔补全单词的字符，补全时替换光标前最后一个分隔符之后的内容
+const WordSeparators = " \t\r\n,()=<>;+-*/|!"
+
+// Suggestion 补全候选
+type Suggestion struct {
+	Text        string
+	Description string
+}
+
+// 词法单元类型
+const (
+	tokenWord = iota
+	tokenLiteral
+	tokenPunct
+)
+
+// token 词法单元
+type token struct {
+	kind int
+	text string
+}
+
+// lower 返回小写形式，便于与关键字比较
+func (t token) lower() string {
+	return strings.ToLower(t.text)
+}
+
+var (
+	// tableTriggers 之后应补全表名的关键字
+	tableTriggers = map[string]bool{"from": true, "join": true, "into": true, "update": true, "table": true}
+	// leadingTableTriggers 仅作为语句首个单词时之后应补全表名的命令
+	leadingTableTriggers = map[string]bool{"desc": true, "describe": true, "browse": true, "import": true, "export": true}
+	// columnTriggers 之后应补全字段名的关键字和运算符
+	columnTriggers = map[string]bool{
+		"select": true, "where": true, "set": true, "by": true, "on": true, "and": true, "or": true,
+		"having": true, "not": true, "distinct": true, "when": true, "then": true, "else": true, "case": true,
+		"(": true, "=": true, "<": true, ">": true, "!": true, "+": true, "-": true, "/": true, "|": true,
+	}
+	// clauseKeywords 标识当前子句的关键字
+	clauseKeywords = map[string]bool{
+		"select": true, "from": true, "join": true, "where": true, "set": true, "by": true, "on": true,
+		"having": true, "into": true, "update": true, "values": true, "limit": true,
+	}
+	// columnClauses 逗号之后应补全字段名的子句
+	columnClauses = map[string]bool{"select": true, "set": true, "by": true, "where": true, "having": true, "on": true, "(": true}
+	// reservedWords 表名之后不会作为别名的关键字
+	reservedWords = map[string]bool{
+		"where": true, "join": true, "inner": true, "left": true, "right": true, "full": true, "cross": true,
+		"outer": true, "natural": true, "on": true, "using": true, "group": true, "order": true, "having": true,
+		"limit": true, "offset": true, "fetch": true, "set": true, "union": true, "minus": true, "except": true,
+		"intersect": true, "values": true, "select": true, "from": true, "format": true, "connect": true,
+		"start": true, "window": true, "returning": true, "table": true,
+	}
+)
+
+// Complete 根据光标前后的文本返回SQL补全候选，before为光标前的文本，after为光标后的文本
+func Complete(catalog Catalog, dialect, before, after string) []Suggestion {
+	word := currentWord(before)
+	tokens, inLiteral := tokenize(before[:len(before)-len(word)])
+	if inLiteral {
+		return nil
+	}
+	tokens = lastStatement(tokens)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	afterTokens, _ := tokenize(after)
+	stmt := append(append([]token(nil), tokens...), firstStatement(afterTokens)...)
+	prev := tokens[len(tokens)-1].lower()
+	first := tokens[0].lower()
+
+	switch {
+	case leadingTableTriggers[prev] && len(tokens) == 1,
+		tableTriggers[prev] && !(prev == "from" && first == "import"),
+		prev == "," && currentClause(tokens) == "from":
+		return filter(tableSuggestions(catalog), word)
+	case columnTriggers[prev], prev == "," && columnClauses[currentClause(tokens)]:
+		tables, aliases := tableRefs(stmt)
+		if i := strings.LastIndex(word, "."); i >= 0 {
+			qualifier := word[:i]
+			table := resolveTable(qualifier, aliases)
+			return filterQualified(columnSuggestions(catalog, []string{table}), qualifier, word[i+1:])
+		}
+		suggestions := columnSuggestions(catalog, tables)
+		suggestions = append(suggestions, functionSuggestions(dialect, word)...)
+		suggestions = append(suggestions, keywordSuggestions(dialect, word)...)
+		return filter(suggestions, word)
+	default:
+		return filter(keywordSuggestions(dialect, word), word)
+	}
+}
+
+// currentWord 返回光标前正在输入的单词
+func currentWord(before string) string {
+	i := strings.LastIndexAny(before, WordSeparators)
+	return before[i+1:]
+}
+
+// tokenize 将SQL文本拆分为词法单元并去掉空白和注释，返回的布尔值表示文本是否结束在字符串或注释中
+func tokenize(s string) ([]token, bool) {
+	var tokens []token
+	inLiteral := false
+	for _, t := range sqllex.Lex(s) {
+		inLiteral = t.Kind == sqllex.String && t.Unterminated ||
+			t.Kind == sqllex.Comment && (t.Unterminated || strings.HasPrefix(t.Text, "--"))
+		switch t.Kind {
+		case sqllex.Whitespace, sqllex.Comment:
+		case sqllex.Keyword, sqllex.Identifier, sqllex.Number:
+			tokens = append(tokens, token{kind: tokenWord, text: t.Text})
+		case sqllex.String:
+			tokens = append(tokens, token{kind: tokenLiteral, text: t.Text})
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: t.Text})
+		}
+	}
+	return tokens, inLiteral
+}
+
+// lastStatement 返回最后一个分号之后的词法单元
+func lastStatement(tokens []token) []token {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].text == ";" {
+			return tokens[i+1:]
+		}
+	}
+	return tokens
+}
+
+// firstStatement 返回第一个分号之前的词法单元
+func firstStatement(tokens []token) []token {
+	for i, t := range tokens {
+		if t.text == ";" {
+			return tokens[:i]
+		}
+	}
+	return tokens
+}
+
+// currentClause 返回光标所在的子句关键字
+func currentClause(tokens []token) string {
+	depth := 0
+	for i := len(tokens) - 1; i >= 0; i-- {
+		switch t := tokens[i].lower(); {
+		case t == ")":
+			depth++
+		case t == "(":
+			if depth == 0 {
+				return "("
+			}
+			depth--
+		case depth == 0 && tokens[i].kind == tokenWord && clauseKeywords[t]:
+			return t
+		}
+	}
+	return ""
+}
+
+// tableRefs 解析语句中引用的表及其别名，别名的键为小写
+func tableRefs(tokens []token) ([]string, map[string]string) {
+	var tables []string
+	aliases := make(map[string]string)
+	for i := 0; i < len(tokens); i++ {
+		trigger := tokens[i].lower()
+		if tokens[i].kind != tokenWord || !tableTriggers[trigger] && !(i == 0 && leadingTableTriggers[trigger]) {
+			continue
+		}
+		for i+1 < len(tokens) && tokens[i+1].kind == tokenWord && !reservedWords[tokens[i+1].lower()] {
+			i++
+			table := unquote(tokens[i].text)
+			tables = append(tables, table)
+
+			// 可选的 AS 和别名
+			if i+1 < len(tokens) && tokens[i+1].lower() == "as" {
+				i++
+			}
+			if i+1 < len(tokens) && tokens[i+1].kind == tokenWord && !reservedWords[tokens[i+1].lower()] {
+				i++
+				aliases[strings.ToLower(unquote(tokens[i].text))] = table
+			}
+
+			// FROM 子句中以逗号分隔的多个表
+			if trigger != "from" || i+1 >= len(tokens) || tokens[i+1].text != "," {
+				break
+			}
+			i++
+		}
+	}
+	return tables, aliases
+}
+
+// resolveTable 将别名解析为表名，不是别名时按表名处理
+func resolveTable(qualifier string, aliases map[string]string) string {
+	qualifier = unquote(qualifier)
+	if table, ok := aliases[strings.ToLower(qualifier)]; ok {
+		return table
+	}
+	return qualifier
+}
+
+// unquote 去掉标识符两端的引号
+func unquote(name string) string {
+	if len(name) >= 2 {
+		switch {
+		case name[0] == '"' && name[len(name)-1] == '"',
+			name[0] == '`' && name[len(name)-1] == '`',
+			name[0] == '[' && name[len(name)-1] == ']':
+			return name[1 : len(name)-1]
+		}
+	}
+	return name
+}
+
+// tableSuggestions 返回表名候选
+func tableSuggestions(catalog Catalog) []Suggestion {
+	var suggestions []Suggestion
+	for _, table := range catalog.Tables() {
+		suggestions = append(suggestions, Suggestion{Text: table, Description: "表"})
+	}
+	return suggestions
+}
+
+// columnSuggestions 返回指定表的字段候选，同名字段只保留一个
+func columnSuggestions(catalog Catalog, tables []string) []Suggestion {
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	for _, table := range tables {
+		for _, column := range catalog.Columns(table) {
+			if seen[strings.ToLower(column)] {
+				continue
+			}
+			seen[strings.ToLower(column)] = true
+			suggestions = append(suggestions, Suggestion{Text: column, Description: "字段 (" + table + ")"})
+		}
+	}
+	return suggestions
+}
+
+// keywordSuggestions 返回关键字候选，大小写与已输入的内容保持一致
+func keywordSuggestions(dialect, word string) []Suggestion {
+	var suggestions []Suggestion
+	for _, keyword := range keywordsFor(dialect) {
+		suggestions = append(suggestions, Suggestion{Text: matchCase(keyword, word), Description: "关键字"})
+	}
+	return suggestions
+}
+
+// functionSuggestions 返回函数候选
+func functionSuggestions(dialect, word string) []Suggestion {
+	var suggestions []Suggestion
+	for _, function := range functionsFor(dialect) {
+		suggestions = append(suggestions, Suggestion{Text: matchCase(function, word) + "(", Description: "函数"})
+	}
+	return suggestions
+}
+
+// matchCase 已输入的内容为小写时返回小写形式
+func matchCase(keyword, word string) string {
+	if word != "" && word == strings.ToLower(word) {
+		return strings.ToLower(keyword)
+	}
+	return keyword
+}
+
+// filter 返回以word开头(不区分大小写)的候选
+func filter(suggestions []Suggestion, word string) []Suggestion {
+	prefix := strings.ToLower(word)
+	var result []Suggestion
+	for _, s := range suggestions {
+		if strings.HasPrefix(strings.ToLower(s.Text), prefix) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// filterQualified 返回以prefix开头的字段，候选文本带上限定名
+func filterQualified(suggestions []Suggestion, qualifier, prefix string) []Suggestion {
+	var result []Suggestion
+	for _, s := range filter(suggestions, prefix) {
+		result = append(result, Suggestion{Text: qualifier + "." + s.Text, Description: s.Description})
+	}
+	return result
+}
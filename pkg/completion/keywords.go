@@ -0,0 +1,44 @@
+package completion
+
+// commonKeywords 各数据库通用的SQL关键字
+var commonKeywords = []string{
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IN", "IS", "NULL", "LIKE", "BETWEEN", "EXISTS",
+	"AS", "DISTINCT", "JOIN", "INNER JOIN", "LEFT JOIN", "RIGHT JOIN", "FULL JOIN", "CROSS JOIN", "ON",
+	"GROUP BY", "ORDER BY", "HAVING", "ASC", "DESC", "UNION", "UNION ALL",
+	"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM",
+	"CASE", "WHEN", "THEN", "ELSE", "END", "WITH",
+}
+
+// commonFunctions 各数据库通用的函数
+var commonFunctions = []string{
+	"COUNT", "SUM", "AVG", "MIN", "MAX", "COALESCE", "NULLIF", "CAST",
+	"UPPER", "LOWER", "TRIM", "LTRIM", "RTRIM", "REPLACE", "SUBSTRING", "ROUND", "ABS",
+}
+
+// dialectKeywords 各数据库特有的关键字
+var dialectKeywords = map[string][]string{
+	"mysql":      {"LIMIT", "OFFSET", "SHOW TABLES", "REGEXP", "ON DUPLICATE KEY UPDATE", "IGNORE"},
+	"postgresql": {"LIMIT", "OFFSET", "ILIKE", "RETURNING", "ON CONFLICT", "FETCH FIRST", "ROWS ONLY"},
+	"oracle":     {"ROWNUM", "FETCH FIRST", "ROWS ONLY", "CONNECT BY", "START WITH", "MINUS", "DUAL"},
+	"mssql":      {"TOP", "OFFSET", "FETCH NEXT", "ROWS ONLY", "OUTPUT", "NOLOCK"},
+	"dameng":     {"LIMIT", "OFFSET", "ROWNUM", "TOP", "CONNECT BY", "START WITH", "MINUS", "DUAL"},
+}
+
+// dialectFunctions 各数据库特有的函数
+var dialectFunctions = map[string][]string{
+	"mysql":      {"NOW", "IFNULL", "CONCAT", "CONCAT_WS", "GROUP_CONCAT", "DATE_FORMAT", "STR_TO_DATE", "LENGTH", "CHAR_LENGTH"},
+	"postgresql": {"NOW", "CONCAT", "STRING_AGG", "ARRAY_AGG", "TO_CHAR", "TO_DATE", "DATE_TRUNC", "LENGTH", "EXTRACT"},
+	"oracle":     {"SYSDATE", "NVL", "NVL2", "DECODE", "TO_CHAR", "TO_DATE", "TO_NUMBER", "LISTAGG", "LENGTH", "INSTR"},
+	"mssql":      {"GETDATE", "ISNULL", "CONCAT", "CONVERT", "FORMAT", "LEN", "DATEADD", "DATEDIFF", "STRING_AGG"},
+	"dameng":     {"SYSDATE", "NOW", "NVL", "DECODE", "TO_CHAR", "TO_DATE", "LISTAGG", "LENGTH", "INSTR"},
+}
+
+// keywordsFor 返回指定数据库可用的关键字
+func keywordsFor(dialect string) []string {
+	return append(append([]string(nil), commonKeywords...), dialectKeywords[dialect]...)
+}
+
+// functionsFor 返回指定数据库可用的函数
+func functionsFor(dialect string) []string {
+	return append(append([]string(nil), commonFunctions...), dialectFunctions[dialect]...)
+}
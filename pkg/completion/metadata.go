@@ -0,0 +1,108 @@
+package completion
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+// Catalog 补全所需的数据库元数据
+type Catalog interface {
+	// Tables 返回所有表名
+	Tables() []string
+	// Columns 返回指定表的字段名，表不存在时返回nil
+	Columns(table string) []string
+}
+
+// Metadata 缓存当前连接的表和字段信息，避免每次按键都查询数据库
+type Metadata struct {
+	mu      sync.Mutex
+	conn    db.Connection
+	loaded  bool
+	tables  []string
+	columns map[string][]string // 键为小写表名
+	err     error
+}
+
+// NewMetadata 创建元数据缓存
+func NewMetadata() *Metadata {
+	return &Metadata{columns: make(map[string][]string)}
+}
+
+// Use 设置当前连接，连接变化时清空缓存
+func (m *Metadata) Use(conn db.Connection) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != conn {
+		m.conn = conn
+		m.reset()
+	}
+}
+
+// Refresh 清空缓存并立即重新加载表名，返回表的数量
+func (m *Metadata) Refresh() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reset()
+	m.loadTables()
+	return len(m.tables), m.err
+}
+
+// reset 清空缓存，调用方需持有锁
+func (m *Metadata) reset() {
+	m.loaded = false
+	m.tables = nil
+	m.columns = make(map[string][]string)
+	m.err = nil
+}
+
+// loadTables 首次使用时加载表名，调用方需持有锁
+func (m *Metadata) loadTables() {
+	if m.loaded || m.conn == nil {
+		return
+	}
+	// 加载失败也标记为已加载，避免每次按键重试，可通过 refresh 重新加载
+	m.loaded = true
+	m.tables, m.err = m.conn.GetTables()
+}
+
+// Tables 返回所有表名
+func (m *Metadata) Tables() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loadTables()
+	return m.tables
+}
+
+// Columns 返回指定表的字段名，表名不区分大小写
+func (m *Metadata) Columns(table string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil || table == "" {
+		return nil
+	}
+	key := strings.ToLower(table)
+	if columns, ok := m.columns[key]; ok {
+		return columns
+	}
+
+	// 使用数据库中实际的表名大小写查询字段
+	m.loadTables()
+	name := ""
+	for _, t := range m.tables {
+		if strings.EqualFold(t, table) {
+			name = t
+			break
+		}
+	}
+	if name == "" {
+		return nil
+	}
+	columns, err := m.conn.GetTableColumns(name)
+	if err != nil {
+		columns = nil
+	}
+	m.columns[key] = columns
+	return columns
+}
@@ -0,0 +1,56 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Command 返回使用的编辑器命令，依次读取 VISUAL、EDITOR 环境变量，未设置时使用系统默认编辑器
+func Command() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// Edit 在外部编辑器中编辑文本，返回保存后的内容
+func Edit(text string) (string, error) {
+	file, err := os.CreateTemp("", "datamgr-*.sql")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("写入临时文件失败: %v", err)
+	}
+
+	// 编辑器命令可以带参数，如 "code --wait"
+	args := strings.Fields(Command())
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("运行编辑器 %s 失败: %v", args[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取编辑结果失败: %v", err)
+	}
+	return string(data), nil
+}
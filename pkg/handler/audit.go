@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+const auditUsage = "用法: audit show [--since <时长或时间>] | audit verify"
+
+// RecordAudit 将在当前连接上执行的语句写入审计日志，写入失败时只输出警告
+func RecordAudit(statement string, start time.Time, rows int64, err error) {
+	if auditErr := audit.Log(statement, start, rows, err); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "写入审计日志失败: %v\n", auditErr)
+	}
+}
+
+// ParseSince 解析 --since 参数：时长如 30m、2h、7d 表示从现在往前，或者一个时间
+func ParseSince(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	if t, ok := output.ParseTime(s); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s，应为时长如 2h、7d 或时间如 2024-05-01", s)
+}
+
+// AuditResult 将审计记录转换为查询结果
+func AuditResult(entries []*audit.Entry) *output.Result {
+	result := &output.Result{Columns: []string{"时间", "用户", "配置", "数据库", "语句", "耗时(ms)", "行数", "错误"}}
+	for _, e := range entries {
+		database := e.Database
+		if e.Server != "" {
+			database = fmt.Sprintf("%s %s/%s", e.Type, e.Server, e.Database)
+		}
+		var errText interface{}
+		if e.Error != "" {
+			errText = e.Error
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			e.Time, e.User, e.Profile, database, e.Statement, e.DurationMs, e.Rows, errText,
+		})
+	}
+	return result
+}
+
+// HandleAudit 处理 audit 命令：show 显示审计日志，--since 只显示指定时间之后的记录；
+// verify 校验审计日志的哈希链是否完整
+func HandleAudit(args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		fields = []string{"show"}
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "show":
+		var since time.Time
+		switch rest := fields[1:]; {
+		case len(rest) == 0:
+		case len(rest) >= 2 && rest[0] == "--since":
+			// 时间中可能包含空格，如 2024-05-01 08:00:00
+			t, err := ParseSince(trimQuotes(strings.Join(rest[1:], " ")))
+			if err != nil {
+				return err
+			}
+			since = t
+		default:
+			return errors.New(auditUsage)
+		}
+		entries, err := audit.Load(since)
+		if err != nil {
+			return err
+		}
+		return writeResult(AuditResult(entries), outputFormat, false)
+	case "verify":
+		if len(fields) != 1 {
+			return errors.New(auditUsage)
+		}
+		count, err := audit.Verify()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(Output(), "审计日志完整，共校验 %d 条记录\n", count)
+		return nil
+	default:
+		return errors.New(auditUsage)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/browse"
+	"github.com/yuanpli/datamgr-cli/pkg/undo"
+)
+
+// HandleBrowse 以全屏方式浏览表数据
+func HandleBrowse(tableName string) error {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+	config := db.GetCurrentConfig()
+	return browse.Run(conn, config.Type, tableName, browse.DefaultRowLimit, BrowseWriter(conn))
+}
+
+// BrowseWriter 返回保存 browse 中单元格编辑的函数。编辑与交互模式的写入语句一样检查只读连接、
+// 写入审计日志，开启 undo 设置时保存修改前的行。编辑按主键只修改一行，不需要安全模式确认
+func BrowseWriter(conn db.Connection) browse.Writer {
+	return func(u *browse.Update) error {
+		start := time.Now()
+		affected, entry, err := writeCell(conn, u)
+		// 终端处于原始模式，写入审计日志失败时不输出警告
+		_ = audit.Log(u.SQL, start, affected, err)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			if err := undo.Append(entry); err != nil {
+				u.Warning = fmt.Sprintf("已保存 %s，但写入撤销日志失败: %v", u.Column, err)
+			}
+		}
+		return nil
+	}
+}
+
+// writeCell 在事务中执行单元格的 UPDATE 语句，行不存在或修改了多行时回滚。
+// 开启 undo 设置时在同一事务中先查询修改前的行，返回要保存的撤销记录
+func writeCell(conn db.Connection, u *browse.Update) (int64, *undo.Entry, error) {
+	if err := CheckWritable(u.SQL); err != nil {
+		return 0, nil, err
+	}
+	beginner, ok := conn.(db.TxBeginner)
+	if !ok {
+		return 0, nil, errors.New("当前连接不支持事务，无法编辑")
+	}
+	ctx := context.Background()
+	tx, err := beginner.BeginTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	// MySQL 在值未改变时报告修改了 0 行，先按主键确认行存在
+	var count int64
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+u.Table+" WHERE "+u.Where, u.WhereArgs...).Scan(&count); err != nil {
+		return 0, nil, err
+	}
+	if count != 1 {
+		return 0, nil, fmt.Errorf("按主键找到 %d 行，行可能已被删除或主键已被修改", count)
+	}
+
+	var entry *undo.Entry
+	if undoJournal {
+		plan := &undoPlan{
+			impact: &WriteImpact{Keyword: "UPDATE", Table: u.Table, From: u.Table, HasWhere: true, SetColumns: []string{u.Column}},
+			keys:   u.Keys,
+			sql:    u.SQL,
+			query:  "SELECT * FROM " + u.Table + " WHERE " + u.Where + lockClause(db.GetCurrentConfig().Type),
+			args:   u.WhereArgs,
+		}
+		if entry, err = plan.beforeImage(ctx, tx); err != nil {
+			return 0, nil, fmt.Errorf("无法保存执行前的数据: %v", err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, u.SQL, u.Args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	if affected > 1 {
+		return 0, nil, fmt.Errorf("预期更新 1 行，实际更新 %d 行，已回滚", affected)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return affected, entry, nil
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	cancelMu      sync.Mutex
+	cancelRunning context.CancelFunc
+)
+
+// beginCancelable 返回可被 Ctrl+C 取消的上下文，执行结束后需调用返回的函数
+func beginCancelable() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancelMu.Lock()
+	cancelRunning = cancel
+	cancelMu.Unlock()
+
+	return ctx, func() {
+		cancelMu.Lock()
+		cancelRunning = nil
+		cancelMu.Unlock()
+		cancel()
+	}
+}
+
+// CancelRunning 取消正在执行的语句，没有正在执行的语句时返回false
+func CancelRunning() bool {
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+
+	if cancelRunning == nil {
+		return false
+	}
+	cancelRunning()
+	return true
+}
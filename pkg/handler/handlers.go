@@ -18,6 +18,7 @@ import (
 	"github.com/chzyer/readline"
 	"github.com/xuri/excelize/v2"
 	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
 	"github.com/yuanpli/datamgr-cli/pkg/utils"
 )
 
@@ -27,30 +28,100 @@ func HandleHelp() {
 可用命令:
   系统命令:
     help                   - 显示此帮助信息
-    connect                - 连接数据库
+    connect [@配置名]      - 连接数据库，@配置名 使用保存的命名配置
     status                 - 显示连接状态
     exit, quit             - 退出程序
     clear                  - 清屏
+    history [关键字]       - 显示命令历史，可按关键字过滤
+    tee [文件|off]         - 将执行的命令、输出和耗时追加到日志文件，off 停止记录
+    refresh                - 重新加载自动补全使用的表和字段信息
+    Ctrl+R                 - 反向增量搜索命令历史，Esc 编辑匹配结果，Ctrl+G 取消
+    edit                   - 在 $EDITOR 中编辑最近的查询，关闭编辑器后执行
+    Ctrl+X                 - 在 $EDITOR 中编辑当前输入（为空时为最近的查询），关闭后载回提示符
+    ! <命令>               - 执行 shell 命令，单独的 ! 启动交互式 shell
+    <查询语句> | <命令>    - 将查询结果通过管道传给 shell 命令，如 | grep ERROR、| wc -l
+
+  查询库:
+    query save <名称> [-d "说明"] [SQL] - 保存 SQL 或最近的查询，save query 为其别名
+    query list             - 列出保存的查询及其参数
+    query show <名称>      - 显示保存的查询
+    query run <名称> [参数=值 ...] - 执行保存的查询，参数以 :name 绑定或展开 {{.name}} 模板
+    query delete <名称>    - 删除保存的查询
 
   配置管理:
     config                 - 显示当前默认配置
     config save            - 保存当前连接为默认配置
-    config set <项> <值>   - 设置默认配置项
+    config set <项> <值>   - 设置默认配置项或数据库类型的连接选项
     config clear           - 清除默认配置
+    config lock [--key-file [路径]] - 加密保存的密码（主密码或 0600 密钥文件）
+    config unlock          - 解密保存的密码，恢复明文保存
+    config resolve [@名称] - 显示合并后的连接配置及每个值来自哪一层
+    config trust [路径]    - 信任项目配置文件，允许其修改保存了密码的配置的服务器
+    config untrust [路径]  - 取消信任项目配置文件
+    config profile add <名称> - 将当前连接保存为命名配置
+    config profile list    - 列出命名配置，默认配置以 * 标记
+    config profile remove|rename|default - 删除、重命名配置或设置默认配置
+    config profile readonly <名称> [on|off] - 只读配置拒绝写入语句、DDL 和导入
+
+  显示设置:
+    set format <格式>      - 设置查询结果输出格式 (table, vertical, csv, tsv, json, ndjson, markdown, html)
+    set pipeformat <格式>  - 设置通过管道传给命令的结果格式，默认 tsv
+    set overflow <方式>    - 设置表格超出终端宽度时截断(truncate)或折行(wrap)
+    set pager <模式>       - 设置分页模式 (on, off, auto)，分页器取自 $PAGER，默认 less -S
+    set maxrows <行数>     - 获取超过该行数时询问是否继续，0 表示不限制
+    set nullstring|datetimeformat|timezone|timing|confirm|locale <值> - 其他会话设置
+    set safemode on|off    - 安全模式（默认开启）: UPDATE、DELETE 等先估算影响行数，没有 WHERE、
+                             DROP、TRUNCATE 或超过 safelimit 行(默认 1000)时需输入表名确认
+    set undo on            - 执行 UPDATE、DELETE 前按主键保存将被修改的行（默认关闭）
+    set [--global|--profile] <设置> <值> - 保存为全局设置或当前配置的设置，值为 default 时恢复
+    set <设置>             - 显示设置的值和来源
+    set, show settings     - 列出所有设置、当前值和来源
+    <查询语句> \G          - 以纵向记录形式显示本次查询结果
+    Ctrl+C                 - 执行查询期间取消当前查询
 
   表管理命令:
     show tables            - 列出所有表
     desc table <表名>      - 显示表结构
+    browse <表名>          - 全屏浏览表数据，支持排序、过滤、查看详情和按主键编辑
+    watch [间隔] <查询> [--until <条件>] - 每隔 N 秒(默认 2)重新执行查询并原地刷新，
+                             突出显示变化的单元格；条件如 "count = 0" 或 empty，Ctrl+C 停止
+
+  元命令:
+    \?                     - 显示元命令帮助
+    \dt [模式]             - 列出名称匹配模式的表
+    \d <表模式> [字段模式] - 显示表结构，可只显示匹配的字段
+    \di [模式]、\dv [模式] - 列出索引、视图
+    \dn、\l               - 列出模式、数据库
+    \c [数据库|@配置|default] - 切换到当前服务器上的其他数据库、命名配置或默认配置
+    \set [变量 [值|查询]]  - 设置变量，值为查询语句时取其唯一结果，不带参数时列出变量
+    \unset <变量>          - 删除变量
+    \x、\timing           - 切换纵向显示、显示执行耗时
+    \o [文件]              - 将查询结果输出到文件，不带参数恢复输出到终端
+    \tee [文件|off]        - 同 tee 命令
+    \i <文件>              - 执行脚本文件；连接后自动执行 ~/.datamgr-cli/rc.sql 和 rc/<配置名>.sql，--norc 跳过
+    \e                     - 在 $EDITOR 中编辑最近的查询并执行
+    \q                     - 退出程序
+    模式支持通配符: * 或 % 匹配任意字符，? 匹配单个字符
+    语句中的 :name 或 :'name' 以参数形式绑定变量 name 的值
 
   数据操作命令:
     SELECT [字段] FROM <表> [WHERE 条件] [LIMIT 数量]  - 查询数据
     INSERT INTO <表> SET 字段1=值1, 字段2=值2...      - 插入数据
     UPDATE <表> SET 字段=值 [WHERE 条件]             - 更新数据
     DELETE FROM <表> [WHERE 条件]                    - 删除数据
+    undo [N]                                         - 显示撤销最近 N 条 UPDATE/DELETE 的补偿语句
+    undo apply [N] | undo list | undo clear          - 在事务中执行补偿语句、列出或清除撤销日志
+    audit show [--since <时长或时间>]                 - 显示审计日志，如 --since 2h、--since 7d
+    audit verify                                     - 校验审计日志的哈希链是否完整
     IMPORT <表> FROM <文件> [FORMAT csv/excel]       - 导入数据
     EXPORT <表> [WHERE 条件] <文件> [FORMAT csv/excel] - 导出数据
 `
-	fmt.Println(helpText)
+	fmt.Fprint(Output(), helpText)
+	fmt.Fprintln(Output(), "  支持的数据库 (connect --type，可用 --option 名称=值 或 config set <选项> <值> 设置连接选项):")
+	for _, driver := range db.Drivers() {
+		fmt.Fprintf(Output(), "    %s\n", driver.Summary())
+	}
+	fmt.Fprintln(Output())
 }
 
 // HandleClear 清屏命令处理
@@ -72,12 +143,15 @@ func HandleStatus() error {
 		return errors.New("当前未连接到任何数据库")
 	}
 
-	fmt.Println("当前连接状态:")
-	fmt.Printf("  数据库类型: %s\n", config.Type)
-	fmt.Printf("  主机地址: %s\n", config.Host)
-	fmt.Printf("  端口: %d\n", config.Port)
-	fmt.Printf("  用户名: %s\n", config.User)
-	fmt.Printf("  数据库名: %s\n", config.DbName)
+	fmt.Fprintln(Output(), "当前连接状态:")
+	fmt.Fprintf(Output(), "  数据库类型: %s\n", config.Type)
+	fmt.Fprintf(Output(), "  主机地址: %s\n", config.Host)
+	fmt.Fprintf(Output(), "  端口: %d\n", config.Port)
+	fmt.Fprintf(Output(), "  用户名: %s\n", config.User)
+	fmt.Fprintf(Output(), "  数据库名: %s\n", config.DbName)
+	if config.ReadOnly {
+		fmt.Fprintln(Output(), "  只读: 是")
+	}
 	return nil
 }
 
@@ -89,63 +163,79 @@ func HandleConnect(cmdStr string) error {
 		// 交互式连接向导
 		return handleInteractiveConnect()
 	}
+	if len(args) == 2 && strings.HasPrefix(args[1], "@") {
+		return HandleConnectProfile(strings.TrimPrefix(args[1], "@"))
+	}
 
-	// 解析命令行参数
-	var dbType, host, user, password, dbName string
-	var port int = 5236 // 默认端口
-
+	// 解析命令行参数，未指定类型和端口时使用默认数据库类型及该类型的默认端口
+	values := make(map[string]string)
 	for i := 1; i < len(args); i++ {
+		field := ""
 		switch args[i] {
 		case "--type":
-			if i+1 < len(args) {
-				dbType = args[i+1]
-				i++
-			}
+			field = "type"
 		case "-H", "--host":
-			if i+1 < len(args) {
-				host = args[i+1]
-				i++
-			}
+			field = "host"
 		case "-P", "--port":
-			if i+1 < len(args) {
-				fmt.Sscanf(args[i+1], "%d", &port)
-				i++
-			}
+			field = "port"
 		case "-u", "--user":
-			if i+1 < len(args) {
-				user = args[i+1]
-				i++
-			}
+			field = "user"
 		case "-p", "--password":
-			if i+1 < len(args) {
-				password = args[i+1]
-				i++
-			}
+			field = "password"
 		case "-D", "--dbname":
+			field = "dbname"
+		case "--option":
+			// 驱动的连接选项: --option 名称=值
 			if i+1 < len(args) {
-				dbName = args[i+1]
+				name, value, ok := strings.Cut(args[i+1], "=")
+				if !ok {
+					return fmt.Errorf("连接选项格式错误: %s，应为 名称=值", args[i+1])
+				}
+				values[name] = value
 				i++
 			}
+			continue
+		}
+		if field != "" && i+1 < len(args) {
+			values[field] = args[i+1]
+			i++
 		}
 	}
 
-	// 如果没有指定数据库类型，默认为达梦
-	if dbType == "" {
-		dbType = "dameng"
+	config := utils.NewConfig()
+	if err := config.Apply(values); err != nil {
+		return err
 	}
 
-	// 验证必要参数
-	if host == "" || user == "" || password == "" || dbName == "" {
-		return errors.New("连接参数不完整，请提供主机、用户名、密码和数据库名")
+	// 执行连接，驱动要求的参数不完整时返回错误
+	if err := db.ConnectConfig(config.DbConfig()); err != nil {
+		return err
 	}
 
-	// 执行连接
-	err := db.Connect(dbType, host, port, user, password, dbName)
+	fmt.Fprintf(Output(), "已成功连接到 %s 数据库: %s\n", config.Type, config.DbName)
+	AfterConnect("")
+	return nil
+}
+
+// HandleConnectProfile 断开当前连接后使用命名配置连接，name 为空时使用默认配置
+func HandleConnectProfile(name string) error {
+	res, err := utils.ResolveConfig(name, nil)
 	if err != nil {
 		return err
 	}
-
-	fmt.Printf("已成功连接到 %s 数据库: %s\n", dbType, dbName)
+	config := res.Config
+	if db.GetCurrentConnection() != nil {
+		db.Disconnect()
+	}
+	if err := db.ConnectConfig(config.DbConfig()); err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Fprintf(Output(), "已使用默认配置连接到 %s 数据库: %s\n", config.Type, config.DbName)
+	} else {
+		fmt.Fprintf(Output(), "已使用配置 %s 连接到 %s 数据库: %s\n", name, config.Type, config.DbName)
+	}
+	AfterConnect(res.Profile)
 	return nil
 }
 
@@ -157,7 +247,7 @@ func init() {
 	var err error
 	rl, err = readline.New("")
 	if err != nil {
-		fmt.Println("初始化输入处理失败:", err)
+		fmt.Fprintln(Output(), "初始化输入处理失败:", err)
 		os.Exit(1)
 	}
 }
@@ -166,7 +256,7 @@ func init() {
 func readInput(prompt string) string {
 	if rl == nil {
 		// 如果无法使用readline，回退到简单输入
-		fmt.Print(prompt)
+		fmt.Fprint(Output(), prompt)
 		var input string
 		fmt.Scanln(&input)
 		return input
@@ -179,12 +269,12 @@ func readInput(prompt string) string {
 	line, err := rl.Readline()
 	if err != nil {
 		if err == readline.ErrInterrupt {
-			fmt.Println("^C")
+			fmt.Fprintln(Output(), "^C")
 			os.Exit(0)
 		} else if err == io.EOF {
 			return ""
 		}
-		fmt.Println("读取错误:", err)
+		fmt.Fprintln(Output(), "读取错误:", err)
 		return ""
 	}
 	
@@ -196,7 +286,7 @@ func readPassword(prompt string) string {
 	// 创建一个临时的readline实例，用于密码输入
 	rlTemp, err := readline.New(prompt)
 	if err != nil {
-		fmt.Println("初始化密码读取失败:", err)
+		fmt.Fprintln(Output(), "初始化密码读取失败:", err)
 		return ""
 	}
 	defer rlTemp.Close()
@@ -208,153 +298,115 @@ func readPassword(prompt string) string {
 	password, err := rlTemp.Readline()
 	if err != nil {
 		if err == readline.ErrInterrupt {
-			fmt.Println("^C")
+			fmt.Fprintln(Output(), "^C")
 			os.Exit(0)
 		}
-		fmt.Println("读取密码错误:", err)
+		fmt.Fprintln(Output(), "读取密码错误:", err)
 		return ""
 	}
 	
 	return strings.TrimSpace(password)
 }
 
-// handleInteractiveConnect 交互式连接向导
+// handleInteractiveConnect 交互式连接向导，按所选数据库类型的驱动描述询问连接参数和连接选项
 func handleInteractiveConnect() error {
-	fmt.Println("请输入连接信息:")
-
-	// 尝试加载默认配置
-	defaultConfig, err := utils.LoadConfig()
-	var dbType, host, user, password, dbName string
-	var port int
+	fmt.Fprintln(Output(), "请输入连接信息:")
 
-	// 默认使用达梦数据库
-	dbType = "dameng"
-	port = 5236
+	// 尝试加载默认配置，没有默认配置时使用默认数据库类型及其默认端口
+	config := utils.NewConfig()
+	res, err := utils.ResolveConfig("", nil)
 
 	// 如果有默认配置，使用默认值，但允许用户修改
-	if err == nil && defaultConfig != nil {
-		dbType = defaultConfig.Type
-		port = defaultConfig.Port
-		
+	if err == nil {
+		defaultConfig := res.Config
 		// 提示用户是否使用默认配置
-		fmt.Println("发现默认配置:")
-		fmt.Printf("  数据库类型: %s\n", defaultConfig.Type)
-		fmt.Printf("  主机地址: %s\n", defaultConfig.Host)
-		fmt.Printf("  端口: %d\n", defaultConfig.Port)
-		fmt.Printf("  用户名: %s\n", defaultConfig.User)
-		fmt.Printf("  数据库名: %s\n", defaultConfig.DbName)
-		
+		fmt.Fprintln(Output(), "发现默认配置:")
+		fmt.Fprintf(Output(), "  数据库类型: %s\n", defaultConfig.Type)
+		fmt.Fprintf(Output(), "  主机地址: %s\n", defaultConfig.Host)
+		fmt.Fprintf(Output(), "  端口: %d\n", defaultConfig.Port)
+		fmt.Fprintf(Output(), "  用户名: %s\n", defaultConfig.User)
+		fmt.Fprintf(Output(), "  数据库名: %s\n", defaultConfig.DbName)
+
 		// 读取用户选择
 		useDefault := readInput("是否使用默认配置? (y/n): ")
-		
+
 		// 如果用户选择使用默认配置
 		if strings.HasPrefix(strings.ToLower(useDefault), "y") {
-			fmt.Println("使用默认配置连接...")
-			return db.Connect(defaultConfig.Type, defaultConfig.Host, defaultConfig.Port, 
-				defaultConfig.User, defaultConfig.Password, defaultConfig.DbName)
+			fmt.Fprintln(Output(), "使用默认配置连接...")
+			if err := db.ConnectConfig(defaultConfig.DbConfig()); err != nil {
+				return err
+			}
+			AfterConnect(res.Profile)
+			return nil
 		}
-		
+
 		// 否则让用户输入新配置
-		fmt.Println("请输入新的连接信息 (直接回车使用默认值):")
+		fmt.Fprintln(Output(), "请输入新的连接信息 (直接回车使用默认值):")
+		*config = *defaultConfig
+		config.Options = make(map[string]string)
+		for name, value := range defaultConfig.Options {
+			config.Options[name] = value
+		}
 	}
 
-	// 获取数据库类型
-	fmt.Println("支持的数据库类型: dameng, mysql, postgresql, sqlite, oracle, mssql")
-	var dbTypePrompt string
-	if defaultConfig != nil {
-		dbTypePrompt = fmt.Sprintf("数据库类型 (默认 %s): ", defaultConfig.Type)
-	} else {
-		dbTypePrompt = fmt.Sprintf("数据库类型 (默认 %s): ", dbType)
+	// 获取数据库类型，修改类型时端口随之改为新类型的默认端口
+	fmt.Fprintf(Output(), "支持的数据库类型: %s\n", strings.Join(db.DriverNames(), ", "))
+	if dbTypeInput := strings.ToLower(readInput(fmt.Sprintf("数据库类型 (默认 %s): ", config.Type))); dbTypeInput != "" {
+		if err := config.SetType(dbTypeInput); err != nil {
+			fmt.Fprintf(Output(), "无效的数据库类型: %s, 将使用默认类型: %s\n", dbTypeInput, config.Type)
+		}
 	}
-	
-	dbTypeInput := readInput(dbTypePrompt)
-	
-	// 验证数据库类型
-	if dbTypeInput != "" {
-		dbTypeInput = strings.ToLower(dbTypeInput)
-		supportedDbTypes := []string{"dameng", "mysql", "postgresql", "sqlite", "oracle", "mssql"}
-		isValidType := false
-		for _, supportedType := range supportedDbTypes {
-			if dbTypeInput == supportedType {
-				isValidType = true
-				break
+	driver, err := db.LookupDriver(config.Type)
+	if err != nil {
+		return err
+	}
+
+	// 只询问该类型使用的连接参数
+	for _, field := range driver.Fields() {
+		if field == db.FieldPassword {
+			// 密码不显示，直接回车保留默认配置中的密码
+			if password := readPassword("密码: "); password != "" {
+				config.Password = password
 			}
+			continue
 		}
-		if isValidType {
-			dbType = dbTypeInput
-		} else {
-			fmt.Printf("无效的数据库类型: %s, 将使用默认类型: %s\n", dbTypeInput, dbType)
+		prompt := db.FieldLabel(field) + ": "
+		if current := config.Field(field); current != "" {
+			prompt = fmt.Sprintf("%s (默认 %s): ", db.FieldLabel(field), current)
+		}
+		if value := readInput(prompt); value != "" {
+			if err := config.SetField(field, value); err != nil {
+				return err
+			}
 		}
-	} else if defaultConfig != nil {
-		dbType = defaultConfig.Type
 	}
 
-	// 获取主机地址
-	var hostPrompt string
-	if defaultConfig != nil {
-		hostPrompt = fmt.Sprintf("主机地址 (默认 %s): ", defaultConfig.Host)
-	} else {
-		hostPrompt = "主机地址: "
-	}
-	
-	host = readInput(hostPrompt)
-	if host == "" && defaultConfig != nil {
-		host = defaultConfig.Host
-	}
-	
-	// 获取端口
-	var portPrompt string
-	if defaultConfig != nil {
-		portPrompt = fmt.Sprintf("端口 (默认 %d): ", defaultConfig.Port)
-	} else {
-		portPrompt = fmt.Sprintf("端口 (默认 %d): ", port)
-	}
-	
-	portStr := readInput(portPrompt)
-	if portStr != "" {
-		fmt.Sscanf(portStr, "%d", &port)
-	} else if defaultConfig != nil && defaultConfig.Port != 0 {
-		port = defaultConfig.Port
-	}
-	
-	// 获取用户名
-	var userPrompt string
-	if defaultConfig != nil {
-		userPrompt = fmt.Sprintf("用户名 (默认 %s): ", defaultConfig.User)
-	} else {
-		userPrompt = "用户名: "
-	}
-	
-	user = readInput(userPrompt)
-	if user == "" && defaultConfig != nil {
-		user = defaultConfig.User
-	}
-	
-	// 获取密码
-	password = readPassword("密码: ")
-	if password == "" && defaultConfig != nil {
-		password = defaultConfig.Password
-	}
-	
-	// 获取数据库名
-	var dbNamePrompt string
-	if defaultConfig != nil {
-		dbNamePrompt = fmt.Sprintf("数据库名 (默认 %s): ", defaultConfig.DbName)
-	} else {
-		dbNamePrompt = "数据库名: "
-	}
-	
-	dbName = readInput(dbNamePrompt)
-	if dbName == "" && defaultConfig != nil {
-		dbName = defaultConfig.DbName
+	// 询问驱动的连接选项，直接回车使用默认值
+	for _, opt := range driver.Options {
+		prompt := fmt.Sprintf("%s - %s", opt.Name, opt.Description)
+		if len(opt.Values) > 0 {
+			prompt += fmt.Sprintf(" [%s]", strings.Join(opt.Values, "/"))
+		}
+		current := config.Options[opt.Name]
+		if current == "" {
+			current = opt.Default
+		}
+		if current != "" {
+			prompt += fmt.Sprintf(" (默认 %s)", current)
+		}
+		if value := readInput(prompt + ": "); value != "" {
+			if err := config.SetField(opt.Name, value); err != nil {
+				fmt.Fprintf(Output(), "%v，将使用默认值\n", err)
+			}
+		}
 	}
 
-	if host == "" || user == "" || password == "" || dbName == "" {
-		return errors.New("连接参数不完整，请提供主机、用户名、密码和数据库名")
+	// 连接数据库，驱动要求的参数不完整时返回错误
+	if err := db.ConnectConfig(config.DbConfig()); err != nil {
+		return err
 	}
-
-	// 连接数据库
-	return db.Connect(dbType, host, port, user, password, dbName)
+	AfterConnect("")
+	return nil
 }
 
 // HandleInteractiveConnect 交互式连接向导（公开版本）
@@ -364,27 +416,7 @@ func HandleInteractiveConnect() error {
 
 // HandleShowTables 显示表列表
 func HandleShowTables() error {
-	conn := db.GetCurrentConnection()
-	if conn == nil {
-		return errors.New("当前未连接到任何数据库")
-	}
-
-	tables, err := conn.GetTables()
-	if err != nil {
-		return err
-	}
-
-	if len(tables) == 0 {
-		fmt.Println("数据库中没有找到表")
-		return nil
-	}
-
-	fmt.Println("表列表:")
-	for i, table := range tables {
-		fmt.Printf("%3d) %s\n", i+1, table)
-	}
-
-	return nil
+	return HandleListTables("")
 }
 
 // HandleDescribeTable 显示表结构
@@ -393,7 +425,11 @@ func HandleDescribeTable(tableName string) error {
 	if conn == nil {
 		return errors.New("当前未连接到任何数据库")
 	}
+	return describeTable(conn, tableName, "")
+}
 
+// describeTable 显示表结构，columnPattern不为空时只显示名称匹配的字段
+func describeTable(conn db.Connection, tableName, columnPattern string) error {
 	columns, err := conn.DescribeTable(tableName)
 	if err != nil {
 		return err
@@ -404,9 +440,9 @@ func HandleDescribeTable(tableName string) error {
 	}
 
 	// 打印表头
-	fmt.Printf("\n表 %s 的结构:\n", tableName)
-	fmt.Printf("%-20s %-15s %-10s %-10s %-15s %-30s\n", "字段名", "数据类型", "长度", "可空", "约束", "描述")
-	fmt.Println(strings.Repeat("-", 105))
+	fmt.Fprintf(Output(), "\n表 %s 的结构:\n", tableName)
+	fmt.Fprintf(Output(), "%-20s %-15s %-10s %-10s %-15s %-30s\n", "字段名", "数据类型", "长度", "可空", "约束", "描述")
+	fmt.Fprintln(Output(), strings.Repeat("-", 105))
 
 	for _, col := range columns {
 		// 处理不同数据库返回的列名大小写差异
@@ -421,6 +457,9 @@ func HandleDescribeTable(tableName string) error {
 		} else {
 			colName = "<未知>"
 		}
+		if !utils.MatchPattern(columnPattern, colName) {
+			continue
+		}
 
 		// 数据类型处理
 		if val, ok := col["DATA_TYPE"]; ok && val != nil {
@@ -467,10 +506,10 @@ func HandleDescribeTable(tableName string) error {
 			description = ""
 		}
 
-		fmt.Printf("%-20s %-15s %-10s %-10s %-15s %-30s\n",
+		fmt.Fprintf(Output(), "%-20s %-15s %-10s %-10s %-15s %-30s\n",
 			colName, dataType, dataLength, nullable, constraint, description)
 	}
-	fmt.Println()
+	fmt.Fprintln(Output())
 
 	return nil
 }
@@ -482,13 +521,20 @@ const (
 )
 
 // HandleImport 处理导入命令
-func HandleImport(cmdStr string) error {
+func HandleImport(cmdStr string) (err error) {
 	// 解析命令
 	// IMPORT <table> FROM <file> [FORMAT csv/excel] [MODE insert/upsert]
 	parts := strings.Fields(cmdStr)
 	if len(parts) < 4 || strings.ToUpper(parts[2]) != "FROM" {
 		return errors.New("用法: IMPORT <表名> FROM <文件路径> [FORMAT csv/excel] [MODE insert/upsert]")
 	}
+	// 导入的结果写入审计日志
+	var successCount int64
+	started := time.Now()
+	defer func() { RecordAudit(cmdStr, started, successCount, err) }()
+	if err := checkImport(); err != nil {
+		return err
+	}
 
 	// 获取表名和文件路径
 	tableName := parts[1]
@@ -669,7 +715,6 @@ func HandleImport(cmdStr string) error {
 	}
 
 	// 开始导入数据
-	successCount := 0
 	updateCount := 0
 	insertCount := 0
 	errorCount := 0
@@ -749,63 +794,15 @@ func HandleImport(cmdStr string) error {
 							continue
 						}
 					case strings.Contains(dataType, "DATE") || strings.Contains(dataType, "TIME"):
-						// 处理日期时间格式
-						// 达梦数据库支持的标准格式是 YYYY-MM-DD HH24:MI:SS 或 YYYY-MM-DD
-						dateTimeStr := value
-						
-						// 检测并处理常见的日期时间格式错误
-						if strings.Contains(dateTimeStr, "+0800") {
-							// 处理带时区信息的日期时间，比如: 2025-04-28 15:00:13.727014 +0800 +0800
-							parts := strings.Split(dateTimeStr, " ")
-							if len(parts) >= 2 {
-								// 保留日期和时间部分，去掉时区信息
-								dateTimeStr = parts[0] + " " + parts[1]
-								
-								// 处理可能的毫秒部分
-								if strings.Contains(dateTimeStr, ".") {
-									timeParts := strings.Split(dateTimeStr, ".")
-									if len(timeParts) > 1 {
-										// 只保留到秒
-										dateTimeStr = timeParts[0]
-									}
-								}
-							}
-						} else if strings.Contains(dateTimeStr, "T") && strings.Contains(dateTimeStr, "Z") {
-							// 处理ISO格式: 2025-04-28T15:00:13Z
-							dateTimeStr = strings.Replace(dateTimeStr, "T", " ", 1)
-							dateTimeStr = strings.Replace(dateTimeStr, "Z", "", 1)
-						} else if len(dateTimeStr) == 10 && strings.Count(dateTimeStr, "-") == 2 {
-							// 只有日期部分，保持不变
-						} else if len(dateTimeStr) > 19 {
-							// 如果字符串长度超过标准的日期时间格式(YYYY-MM-DD HH:MM:SS)，截取前19个字符
-							dateTimeStr = dateTimeStr[:19]
-						}
-						
-						// 检查最终格式是否符合标准
-						isDateOnly := len(dateTimeStr) == 10 && strings.Count(dateTimeStr, "-") == 2
-						isDateTime := len(dateTimeStr) == 19 && strings.Count(dateTimeStr, "-") == 2 && strings.Count(dateTimeStr, ":") == 2
-						
-						if !isDateOnly && !isDateTime {
-							// 格式仍然不符合，尝试解析为更通用的格式
-							t, err := time.Parse(time.RFC3339, value)
-							if err != nil {
-								t, err = time.Parse(time.RFC1123, value)
-							}
-							if err != nil {
-								t, err = time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", value)
-							}
-							if err != nil {
-								// 无法解析，使用当前时间
-								fmt.Printf("警告: 第 %d 行日期时间格式不正确: %s，将被忽略\n", i+1, value)
-								columns = columns[:len(columns)-1]
-								placeholders = placeholders[:len(placeholders)-1]
-								continue
-							}
-							// 格式化为达梦数据库接受的格式
-							dateTimeStr = t.Format("2006-01-02 15:04:05")
+						// 按 datetimeformat 设置及常见格式解析，保留小数秒，带时区的值转换到显示时区
+						t, ok := output.ParseTime(value)
+						if !ok {
+							fmt.Fprintf(Output(), "警告: 第 %d 行日期时间格式不正确: %s，将被忽略\n", i+1, value)
+							columns = columns[:len(columns)-1]
+							placeholders = placeholders[:len(placeholders)-1]
+							continue
 						}
-						
-						convertedValue = dateTimeStr
+						convertedValue = importTimeText(output.NormalizeTime(t))
 					default:
 						// 字符串或其他类型
 						convertedValue = value
@@ -823,7 +820,7 @@ func HandleImport(cmdStr string) error {
 		
 		// 如果没有有效列，跳过此行
 		if len(columns) == 0 {
-			fmt.Printf("警告: 第 %d 行没有有效数据，已跳过\n", i+1)
+			fmt.Fprintf(Output(), "警告: 第 %d 行没有有效数据，已跳过\n", i+1)
 			continue
 		}
 		
@@ -845,7 +842,7 @@ func HandleImport(cmdStr string) error {
 				successCount++
 			} else {
 				errorCount++
-				fmt.Printf("错误: 第 %d 行更新失败: %v\n", i+1, err)
+				fmt.Fprintf(Output(), "错误: 第 %d 行更新失败: %v\n", i+1, err)
 			}
 		} else {
 			// 执行插入操作 - 不包含ID字段
@@ -860,19 +857,27 @@ func HandleImport(cmdStr string) error {
 				successCount++
 			} else {
 				errorCount++
-				fmt.Printf("错误: 第 %d 行插入失败: %v\n", i+1, err)
+				fmt.Fprintf(Output(), "错误: 第 %d 行插入失败: %v\n", i+1, err)
 			}
 		}
 		
 		if err != nil {
-			fmt.Printf("错误: 第 %d 行操作失败: %v\n", i+1, err)
+			fmt.Fprintf(Output(), "错误: 第 %d 行操作失败: %v\n", i+1, err)
 		}
 	}
 
-	fmt.Printf("成功导入 %d 条记录到 %s\n", successCount, filePath)
+	fmt.Fprintf(Output(), "成功导入 %d 条记录到 %s\n", successCount, filePath)
 	return nil
 }
 
+// importTimeText 返回导入时写入数据库的时间文本，没有时间部分时只保留日期
+func importTimeText(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05.999999999")
+}
+
 // readCSV 读取CSV文件
 func readCSV(filePath string) ([][]string, []string, error) {
 	// 打开文件
@@ -896,6 +901,7 @@ func readCSV(filePath string) ([][]string, []string, error) {
 	
 	// 创建CSV读取器
 	csvReader := csv.NewReader(reader)
+	csvReader.Comma = csvDelimiter()
 	
 	// 读取所有记录
 	records, err := csvReader.ReadAll()
@@ -954,13 +960,17 @@ const (
 )
 
 // HandleExport 处理导出命令
-func HandleExport(cmdStr string) error {
+func HandleExport(cmdStr string) (err error) {
 	// 解析命令
 	// EXPORT <table> [WHERE 条件] <file> [FORMAT csv/excel]
 	parts := strings.Fields(cmdStr)
 	if len(parts) < 3 {
 		return errors.New("用法: EXPORT <表名> [WHERE 条件] <文件名> [FORMAT csv/excel]")
 	}
+	// 导出的结果写入审计日志
+	var exported int64
+	started := time.Now()
+	defer func() { RecordAudit(cmdStr, started, exported, err) }()
 
 	// 获取表名
 	tableName := parts[1]
@@ -1057,7 +1067,7 @@ func HandleExport(cmdStr string) error {
 	orderedColumns, err := conn.GetTableColumns(tableName)
 	if err != nil {
 		// 如果获取列顺序失败，记录错误但不中断执行
-		fmt.Printf("警告: 无法获取表的列顺序: %v，将使用默认排序\n", err)
+		fmt.Fprintf(Output(), "警告: 无法获取表的列顺序: %v，将使用默认排序\n", err)
 		orderedColumns = nil
 	}
 	
@@ -1089,7 +1099,8 @@ func HandleExport(cmdStr string) error {
 		return err
 	}
 	
-	fmt.Printf("成功导出 %d 条记录到 %s\n", len(results), filePath)
+	exported = int64(len(results))
+	fmt.Fprintf(Output(), "成功导出 %d 条记录到 %s\n", len(results), filePath)
 	return nil
 }
 
@@ -1114,6 +1125,7 @@ func exportToCSV(data []map[string]interface{}, filePath string, fieldDescriptio
 	
 	// 创建CSV写入器
 	writer := csv.NewWriter(file)
+	writer.Comma = csvDelimiter()
 	defer writer.Flush()
 	
 	// 获取列顺序
@@ -1169,29 +1181,8 @@ func exportToCSV(data []map[string]interface{}, filePath string, fieldDescriptio
 	for _, record := range data {
 		var row []string
 		for _, header := range headers {
-			// 将各种类型转换为字符串
-			var cell string
-			if record[header] == nil {
-				cell = ""
-			} else {
-				cell = fmt.Sprintf("%v", record[header])
-				// 处理日期时间格式，只保留到秒
-				if strings.Contains(cell, "-") && strings.Contains(cell, ":") {
-					// 判断是否是日期时间格式
-					if strings.Contains(cell, "+") || strings.Contains(cell, ".") {
-						parts := strings.Split(cell, " ")
-						if len(parts) >= 2 {
-							// 提取日期和时间部分，截断到秒
-							timeStr := parts[1]
-							if strings.Contains(timeStr, ".") {
-								timeStr = strings.Split(timeStr, ".")[0]
-							}
-							// 重建日期时间字符串，不包含时区和毫秒部分
-							cell = parts[0] + " " + timeStr
-						}
-					}
-				}
-			}
+			// 时间按 datetimeformat 和 timezone 设置格式化，NULL 导出为空
+			cell, _ := output.FormatValue(record[header])
 			row = append(row, cell)
 		}
 		if err := writer.Write(row); err != nil {
@@ -1264,32 +1255,22 @@ func exportToExcel(data []map[string]interface{}, filePath string, fieldDescript
 		f.SetCellValue(sheetName, cell, displayHeader)
 	}
 	
+	dateStyle, err := output.ExcelDateStyle(f)
+	if err != nil {
+		return err
+	}
+	
 	// 写入数据行
 	for rowIdx, record := range data {
 		for colIdx, header := range headers {
 			cell, _ := excelize.CoordinatesToCellName(colIdx+1, rowIdx+2)
 			
-			// 获取值并处理时间格式
-			var value interface{} = record[header]
-			if value != nil {
-				strValue := fmt.Sprintf("%v", value)
-				// 处理日期时间格式，只保留到秒
-				if strings.Contains(strValue, "-") && strings.Contains(strValue, ":") {
-					// 判断是否是日期时间格式
-					if strings.Contains(strValue, "+") || strings.Contains(strValue, ".") {
-						parts := strings.Split(strValue, " ")
-						if len(parts) >= 2 {
-							// 提取日期和时间部分，截断到秒
-							timeStr := parts[1]
-							if strings.Contains(timeStr, ".") {
-								timeStr = strings.Split(timeStr, ".")[0]
-							}
-							// 重建日期时间字符串，不包含时区和毫秒部分
-							strValue = parts[0] + " " + timeStr
-							value = strValue
-						}
-					}
-				}
+			value := record[header]
+			if t, ok := value.(time.Time); ok {
+				// 时间写为 Excel 日期单元格
+				f.SetCellValue(sheetName, cell, output.NormalizeTime(t))
+				f.SetCellStyle(sheetName, cell, cell, dateStyle)
+				continue
 			}
 			
 			f.SetCellValue(sheetName, cell, value)
@@ -1324,4 +1305,6 @@ func Close() {
 		rl.Close()
 		rl = nil
 	}
+	SetOutputFile("")
+	StopTee()
 } 
\ No newline at end of file
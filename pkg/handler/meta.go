@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+var (
+	// expandedFormat 开启纵向显示前的输出格式，为空表示未开启
+	expandedFormat string
+	// timing 是否显示语句执行耗时
+	timing bool
+)
+
+// ToggleExpanded 切换纵向显示，返回切换后是否为纵向显示
+func ToggleExpanded() bool {
+	if expandedFormat != "" {
+		outputFormat = expandedFormat
+		expandedFormat = ""
+		return false
+	}
+	expandedFormat = outputFormat
+	outputFormat = output.FormatVertical
+	return true
+}
+
+// ToggleTiming 切换是否显示语句执行耗时，返回切换后的状态
+func ToggleTiming() bool {
+	timing = !timing
+	if timing {
+		sessionSettings["timing"] = "on"
+	} else {
+		sessionSettings["timing"] = "off"
+	}
+	return timing
+}
+
+// HandleListTables 列出名称匹配模式的表，模式支持 * % ? 通配符
+func HandleListTables(pattern string) error {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+
+	tables, err := matchingTables(conn, pattern)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		if pattern != "" {
+			fmt.Fprintf(Output(), "没有与 %s 匹配的表\n", pattern)
+		} else {
+			fmt.Fprintln(Output(), "数据库中没有找到表")
+		}
+		return nil
+	}
+
+	fmt.Fprintln(Output(), "表列表:")
+	for i, table := range tables {
+		fmt.Fprintf(Output(), "%3d) %s\n", i+1, table)
+	}
+	return nil
+}
+
+// HandleDescribeTables 显示名称匹配模式的表结构，columnPattern不为空时只显示匹配的字段
+func HandleDescribeTables(tablePattern, columnPattern string) error {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+
+	// 不含通配符时直接按表名查询，以支持带模式名的表
+	if !utils.HasWildcard(tablePattern) {
+		return describeTable(conn, tablePattern, columnPattern)
+	}
+
+	tables, err := matchingTables(conn, tablePattern)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("没有与 %s 匹配的表", tablePattern)
+	}
+	for _, table := range tables {
+		if err := describeTable(conn, table, columnPattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleCatalog 列出索引、视图、模式或数据库，pattern用于过滤名称
+func HandleCatalog(kind, pattern string) error {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+	query, err := db.CatalogQuery(db.GetCurrentConfig().Type, kind)
+	if err != nil {
+		return err
+	}
+	result, err := QueryResult(conn, query)
+	if err != nil {
+		return err
+	}
+
+	if pattern != "" {
+		// 索引按表名或索引名过滤，其他按第一列过滤
+		nameColumns := 1
+		if kind == db.CatalogIndexes {
+			nameColumns = 2
+		}
+		var rows [][]interface{}
+		for _, row := range result.Rows {
+			for i := 0; i < nameColumns && i < len(row); i++ {
+				if utils.MatchPattern(pattern, fmt.Sprintf("%v", row[i])) {
+					rows = append(rows, row)
+					break
+				}
+			}
+		}
+		result.Rows = rows
+	}
+	return writeResult(result, outputFormat, false)
+}
+
+// matchingTables 返回名称匹配模式的表
+func matchingTables(conn db.Connection, pattern string) ([]string, error) {
+	tables, err := conn.GetTables()
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, table := range tables {
+		if utils.MatchPattern(pattern, table) {
+			matched = append(matched, table)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// 分页模式
+const (
+	PagerOn   = "on"   // 总是使用分页器
+	PagerOff  = "off"  // 不使用分页器
+	PagerAuto = "auto" // 输出超过终端高度时使用分页器
+)
+
+// DefaultMaxRows 交互模式下获取结果前需要确认的默认行数上限
+const DefaultMaxRows = 1000
+
+var (
+	// pagerMode 当前分页模式
+	pagerMode = PagerAuto
+	// maxRows 获取超过该行数时询问是否继续，0表示不限制
+	maxRows = DefaultMaxRows
+	// terminalSize 返回终端输出的列数和行数，测试时可替换
+	terminalSize = consoleSize
+)
+
+// SetPagerMode 设置分页模式
+func SetPagerMode(mode string) error {
+	mode = strings.ToLower(mode)
+	if mode != PagerOn && mode != PagerOff && mode != PagerAuto {
+		return fmt.Errorf("不支持的分页模式: %s，支持: on, off, auto", mode)
+	}
+	pagerMode = mode
+	return nil
+}
+
+// PagerMode 返回当前分页模式
+func PagerMode() string {
+	return pagerMode
+}
+
+// SetMaxRows 设置获取结果前需要确认的行数上限，0表示不限制
+func SetMaxRows(n int) error {
+	if n < 0 {
+		return fmt.Errorf("行数上限不能为负数: %d", n)
+	}
+	maxRows = n
+	return nil
+}
+
+// MaxRows 返回获取结果前需要确认的行数上限
+func MaxRows() int {
+	return maxRows
+}
+
+// consoleSize 终端输出为标准输出时返回其所在终端的列数和行数，否则ok为false
+func consoleSize() (width, height int, ok bool) {
+	if console != io.Writer(os.Stdout) {
+		return 0, 0, false
+	}
+	return utils.TerminalSize()
+}
+
+// SetTerminalSize 设置获取终端大小的函数，size为nil时恢复为读取标准输出所在的终端
+func SetTerminalSize(size func() (width, height int, ok bool)) {
+	if size == nil {
+		size = consoleSize
+	}
+	terminalSize = size
+}
+
+// PagerCommand 返回分页器命令，优先使用 $PAGER
+func PagerCommand() []string {
+	if pager := strings.TrimSpace(os.Getenv("PAGER")); pager != "" {
+		return strings.Fields(pager)
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"more"}
+	}
+	if _, err := exec.LookPath("less"); err == nil {
+		// -S 不折行以便横向滚动，-R 保留颜色
+		return []string{"less", "-S", "-R"}
+	}
+	return []string{"more"}
+}
+
+// ShouldPage 判断输出是否需要通过分页器显示
+func ShouldPage(text string) bool {
+	if pagerMode == PagerOff {
+		return false
+	}
+	_, height, ok := terminalSize()
+	if !ok {
+		// 非终端输出不分页
+		return false
+	}
+	if pagerMode == PagerOn {
+		return true
+	}
+	// 预留一行给提示符
+	return strings.Count(text, "\n") >= height-1
+}
+
+// writePaged 将输出写到终端，需要时通过分页器显示
+func writePaged(text string) {
+	if !ShouldPage(text) {
+		fmt.Fprint(Output(), text)
+		return
+	}
+
+	args := PagerCommand()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// 分页器运行期间 Ctrl+C 由分页器处理，不退出程序
+	_, done := beginCancelable()
+	defer done()
+
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			// 分页器无法启动，直接输出
+			fmt.Fprint(Output(), text)
+			return
+		}
+	}
+	// 分页器直接写终端，会话日志需单独记录
+	logWrite([]byte(text))
+}
@@ -0,0 +1,462 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// verticalSuffix 语句以 \G 结尾时按纵向格式输出
+const verticalSuffix = `\G`
+
+var (
+	// outputFormat 交互模式下查询结果的输出格式
+	outputFormat = output.FormatTable
+	// overflowMode 表格内容超出终端宽度时的处理方式
+	overflowMode = output.OverflowTruncate
+)
+
+// SetOutputFormat 设置交互模式下查询结果的输出格式
+func SetOutputFormat(format string) error {
+	format = output.Normalize(format)
+	if !output.IsValidFormat(format) {
+		return fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", format, strings.Join(output.Formats(), ", "))
+	}
+	if output.IsBinaryFormat(format) {
+		return fmt.Errorf("%s 格式只能写入文件，请在 export 或 query run -o 中使用", format)
+	}
+	outputFormat = format
+	return nil
+}
+
+// OutputFormat 返回交互模式下查询结果的输出格式
+func OutputFormat() string {
+	return outputFormat
+}
+
+// SetOverflowMode 设置表格内容超出终端宽度时的处理方式
+func SetOverflowMode(mode string) error {
+	mode = strings.ToLower(mode)
+	if mode != output.OverflowTruncate && mode != output.OverflowWrap {
+		return fmt.Errorf("不支持的处理方式: %s，支持: %s, %s", mode, output.OverflowTruncate, output.OverflowWrap)
+	}
+	overflowMode = mode
+	return nil
+}
+
+// OverflowMode 返回表格内容超出终端宽度时的处理方式
+func OverflowMode() string {
+	return overflowMode
+}
+
+// RenderOptions 返回按会话设置渲染结果的选项，用于写入文件和管道
+func RenderOptions() output.Options {
+	opts := output.DefaultOptions()
+	opts.NullString = nullString
+	return opts
+}
+
+// DisplayOptions 返回输出到终端时的渲染选项，表格宽度适配终端
+func DisplayOptions() output.Options {
+	opts := RenderOptions()
+	opts.Overflow = overflowMode
+	if width, _, ok := utils.TerminalSize(); ok {
+		opts.MaxWidth = width
+		opts.Color = true
+	}
+	return opts
+}
+
+// HandleSQL 执行SQL语句并按当前输出格式输出结果
+func HandleSQL(sql string) error {
+	return runSQL(sql, variables)
+}
+
+// HandleSQLWithParams 执行SQL语句，params 中的参数与会话变量同名时优先使用
+func HandleSQLWithParams(sql string, params map[string]string) error {
+	vars := Variables()
+	for name, value := range params {
+		vars[name] = value
+	}
+	return runSQL(sql, vars)
+}
+
+// runSQL 将变量作为参数绑定后执行SQL语句，执行和被拒绝的语句都写入审计日志
+func runSQL(sql string, vars map[string]string) (err error) {
+	format := outputFormat
+	// 查询以 "| 命令" 结尾时结果通过管道传给该命令
+	var pipeCommand string
+	if IsQueryStatement(sql) {
+		if query, command, ok := SplitPipe(sql); ok {
+			sql, pipeCommand = query, command
+			format = pipeFormat
+		}
+	}
+	if strings.HasSuffix(sql, verticalSuffix) {
+		sql = strings.TrimSpace(strings.TrimSuffix(sql, verticalSuffix))
+		format = output.FormatVertical
+	}
+
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+
+	if err := CheckWritable(sql); err != nil {
+		RecordAudit(sql, time.Now(), 0, err)
+		return err
+	}
+	// 安全模式按未绑定变量的语句估算影响行数，开启 undo 设置时检查语句能否撤销
+	var plan *undoPlan
+	if !IsQueryStatement(sql) {
+		proceed := confirmWrite(conn, sql, vars)
+		if proceed {
+			plan, proceed = planUndo(conn, sql, vars)
+		}
+		if !proceed {
+			fmt.Fprintln(Output(), "已取消执行")
+			return nil
+		}
+	}
+
+	// 审计日志记录未绑定变量的语句
+	statement := sql
+	// 会话变量作为参数绑定，不拼接到语句中
+	sql, args := BindVariables(sql, db.GetCurrentConfig().Type, vars)
+
+	start := time.Now()
+	defer printTiming(start)
+	var rows int64
+	// 在确认提示中取消的语句没有执行，不记录审计日志
+	cancelled := false
+	defer func() {
+		if !cancelled {
+			RecordAudit(statement, start, rows, err)
+		}
+	}()
+
+	if !IsQueryStatement(sql) {
+		// 直接执行更新操作
+		affected, err := executeWrite(conn, plan, sql, args)
+		if errors.Is(err, errWriteCancelled) {
+			cancelled = true
+			fmt.Fprintln(Output(), "已取消执行")
+			return nil
+		}
+		if err != nil {
+			return AnnotateSQLError(sql, err)
+		}
+		rows = affected
+		fmt.Fprintf(Output(), "操作成功，影响了 %d 行数据\n", affected)
+		return nil
+	}
+
+	// 查询期间 Ctrl+C 取消查询而不是退出程序
+	ctx, done := beginCancelable()
+	result, truncated, err := FetchResult(ctx, conn, sql, args, maxRows, confirmFetchMore)
+	done()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return errors.New("查询已取消")
+		}
+		return AnnotateSQLError(sql, err)
+	}
+	rows = int64(len(result.Rows))
+	if pipeCommand != "" {
+		return pipeResult(result, format, pipeCommand)
+	}
+	return writeResult(result, format, truncated)
+}
+
+// writeResult 按指定格式输出查询结果，设置了输出文件时写入文件，否则输出到终端
+func writeResult(result *output.Result, format string, truncated bool) error {
+	if len(result.Rows) == 0 {
+		fmt.Fprintln(Output(), "查询没有返回结果")
+		return nil
+	}
+
+	opts := DisplayOptions()
+	if outputFile != nil {
+		// 写入文件时不按终端宽度截断，也不输出颜色
+		opts = RenderOptions()
+	}
+
+	var buf bytes.Buffer
+	if err := output.Render(&buf, format, result, opts); err != nil {
+		return err
+	}
+	// 机器可读格式不附加统计信息
+	if format == output.FormatTable || format == output.FormatVertical {
+		writeSummary(&buf, result, truncated)
+	}
+
+	if outputFile != nil {
+		if _, err := outputFile.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("写入输出文件失败: %v", err)
+		}
+		fmt.Fprintf(Output(), "已将 %d 行结果写入 %s\n", len(result.Rows), outputFile.Name())
+		return nil
+	}
+	writePaged(buf.String())
+	return nil
+}
+
+// writeSummary 输出结果的行数，结果被截断时说明已达到行数上限
+func writeSummary(w io.Writer, result *output.Result, truncated bool) {
+	fmt.Fprintf(w, "\n共 %d 行结果\n", len(result.Rows))
+	if truncated {
+		fmt.Fprintf(w, "已达到行数上限 %d，其余数据未获取（可用 set maxrows 调整）\n", maxRows)
+	}
+}
+
+// confirmFetchMore 获取的行数达到上限时询问是否继续
+func confirmFetchMore(fetched int) bool {
+	answer := readInput(fmt.Sprintf("已获取 %d 行数据，是否继续获取剩余数据? (y/n): ", fetched))
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
+
+// IsQueryStatement 判断语句是否返回结果集
+func IsQueryStatement(sql string) bool {
+	fields := strings.Fields(strings.ToLower(sql))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "select", "with", "show", "desc", "describe", "explain", "values":
+		return true
+	}
+	return false
+}
+
+// QueryResult 执行查询并按列顺序构建结果
+func QueryResult(conn db.Connection, sql string) (*output.Result, error) {
+	return QueryResultWithParams(conn, sql)
+}
+
+// QueryResultWithParams 执行带参数的查询并按列顺序构建结果
+func QueryResultWithParams(conn db.Connection, sql string, args ...interface{}) (*output.Result, error) {
+	result, _, err := FetchResult(context.Background(), conn, sql, args, 0, nil)
+	return result, err
+}
+
+// execute 执行更新语句，有参数时使用参数绑定
+func execute(conn db.Connection, sql string, args []interface{}) (int64, error) {
+	if len(args) > 0 {
+		return conn.ExecuteWithParams(sql, args...)
+	}
+	return conn.Execute(sql)
+}
+
+// FetchResult 执行查询并构建结果，行数超过limit时调用confirm询问是否继续，confirm为nil时不继续，
+// 返回的truncated表示结果未完整获取
+func FetchResult(ctx context.Context, conn db.Connection, sql string, args []interface{}, limit int, confirm func(int) bool) (result *output.Result, truncated bool, err error) {
+	sqlLower := strings.ToLower(strings.TrimSpace(sql))
+	fields := strings.Fields(sqlLower)
+
+	// show tables 与 desc table 由连接接口提供，统一转换为结果集
+	if len(fields) >= 2 && fields[0] == "show" && fields[1] == "tables" {
+		tables, err := conn.GetTables()
+		if err != nil {
+			return nil, false, err
+		}
+		result := &output.Result{Columns: []string{"TABLE_NAME"}}
+		for _, table := range tables {
+			result.Rows = append(result.Rows, []interface{}{table})
+		}
+		return result, false, nil
+	}
+	if len(fields) >= 2 && (fields[0] == "desc" || fields[0] == "describe") {
+		tableName := strings.Fields(sql)[1]
+		if len(fields) >= 3 && fields[1] == "table" {
+			tableName = strings.Fields(sql)[2]
+		}
+		columns, err := conn.DescribeTable(tableName)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(columns) == 0 {
+			return nil, false, fmt.Errorf("表 %s 不存在或没有字段", tableName)
+		}
+		return output.NewResult(describeColumns(columns[0]), columns), false, nil
+	}
+
+	// 支持逐行读取的连接可以取消查询并限制获取的行数
+	if streamer, ok := conn.(db.StreamQuerier); ok {
+		it, err := streamer.QueryStream(ctx, sql, args...)
+		if err != nil {
+			return nil, false, err
+		}
+		defer it.Close()
+
+		var rows []map[string]interface{}
+		for {
+			row, ok, err := it.Next()
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				break
+			}
+			// 读到超出上限的一行时才询问，结果恰好等于上限时不算截断
+			if limit > 0 && len(rows) == limit {
+				if confirm == nil || !confirm(len(rows)) {
+					truncated = true
+					break
+				}
+				limit = 0
+			}
+			rows = append(rows, row)
+		}
+		return output.NewResult(it.Columns(), rows), truncated, nil
+	}
+
+	var rows []map[string]interface{}
+	if len(args) > 0 {
+		rows, err = conn.QueryWithParams(sql, args...)
+	} else {
+		rows, err = conn.Query(sql)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) == 0 {
+		return &output.Result{}, false, nil
+	}
+
+	isSelectStar := isSelectAllQuery(sqlLower)
+	columns := getOrderedColumns(rows[0], sqlLower, conn, isSelectStar)
+	return output.NewResult(columns, rows), false, nil
+}
+
+// describeColumns 返回表结构结果的列顺序
+func describeColumns(row map[string]interface{}) []string {
+	preferred := []string{"COLUMN_NAME", "DATA_TYPE", "DATA_LENGTH", "NULLABLE", "CONSTRAINT_TYPE", "DESCRIPTION"}
+	var columns []string
+	for _, col := range preferred {
+		for key := range row {
+			if strings.EqualFold(key, col) {
+				columns = append(columns, key)
+				break
+			}
+		}
+	}
+	if len(columns) == 0 {
+		for key := range row {
+			columns = append(columns, key)
+		}
+	}
+	return columns
+}
+
+// isSelectAllQuery 判断是否为SELECT *查询
+func isSelectAllQuery(sqlLower string) bool {
+	// 去除多余空格
+	sqlLower = strings.TrimSpace(sqlLower)
+
+	// 检查是否以SELECT *开头
+	if strings.HasPrefix(sqlLower, "select *") {
+		return true
+	}
+
+	// 检查是否有SELECT和FROM之间只有*（可能有空格）
+	selectIndex := strings.Index(sqlLower, "select")
+	fromIndex := strings.Index(sqlLower, "from")
+
+	if selectIndex >= 0 && fromIndex > selectIndex {
+		between := strings.TrimSpace(sqlLower[selectIndex+6 : fromIndex])
+		if between == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getTableNameFromSQL 从SQL语句中提取表名
+func getTableNameFromSQL(sqlLower string) string {
+	fromIndex := strings.Index(sqlLower, "from")
+	if fromIndex < 0 {
+		return ""
+	}
+
+	afterFrom := sqlLower[fromIndex+4:]
+	parts := strings.Fields(afterFrom)
+	if len(parts) == 0 {
+		return ""
+	}
+
+	// 处理表名可能有的别名、WHERE子句等
+	tableName := parts[0]
+	// 移除可能的逗号、括号等
+	tableName = strings.TrimRight(tableName, ",();")
+
+	return tableName
+}
+
+// getOrderedColumns 根据查询类型获取有序的列名
+func getOrderedColumns(resultRow map[string]interface{}, sqlLower string, conn db.Connection, isSelectStar bool) []string {
+	// 如果不是SELECT *查询，保持原始顺序
+	if !isSelectStar {
+		var columns []string
+		for col := range resultRow {
+			columns = append(columns, col)
+		}
+		return columns
+	}
+
+	// 对于SELECT *查询，尝试按表结构排序
+	tableName := getTableNameFromSQL(sqlLower)
+	if tableName == "" {
+		// 无法确定表名，使用原始顺序
+		var columns []string
+		for col := range resultRow {
+			columns = append(columns, col)
+		}
+		return columns
+	}
+
+	// 获取表的列顺序
+	tableColumns, err := conn.GetTableColumns(tableName)
+	if err != nil || len(tableColumns) == 0 {
+		// 获取列顺序失败，使用原始顺序
+		var columns []string
+		for col := range resultRow {
+			columns = append(columns, col)
+		}
+		return columns
+	}
+
+	// 使用表结构顺序排序结果列
+	var orderedColumns []string
+
+	// 首先添加按表结构顺序的列
+	for _, col := range tableColumns {
+		if _, exists := resultRow[col]; exists {
+			orderedColumns = append(orderedColumns, col)
+		}
+	}
+
+	// 添加可能的额外列（不在表结构中的列）
+	for col := range resultRow {
+		found := false
+		for _, orderedCol := range orderedColumns {
+			if col == orderedCol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			orderedColumns = append(orderedColumns, col)
+		}
+	}
+
+	return orderedColumns
+}
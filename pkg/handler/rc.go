@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+var (
+	// scriptRunner 执行脚本文件的函数，由交互命令行注册，与 \i 使用相同的实现
+	scriptRunner func(path string) error
+	// rcDisabled 为 true 时连接后不执行启动脚本（--norc）
+	rcDisabled bool
+	// runningRC 正在执行启动脚本，脚本中切换连接时不再重复执行
+	runningRC bool
+)
+
+// SetScriptRunner 注册执行脚本文件的函数
+func SetScriptRunner(run func(path string) error) {
+	scriptRunner = run
+}
+
+// DisableRC 设置连接后是否跳过启动脚本
+func DisableRC(disabled bool) {
+	rcDisabled = disabled
+}
+
+// runRC 依次执行 rc.sql 和 profile 的启动脚本，脚本的输出写到标准错误，不影响查询结果的输出
+func runRC(profile string) {
+	if rcDisabled || runningRC || scriptRunner == nil {
+		return
+	}
+	files, err := utils.RCFiles(profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "读取启动脚本失败:", err)
+		return
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	runningRC = true
+	prev := console
+	console = os.Stderr
+	defer func() {
+		console = prev
+		runningRC = false
+	}()
+	for _, path := range files {
+		if err := scriptRunner(path); err != nil {
+			fmt.Fprintf(os.Stderr, "执行启动脚本 %s 失败: %v\n", path, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// DefaultSafeLimit 安全模式下需要输入确认的默认影响行数
+const DefaultSafeLimit = 1000
+
+var (
+	// safeMode 交互模式下执行 UPDATE、DELETE、DROP、TRUNCATE 前估算影响行数，危险的语句需要输入表名确认
+	safeMode = true
+	// safeLimit 估算影响行数超过该值时需要输入确认，0 表示不按行数确认
+	safeLimit = DefaultSafeLimit
+)
+
+// ErrReadOnly 只读连接拒绝执行修改数据的语句
+var ErrReadOnly = errors.New("当前连接为只读")
+
+// writeKeywords 只读连接中查询语句也不能包含的关键字，如 WITH ... DELETE、SELECT ... INTO、SELECT ... FOR UPDATE
+var writeKeywords = map[string]bool{
+	"insert": true, "update": true, "delete": true, "merge": true, "into": true,
+	"create": true, "alter": true, "drop": true, "truncate": true, "grant": true, "revoke": true,
+}
+
+// sessionStatements 只读连接中允许执行的会话语句
+var sessionStatements = map[string]bool{"use": true, "set": true}
+
+// unsafeSettings SET 语句中包含这些词时会修改密码、全局设置、事务的读写模式或权限，
+// 只读连接中不允许执行，如 SET PASSWORD、SET GLOBAL、SET TRANSACTION READ WRITE、SET IDENTITY_INSERT
+var unsafeSettings = []string{"password", "global", "persist", "transaction", "identity_insert", "role", "authorization"}
+
+// IsReadOnlyStatement 判断语句是否不修改数据和数据库对象
+func IsReadOnlyStatement(sql string) bool {
+	fields := strings.Fields(strings.ToLower(sql))
+	if len(fields) == 0 {
+		return true
+	}
+	if sessionStatements[fields[0]] {
+		return isSessionSetting(sql)
+	}
+	if !IsQueryStatement(sql) {
+		return false
+	}
+	for _, tok := range sqllex.Lex(sql) {
+		if (tok.Kind == sqllex.Keyword || tok.Kind == sqllex.Identifier) && writeKeywords[strings.ToLower(tok.Text)] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSessionSetting 判断 USE、SET 语句是否只修改当前会话的设置。
+// 关键字和标识符中包含 unsafeSettings 中的词时不是，如 @@global.read_only、default_transaction_read_only
+func isSessionSetting(sql string) bool {
+	for _, tok := range sqllex.Lex(sql) {
+		if tok.Kind != sqllex.Keyword && tok.Kind != sqllex.Identifier {
+			continue
+		}
+		word := strings.ToLower(tok.Text)
+		for _, unsafe := range unsafeSettings {
+			if strings.Contains(word, unsafe) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CheckWritable 当前连接为只读时拒绝执行修改数据的语句
+func CheckWritable(sql string) error {
+	config := db.GetCurrentConfig()
+	if config == nil || !config.ReadOnly || IsReadOnlyStatement(sql) {
+		return nil
+	}
+	return fmt.Errorf("%w，不能执行 %s 语句", ErrReadOnly, strings.ToUpper(strings.Fields(sql)[0]))
+}
+
+// checkImport 当前连接为只读时拒绝导入
+func checkImport() error {
+	if config := db.GetCurrentConfig(); config != nil && config.ReadOnly {
+		return fmt.Errorf("%w，不能导入数据", ErrReadOnly)
+	}
+	return nil
+}
+
+// WriteImpact 修改数据的语句的分析结果，用于安全模式
+type WriteImpact struct {
+	// Keyword 语句的关键字：UPDATE、DELETE、DROP 或 TRUNCATE
+	Keyword string
+	// Table 语句操作的表或对象，无法确定时为空
+	Table string
+	// HasWhere UPDATE、DELETE 语句是否有 WHERE 条件
+	HasWhere bool
+	// From 受影响的行所在的表及 WHERE 条件，如 "orders o WHERE o.id = 1"，
+	// 多表更新、删除等无法用单表查询定位受影响的行时为空
+	From string
+	// Limited 语句带有 ORDER BY、LIMIT、TOP 等子句，只修改满足 WHERE 条件的部分行
+	Limited bool
+	// SetColumns UPDATE 语句 SET 子句中赋值的列，不含表名或别名前缀
+	SetColumns []string
+}
+
+// CountSQL 返回估算影响行数的 COUNT 查询，无法估算时为空
+func (w *WriteImpact) CountSQL() string {
+	if w.From == "" {
+		return ""
+	}
+	return "SELECT COUNT(*) FROM " + w.From
+}
+
+// Reasons 返回不看影响行数也需要输入确认的原因
+func (w *WriteImpact) Reasons() []string {
+	switch w.Keyword {
+	case "UPDATE", "DELETE":
+		if !w.HasWhere {
+			return []string{fmt.Sprintf("%s 语句没有 WHERE 条件，将影响表中所有行", w.Keyword)}
+		}
+	case "DROP":
+		return []string{"DROP 语句将删除对象及其中的全部数据"}
+	case "TRUNCATE":
+		return []string{"TRUNCATE 语句将清空表中的全部数据"}
+	}
+	return nil
+}
+
+// ConfirmText 返回确认执行时需要输入的文本：操作的表名，无法确定表名时为语句关键字
+func (w *WriteImpact) ConfirmText() string {
+	if w.Table != "" {
+		return w.Table
+	}
+	return w.Keyword
+}
+
+// AnalyzeWrite 分析 UPDATE、DELETE、DROP、TRUNCATE 语句，其他语句返回 ok=false
+func AnalyzeWrite(sql string) (impact *WriteImpact, ok bool) {
+	sql = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+	// 去掉空白和注释，记录括号深度
+	type token struct {
+		sqllex.Token
+		lower string
+		depth int
+	}
+	var tokens []token
+	depth := 0
+	for _, tok := range sqllex.Lex(sql) {
+		if tok.Kind == sqllex.Whitespace || tok.Kind == sqllex.Comment {
+			continue
+		}
+		if tok.Text == ")" {
+			depth--
+		}
+		tokens = append(tokens, token{Token: tok, lower: strings.ToLower(tok.Text), depth: depth})
+		if tok.Text == "(" {
+			depth++
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, false
+	}
+
+	impact = &WriteImpact{Keyword: strings.ToUpper(tokens[0].Text)}
+	i := 1
+	// skip 跳过指定的修饰词
+	skip := func(words ...string) {
+		for i < len(tokens) {
+			found := false
+			for _, word := range words {
+				if tokens[i].lower == word {
+					found = true
+				}
+			}
+			if !found {
+				return
+			}
+			i++
+		}
+	}
+	// name 读取对象名
+	name := func() (string, bool) {
+		if i < len(tokens) && tokens[i].Kind == sqllex.Identifier {
+			i++
+			return tokens[i-1].Text, true
+		}
+		return "", false
+	}
+
+	switch impact.Keyword {
+	case "DROP":
+		if i >= len(tokens) {
+			return impact, true
+		}
+		kind := tokens[i].lower
+		i++
+		skip("if", "exists")
+		impact.Table, _ = name()
+		if kind == "table" {
+			impact.From = impact.Table
+		}
+		return impact, true
+	case "TRUNCATE":
+		skip("table")
+		impact.Table, _ = name()
+		impact.From = impact.Table
+		return impact, true
+	case "UPDATE":
+		skip("low_priority", "ignore", "only")
+	case "DELETE":
+		skip("low_priority", "quick", "ignore")
+		skip("from")
+		skip("only")
+	default:
+		return nil, false
+	}
+
+	// UPDATE/DELETE: 表名和可选的别名
+	tableStart := i
+	table, found := name()
+	if !found {
+		// 如 DELETE TOP (10) FROM ...，只检查 WHERE 条件
+		for _, tok := range tokens {
+			switch {
+			case tok.lower == "where" && tok.depth == 0:
+				impact.HasWhere = true
+			case tok.lower == "top" && tok.depth == 0:
+				impact.Limited = true
+			}
+		}
+		return impact, true
+	}
+	impact.Table = table
+	skip("as")
+	if i < len(tokens) && tokens[i].Kind == sqllex.Identifier {
+		i++
+	}
+	tableEnd := tokens[i-1].Pos + len(tokens[i-1].Text)
+
+	// 多表更新和删除无法用单表 COUNT 估算，UPDATE 的 SET 子句中的逗号除外
+	simple, inSet := true, false
+	where, end := -1, len(sql)
+	for n := i; n < len(tokens); n++ {
+		tok := tokens[n]
+		if tok.lower == "rownum" {
+			// Oracle、达梦的 WHERE ROWNUM <= n
+			impact.Limited = true
+		}
+		if tok.depth != 0 {
+			continue
+		}
+		// SET 子句中 "=" 之前、"SET" 或 "," 之后的标识符为赋值的列
+		if inSet && where < 0 && tok.Kind == sqllex.Identifier && n+1 < len(tokens) && tokens[n+1].Text == "=" &&
+			(tokens[n-1].lower == "set" || tokens[n-1].lower == ",") {
+			column := tok.Text
+			if dot := strings.LastIndexByte(column, '.'); dot >= 0 {
+				column = column[dot+1:]
+			}
+			impact.SetColumns = append(impact.SetColumns, column)
+		}
+		switch tok.lower {
+		case "set":
+			inSet = true
+		case ",":
+			if where < 0 && !inSet {
+				simple = false
+			}
+		case "join", "using", "from":
+			if where < 0 {
+				simple = false
+			}
+		case "where":
+			if where < 0 {
+				where = tok.Pos
+				impact.HasWhere = true
+			}
+		case "order", "limit", "fetch", "offset", "top":
+			impact.Limited = true
+			if where >= 0 && end == len(sql) {
+				end = tok.Pos
+			}
+		case "returning", "output":
+			if where >= 0 && end == len(sql) {
+				end = tok.Pos
+			}
+		}
+	}
+	if !simple {
+		return impact, true
+	}
+
+	impact.From = sql[tokens[tableStart].Pos:tableEnd]
+	if where >= 0 {
+		impact.From += " " + strings.TrimSpace(sql[where:end])
+	}
+	return impact, true
+}
+
+// confirmWrite 交互模式下执行修改数据的语句前确认，返回 false 表示取消。
+// 开启安全模式时先在回滚的事务中估算影响行数，没有 WHERE 条件、DROP、TRUNCATE
+// 或影响行数超过 safelimit 的语句需要输入表名确认，其他语句按 confirm 设置确认。
+// sql 为未绑定变量的语句，估算用的查询单独绑定 vars
+func confirmWrite(conn db.Connection, sql string, vars map[string]string) bool {
+	impact, ok := AnalyzeWrite(sql)
+	if !safeMode || !ok {
+		return confirmStatement(sql)
+	}
+
+	reasons := impact.Reasons()
+	if countSQL := impact.CountSQL(); countSQL != "" {
+		countSQL, args := BindVariables(countSQL, db.GetCurrentConfig().Type, vars)
+		ctx, done := beginCancelable()
+		count, err := db.CountInTx(ctx, conn, countSQL, args...)
+		done()
+		if err != nil {
+			fmt.Fprintf(Output(), "无法估算影响行数: %v\n", err)
+		} else {
+			fmt.Fprintf(Output(), "预计影响 %d 行\n", count)
+			if safeLimit > 0 && count > int64(safeLimit) {
+				reasons = append(reasons, fmt.Sprintf("影响行数超过 safelimit 设置的 %d 行", safeLimit))
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		return confirmStatement(sql)
+	}
+
+	for _, reason := range reasons {
+		fmt.Fprintln(Output(), "警告:", reason)
+	}
+	text := impact.ConfirmText()
+	answer := readInput(fmt.Sprintf("请输入 %s 确认执行，其他输入将取消: ", text))
+	return strings.EqualFold(strings.TrimSpace(answer), text)
+}
@@ -0,0 +1,455 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// 设置的取值类型
+const (
+	SettingBool   = "bool"
+	SettingInt    = "int"
+	SettingEnum   = "enum"
+	SettingString = "string"
+)
+
+// 设置的保存范围
+const (
+	ScopeSession = "session" // 只在本次运行中有效
+	ScopeGlobal  = "global"  // 保存为全局设置
+	ScopeProfile = "profile" // 保存到当前连接使用的配置
+)
+
+// resetValue 作为设置值时删除该范围内的值，恢复为下一层的值
+const resetValue = "default"
+
+// TimezoneNone 时区设置为 none 时保持数据库返回的时区
+const TimezoneNone = "none"
+
+var (
+	// nullString 表格类格式中 NULL 的显示文本
+	nullString = "NULL"
+	// dateTimeFormat 时间值的显示格式，同时用于解析导入文件中的时间
+	dateTimeFormat = output.DateTimeLayout
+	// displayLocation 显示时间使用的时区，为 nil 时保持数据库返回的时区
+	displayLocation *time.Location
+	// confirmDestructive 交互模式下执行 UPDATE、DELETE、DROP 等语句前是否确认
+	confirmDestructive bool
+	// locale 区域设置，决定导出和导入 CSV 文件的分隔符
+	locale = "C"
+
+	// 各层保存的设置，后面的覆盖前面的：默认值 < 全局 < 配置 < 会话
+	globalSettings  map[string]string
+	profileSettings map[string]string
+	sessionSettings = make(map[string]string)
+	// settingsProfile 当前设置所属的配置名，未使用命名配置连接时为空
+	settingsProfile string
+)
+
+// dateTimeAliases 时间格式的别名
+var dateTimeAliases = map[string]string{
+	"datetime":    time.DateTime,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+}
+
+// localePattern 区域设置：C、POSIX 或 语言[_地区]，如 zh_CN、de_DE
+var localePattern = regexp.MustCompile(`^([a-z]{2,3})([_-][A-Za-z]{2})?$`)
+
+// decimalCommaLanguages 以逗号作小数点的语言，CSV 文件使用分号分隔
+var decimalCommaLanguages = map[string]bool{
+	"de": true, "fr": true, "es": true, "it": true, "pt": true, "nl": true, "ru": true, "pl": true,
+	"cs": true, "sv": true, "da": true, "fi": true, "nb": true, "tr": true, "id": true, "vi": true,
+}
+
+// Setting 一项会话设置
+type Setting struct {
+	Name        string
+	Description string
+	Type        string
+	// Values 枚举类型的可选值
+	Values  []string
+	Default string
+	// check 检查并规范化取值，为空时按类型检查
+	check func(value string) (string, error)
+	// apply 应用已检查过的值
+	apply func(value string)
+}
+
+// settings 所有设置，按 show settings 的显示顺序排列
+var settings = []*Setting{
+	{
+		Name: "format", Description: "查询结果的输出格式", Type: SettingEnum,
+		Values: output.TextFormats(), Default: output.FormatTable,
+		check: func(value string) (string, error) {
+			format := output.Normalize(value)
+			if !output.IsValidFormat(format) || output.IsBinaryFormat(format) {
+				return "", fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", value, strings.Join(output.TextFormats(), ", "))
+			}
+			return format, nil
+		},
+		apply: func(value string) {
+			outputFormat = value
+			expandedFormat = ""
+		},
+	},
+	{
+		Name: "pipeformat", Description: "通过管道传给外部命令的结果格式", Type: SettingEnum,
+		Values: output.TextFormats(), Default: output.FormatTSV,
+		apply: func(value string) { pipeFormat = value },
+	},
+	{
+		Name: "nullstring", Description: "表格类格式中 NULL 的显示文本", Type: SettingString, Default: "NULL",
+		check: func(value string) (string, error) { return value, nil },
+		apply: func(value string) { nullString = value },
+	},
+	{
+		Name: "datetimeformat", Description: "时间的显示和导入格式，Go 时间布局或 datetime、rfc3339、rfc3339nano", Type: SettingString,
+		Default: output.DateTimeLayout,
+		check: func(value string) (string, error) {
+			if _, ok := dateTimeAliases[strings.ToLower(value)]; ok {
+				return strings.ToLower(value), nil
+			}
+			if layout := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(value); layout == value {
+				return "", fmt.Errorf("无效的时间格式: %s，请使用 Go 时间布局，如 %s", value, output.DateTimeLayout)
+			}
+			return value, nil
+		},
+		apply: func(value string) {
+			dateTimeFormat = value
+			if layout, ok := dateTimeAliases[value]; ok {
+				dateTimeFormat = layout
+			}
+			output.SetTimeFormat(dateTimeFormat, displayLocation)
+		},
+	},
+	{
+		Name: "timezone", Description: "显示时间使用的时区: local、UTC、IANA 时区名，none 保持数据库返回的时区", Type: SettingString,
+		Default: TimezoneNone,
+		check: func(value string) (string, error) {
+			switch strings.ToLower(value) {
+			case TimezoneNone, "local":
+				return strings.ToLower(value), nil
+			}
+			if _, err := time.LoadLocation(value); err != nil {
+				return "", fmt.Errorf("无效的时区: %s", value)
+			}
+			return value, nil
+		},
+		apply: func(value string) {
+			switch value {
+			case TimezoneNone:
+				displayLocation = nil
+			case "local":
+				displayLocation = time.Local
+			default:
+				displayLocation, _ = time.LoadLocation(value)
+			}
+			output.SetTimeFormat(dateTimeFormat, displayLocation)
+		},
+	},
+	{
+		Name: "maxrows", Description: "获取超过该行数时询问是否继续，0 表示不限制", Type: SettingInt,
+		Default: strconv.Itoa(DefaultMaxRows),
+		apply:   func(value string) { maxRows, _ = strconv.Atoi(value) },
+	},
+	{
+		Name: "timing", Description: "显示语句执行耗时", Type: SettingBool, Default: "off",
+		apply: func(value string) { timing = value == "on" },
+	},
+	{
+		Name: "confirm", Description: "交互模式下执行 UPDATE、DELETE、DROP、TRUNCATE、ALTER 前确认", Type: SettingBool, Default: "off",
+		apply: func(value string) { confirmDestructive = value == "on" },
+	},
+	{
+		Name: "safemode", Description: "交互模式下执行 UPDATE、DELETE、DROP、TRUNCATE 前估算影响行数，没有 WHERE 条件、DROP、TRUNCATE 或超过 safelimit 行时需输入表名确认", Type: SettingBool, Default: "on",
+		apply: func(value string) { safeMode = value == "on" },
+	},
+	{
+		Name: "safelimit", Description: "安全模式下估算影响行数超过该值时需输入表名确认，0 表示不按行数确认", Type: SettingInt,
+		Default: strconv.Itoa(DefaultSafeLimit),
+		apply:   func(value string) { safeLimit, _ = strconv.Atoi(value) },
+	},
+	{
+		Name: "undo", Description: "执行 UPDATE、DELETE 前按主键保存将被修改的行，可用 undo 命令撤销", Type: SettingBool, Default: "off",
+		apply: func(value string) { undoJournal = value == "on" },
+	},
+	{
+		Name: "pager", Description: "分页模式", Type: SettingEnum,
+		Values: []string{PagerAuto, PagerOn, PagerOff}, Default: PagerAuto,
+		apply: func(value string) { pagerMode = value },
+	},
+	{
+		Name: "overflow", Description: "表格内容超出终端宽度时截断或折行", Type: SettingEnum,
+		Values: []string{output.OverflowTruncate, output.OverflowWrap}, Default: output.OverflowTruncate,
+		apply: func(value string) { overflowMode = value },
+	},
+	{
+		Name: "locale", Description: "区域设置，如 zh_CN、de_DE；以逗号作小数点的语言导出和导入 CSV 时使用分号分隔", Type: SettingString,
+		Default: "C",
+		check: func(value string) (string, error) {
+			if value == "C" || value == "POSIX" || localePattern.MatchString(value) {
+				return value, nil
+			}
+			return "", fmt.Errorf("无效的区域设置: %s，如 C、zh_CN、de_DE", value)
+		},
+		apply: func(value string) { locale = value },
+	},
+}
+
+// Settings 返回所有设置
+func Settings() []*Setting {
+	return settings
+}
+
+// LookupSetting 返回指定名称的设置
+func LookupSetting(name string) (*Setting, error) {
+	for _, s := range settings {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("未知的设置: %s，可用 show settings 查看所有设置", name)
+}
+
+// SettingNames 返回所有设置的名称
+func SettingNames() []string {
+	names := make([]string, len(settings))
+	for i, s := range settings {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Normalize 按设置的类型检查取值并返回规范化后的值
+func (s *Setting) Normalize(value string) (string, error) {
+	if s.check != nil {
+		return s.check(value)
+	}
+	switch s.Type {
+	case SettingBool:
+		switch strings.ToLower(value) {
+		case "on", "true", "yes", "1":
+			return "on", nil
+		case "off", "false", "no", "0":
+			return "off", nil
+		}
+		return "", fmt.Errorf("%s 的值应为 on 或 off", s.Name)
+	case SettingInt:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return "", fmt.Errorf("%s 的值应为非负整数", s.Name)
+		}
+		return strconv.Itoa(n), nil
+	case SettingEnum:
+		value = strings.ToLower(value)
+		for _, v := range s.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%s 不支持 %s，可选值为: %s", s.Name, value, strings.Join(s.Values, ", "))
+	}
+	return value, nil
+}
+
+// Value 返回设置当前的值及其来源
+func (s *Setting) Value() (value, source string) {
+	if v, ok := sessionSettings[s.Name]; ok {
+		return v, "会话"
+	}
+	if v, ok := profileSettings[s.Name]; ok {
+		return v, "配置 " + settingsProfile
+	}
+	if v, ok := globalSettings[s.Name]; ok {
+		return v, "全局"
+	}
+	return s.Default, "默认值"
+}
+
+// LoadSettings 读取配置文件中的全局设置和 profile 的设置并按 默认值 < 全局 < 配置 < 会话 的顺序应用，
+// 本次运行中修改的会话设置保持不变。已保存的值无效时使用下一层的值并返回错误
+func LoadSettings(profile string) error {
+	global, local, err := utils.LoadSettings(profile)
+	if err != nil {
+		return err
+	}
+	globalSettings, profileSettings, settingsProfile = global, local, profile
+	return applySettings()
+}
+
+// applySettings 应用每个设置的当前值，跳过无效的值
+func applySettings() error {
+	var errs []error
+	for _, s := range settings {
+		value, source := s.Value()
+		normalized, err := s.Normalize(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s中的设置 %s 无效: %v", source, s.Name, err))
+			normalized = s.Default
+		}
+		s.apply(normalized)
+	}
+	return errors.Join(errs...)
+}
+
+// SetSetting 修改设置并立即应用。scope 为 ScopeSession 时只在本次运行中有效，
+// ScopeGlobal、ScopeProfile 时保存到配置文件；值为 default 时删除该范围内的值
+func SetSetting(scope, name, value string) error {
+	s, err := LookupSetting(name)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(value, resetValue) {
+		if value, err = s.Normalize(value); err != nil {
+			return err
+		}
+	} else {
+		value = ""
+	}
+
+	switch scope {
+	case ScopeSession:
+		if value == "" {
+			delete(sessionSettings, s.Name)
+		} else {
+			sessionSettings[s.Name] = value
+		}
+	case ScopeGlobal, ScopeProfile:
+		profile := ""
+		if scope == ScopeProfile {
+			if settingsProfile == "" {
+				return errors.New("当前连接没有使用命名配置，无法保存到配置")
+			}
+			profile = settingsProfile
+		}
+		if err := utils.SaveSetting(profile, s.Name, value); err != nil {
+			return err
+		}
+		// 保存后由保存的值决定，不再使用会话中修改的值
+		delete(sessionSettings, s.Name)
+		return LoadSettings(settingsProfile)
+	default:
+		return fmt.Errorf("未知的设置范围: %s", scope)
+	}
+	return applySettings()
+}
+
+// SettingsProfile 返回当前设置所属的配置名
+func SettingsProfile() string {
+	return settingsProfile
+}
+
+// settingsResult 将所有设置构建为查询结果，用于 show settings
+func settingsResult() *output.Result {
+	result := &output.Result{Columns: []string{"设置", "值", "来源", "说明"}}
+	for _, s := range settings {
+		value, source := s.Value()
+		result.Rows = append(result.Rows, []interface{}{s.Name, value, source, s.Description})
+	}
+	return result
+}
+
+// HandleShowSettings 列出所有设置的当前值和来源
+func HandleShowSettings() error {
+	return output.Render(Output(), output.FormatTable, settingsResult(), DisplayOptions())
+}
+
+// AfterConnect 连接成功后加载 profile 的设置并执行启动脚本，profile 为空表示未使用命名配置。
+// 切换到同一配置下的其他数据库时不重新加载设置，但会再次执行启动脚本。之后的审计记录使用该配置名
+func AfterConnect(profile string) {
+	audit.SetProfile(profile)
+	if profile != settingsProfile || profileSettings == nil {
+		if err := LoadSettings(profile); err != nil {
+			fmt.Fprintln(os.Stderr, "加载设置失败:", err)
+		}
+	}
+	runRC(profile)
+}
+
+// csvDelimiter 导出和导入 CSV 文件使用的分隔符，由区域设置决定
+func csvDelimiter() rune {
+	if m := localePattern.FindStringSubmatch(locale); m != nil && decimalCommaLanguages[m[1]] {
+		return ';'
+	}
+	return ','
+}
+
+// isDestructiveStatement 判断语句是否会修改或删除已有数据或对象，返回语句的第一个关键字
+func isDestructiveStatement(sql string) (string, bool) {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "", false
+	}
+	keyword := strings.ToUpper(fields[0])
+	switch keyword {
+	case "UPDATE", "DELETE", "DROP", "TRUNCATE", "ALTER":
+		return keyword, true
+	}
+	return "", false
+}
+
+// confirmStatement 开启 confirm 设置时询问是否执行修改或删除数据的语句
+func confirmStatement(sql string) bool {
+	keyword, ok := isDestructiveStatement(sql)
+	if !confirmDestructive || !ok {
+		return true
+	}
+	answer := readInput(fmt.Sprintf("即将执行 %s 语句，确认执行? (y/n): ", keyword))
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
+
+// HandleSet 处理 set 命令：无参数时列出所有设置，只有设置名时显示其值和来源，
+// set [--global|--profile] <设置> <值> 修改设置，值为 default 时恢复为下一层的值
+func HandleSet(args string) error {
+	scope := ScopeSession
+	for {
+		switch {
+		case strings.HasPrefix(args, "--global"):
+			scope, args = ScopeGlobal, strings.TrimSpace(strings.TrimPrefix(args, "--global"))
+			continue
+		case strings.HasPrefix(args, "--profile"):
+			scope, args = ScopeProfile, strings.TrimSpace(strings.TrimPrefix(args, "--profile"))
+			continue
+		}
+		break
+	}
+	if args == "" {
+		if scope != ScopeSession {
+			return errors.New("用法: set [--global|--profile] <设置> <值>")
+		}
+		return HandleShowSettings()
+	}
+
+	name, value, hasValue := strings.Cut(args, " ")
+	s, err := LookupSetting(name)
+	if err != nil {
+		return err
+	}
+	if !hasValue {
+		value, source := s.Value()
+		fmt.Fprintf(Output(), "%s = %s (%s)\n", s.Name, value, source)
+		fmt.Fprintf(Output(), "%s", s.Description)
+		if len(s.Values) > 0 {
+			fmt.Fprintf(Output(), "，可选值: %s", strings.Join(s.Values, ", "))
+		}
+		fmt.Fprintln(Output())
+		return nil
+	}
+
+	if err := SetSetting(scope, s.Name, trimQuotes(strings.TrimSpace(value))); err != nil {
+		return err
+	}
+	value, source := s.Value()
+	fmt.Fprintf(Output(), "%s 已设置为: %s (%s)\n", s.Name, value, source)
+	return nil
+}
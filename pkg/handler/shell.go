@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// pipeFormat 通过管道传给外部命令的查询结果格式
+var pipeFormat = output.FormatTSV
+
+// PipeFormat 返回通过管道输出时使用的格式
+func PipeFormat() string {
+	return pipeFormat
+}
+
+// SetPipeFormat 设置通过管道输出时使用的格式
+func SetPipeFormat(format string) error {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if !output.IsValidFormat(format) || output.IsBinaryFormat(format) {
+		return fmt.Errorf("不支持的管道输出格式: %s，支持的格式为: %s", format, strings.Join(output.TextFormats(), ", "))
+	}
+	pipeFormat = format
+	return nil
+}
+
+// SplitPipe 拆分 "<查询> | <命令>"，只识别字符串和注释之外的最后一个单独的 |，
+// 且 | 之后的第一个词必须是可执行的命令，以免将按位或运算符当作管道
+func SplitPipe(sql string) (query, command string, ok bool) {
+	tokens := sqllex.Lex(sql)
+	for i := len(tokens) - 1; i >= 0; i-- {
+		tok := tokens[i]
+		if tok.Kind != sqllex.Operator || tok.Text != "|" {
+			continue
+		}
+		if (i > 0 && tokens[i-1].Text == "|") || (i+1 < len(tokens) && tokens[i+1].Text == "|") {
+			// || 为字符串连接运算符
+			continue
+		}
+		query = strings.TrimSpace(sql[:tok.Pos])
+		command = strings.TrimSpace(sql[tok.Pos+1:])
+		fields := strings.Fields(command)
+		if query == "" || len(fields) == 0 {
+			return "", "", false
+		}
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			return "", "", false
+		}
+		return query, command, true
+	}
+	return "", "", false
+}
+
+// shellCommand 返回通过系统 shell 执行命令的进程，command 为空时启动交互式 shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		if command == "" {
+			return exec.Command("cmd")
+		}
+		return exec.Command("cmd", "/C", command)
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "sh"
+	}
+	if command == "" {
+		return exec.Command(shell)
+	}
+	return exec.Command(shell, "-c", command)
+}
+
+// commandOutput 外部命令的输出，输出到终端时直接使用标准输出以便交互式程序使用
+func commandOutput() io.Writer {
+	if console != io.Writer(os.Stdout) {
+		return console
+	}
+	return os.Stdout
+}
+
+// runCommand 运行外部命令，命令自身的非零退出码不作为错误
+func runCommand(cmd *exec.Cmd) error {
+	// 外部命令运行期间 Ctrl+C 由该命令处理，不退出程序
+	_, done := beginCancelable()
+	defer done()
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil
+		}
+		return fmt.Errorf("无法执行命令: %v", err)
+	}
+	return nil
+}
+
+// HandleShell 执行 shell 命令，command 为空时启动交互式 shell，退出后回到提示符
+func HandleShell(command string) error {
+	cmd := shellCommand(strings.TrimSpace(command))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = commandOutput()
+	cmd.Stderr = os.Stderr
+	return runCommand(cmd)
+}
+
+// pipeResult 将查询结果按指定格式写入外部命令的标准输入
+func pipeResult(result *output.Result, format, command string) error {
+	cmd := shellCommand(command)
+	cmd.Stdout = commandOutput()
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	_, done := beginCancelable()
+	defer done()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("无法执行命令: %v", err)
+	}
+	renderErr := output.Render(stdin, format, result, RenderOptions())
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return fmt.Errorf("执行命令失败: %v", err)
+		}
+	}
+	// 命令提前退出（如 head）时不再读取剩余结果，不视为错误
+	if renderErr != nil && !errors.Is(renderErr, syscall.EPIPE) && !errors.Is(renderErr, os.ErrClosed) {
+		return renderErr
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// dollarTag PostgreSQL 的 $$ 或 $tag$ 引用的开始标记
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+var (
+	// routineObjects CREATE 之后表示过程块的对象类型
+	routineObjects = map[string]bool{"procedure": true, "proc": true, "function": true, "trigger": true}
+	// createObjects CREATE 之后的其他对象类型，遇到时不再向后查找
+	createObjects = map[string]bool{
+		"table": true, "view": true, "index": true, "sequence": true, "schema": true, "database": true,
+		"user": true, "role": true, "synonym": true, "materialized": true, "extension": true, "domain": true,
+	}
+	// transactionBegin BEGIN 之后的这些词表示开始事务而不是语句块
+	transactionBegin = map[string]bool{
+		"": true, ";": true, "transaction": true, "tran": true, "work": true, "distributed": true,
+		"isolation": true, "read": true, "deferred": true, "immediate": true, "exclusive": true,
+	}
+	// endQualifiers END 之后的这些词结束的是 IF、LOOP 等控制结构，不对应 BEGIN 或 CASE
+	endQualifiers = map[string]bool{"if": true, "loop": true, "while": true, "repeat": true, "for": true}
+	// bodyStatements 过程体中的语句开头，出现后 IS、AS 不再表示声明部分
+	bodyStatements = map[string]bool{
+		"select": true, "insert": true, "update": true, "delete": true, "merge": true,
+		"set": true, "call": true, "values": true,
+	}
+	// triggerEvents 触发器头部中 INSERT、UPDATE 等事件之前的词
+	triggerEvents = map[string]bool{"before": true, "after": true, "for": true, "of": true, "or": true, ",": true}
+	// cursorOptions PostgreSQL 的 DECLARE 游标语句中游标名之后的词
+	cursorOptions = map[string]bool{
+		"cursor": true, "binary": true, "asensitive": true, "insensitive": true, "scroll": true, "no": true,
+	}
+)
+
+// SplitStatements 按分号拆分多条SQL语句。引号、注释和 $$ 引用中的分号不拆分；
+// 存储过程、函数、触发器和 BEGIN ... END 块作为一条语句，单独一行的 / 或 GO 也结束一条语句
+func SplitStatements(text string) []string {
+	s := &splitter{text: text, tokens: lexScript(text)}
+	for i, t := range s.tokens {
+		switch {
+		case t.Kind == sqllex.Comment && (strings.HasPrefix(t.Text, "--") || s.words == 0 && !isDirectiveComment(t.Text)):
+			// 丢弃行注释和语句开头的注释，保留优化器提示等注释
+			continue
+		case s.isTerminatorLine(t):
+			s.flush()
+			continue
+		case t.Kind == sqllex.Operator && t.Text == ";" && s.canEnd():
+			// PL/SQL 块需要保留 END 之后的分号
+			if s.block {
+				s.current.WriteString(t.Text)
+			}
+			s.flush()
+			continue
+		case t.Kind != sqllex.Whitespace && t.Kind != sqllex.Comment:
+			s.feed(i)
+		}
+		s.current.WriteString(t.Text)
+	}
+	s.flush()
+	return s.statements
+}
+
+// IsBlockStatement 判断语句是否为存储过程、函数、触发器或 BEGIN ... END 等过程块，
+// 过程块末尾 END 之后的分号是语句的一部分
+func IsBlockStatement(sql string) bool {
+	s := &splitter{text: sql, tokens: lexScript(sql)}
+	for i, t := range s.tokens {
+		if t.Kind != sqllex.Whitespace && t.Kind != sqllex.Comment {
+			s.feed(i)
+		}
+	}
+	return s.block
+}
+
+// splitter 拆分语句的状态
+type splitter struct {
+	text       string
+	tokens     []sqllex.Token
+	statements []string
+	current    strings.Builder
+
+	words  int    // 当前语句中已读取的词法单元数，不含空白和注释
+	block  bool   // 当前语句是过程块，其中的分号不一定结束语句
+	whole  bool   // 包或类型体，只在单独一行的 / 或文本末尾结束
+	begun  bool   // 已出现 BEGIN
+	decl   bool   // 已出现 IS、AS 或 DECLARE，BEGIN 之前的分号属于声明部分
+	body   bool   // 已出现过程体中的语句
+	depth  int    // 未结束的 BEGIN 和 CASE 数
+	parens int    // 未闭合的括号数
+	prev   string // 上一个词法单元的小写文本
+}
+
+// flush 结束当前语句
+func (s *splitter) flush() {
+	if stmt := strings.TrimSpace(s.current.String()); stmt != "" {
+		s.statements = append(s.statements, stmt)
+	}
+	s.current.Reset()
+	*s = splitter{text: s.text, tokens: s.tokens, statements: s.statements}
+}
+
+// canEnd 判断当前位置的分号是否结束语句
+func (s *splitter) canEnd() bool {
+	switch {
+	case !s.block:
+		return true
+	case s.whole:
+		return false
+	case s.begun:
+		return s.depth == 0
+	default:
+		// MySQL 中没有 BEGIN 的单语句过程体以分号结束
+		return !s.decl
+	}
+}
+
+// feed 读取第i个词法单元，更新过程块的状态
+func (s *splitter) feed(i int) {
+	t := s.tokens[i]
+	word := strings.ToLower(t.Text)
+	prev := s.prev
+	s.prev = word
+	s.words++
+	if s.words == 1 {
+		s.classify(i, word)
+		return
+	}
+	if !s.block {
+		return
+	}
+
+	switch word {
+	case "(":
+		s.parens++
+	case ")":
+		s.parens--
+	case "begin":
+		if !transactionBegin[s.next(i)] {
+			s.depth++
+			s.begun = true
+		}
+	case "case":
+		s.depth++
+	case "end":
+		if !endQualifiers[s.next(i)] && s.depth > 0 {
+			s.depth--
+		}
+	case "declare":
+		s.decl = true
+	case "is", "as":
+		if s.begun || s.body || s.parens > 0 {
+			break
+		}
+		if j := s.nextIndex(i); j >= 0 && s.tokens[j].Kind == sqllex.String {
+			// PostgreSQL 的函数体是字符串，整条语句以分号结束
+			s.block = false
+			break
+		}
+		s.decl = true
+	default:
+		if bodyStatements[word] && s.parens == 0 && !triggerEvents[prev] {
+			s.body = true
+		}
+	}
+}
+
+// classify 根据语句开头判断是否为过程块
+func (s *splitter) classify(i int, word string) {
+	switch word {
+	case "begin":
+		if !transactionBegin[s.next(i)] {
+			s.block, s.begun, s.depth = true, true, 1
+		}
+	case "declare":
+		// T-SQL 的 DECLARE @变量 和 PostgreSQL 的 DECLARE 游标是普通语句
+		j := s.nextIndex(i)
+		if j >= 0 && s.tokens[j].Text != "@" && !cursorOptions[s.next(j)] {
+			s.block, s.decl = true, true
+		}
+	case "create":
+		for j, n := s.nextIndex(i), 0; j >= 0 && n < 10; j, n = s.nextIndex(j), n+1 {
+			object := strings.ToLower(s.tokens[j].Text)
+			switch {
+			case routineObjects[object]:
+				s.block = true
+			case object == "package" || object == "type" && s.next(j) == "body":
+				s.block, s.whole = true, true
+			case !createObjects[object] && object != "(":
+				continue
+			}
+			return
+		}
+	}
+}
+
+// nextIndex 返回第i个词法单元之后第一个不是空白或注释的词法单元的下标，不存在时返回-1
+func (s *splitter) nextIndex(i int) int {
+	for j := i + 1; j < len(s.tokens); j++ {
+		if kind := s.tokens[j].Kind; kind != sqllex.Whitespace && kind != sqllex.Comment {
+			return j
+		}
+	}
+	return -1
+}
+
+// next 返回第i个词法单元之后第一个不是空白或注释的词法单元的小写文本，不存在时返回空字符串
+func (s *splitter) next(i int) string {
+	if j := s.nextIndex(i); j >= 0 {
+		return strings.ToLower(s.tokens[j].Text)
+	}
+	return ""
+}
+
+// isTerminatorLine 判断词法单元是否为单独一行的 / 或 GO，Oracle、达梦和 SQL Server 的脚本用它结束语句块
+func (s *splitter) isTerminatorLine(t sqllex.Token) bool {
+	if t.Text != "/" && !strings.EqualFold(t.Text, "go") {
+		return false
+	}
+	start := strings.LastIndexByte(s.text[:t.Pos], '\n') + 1
+	end := len(s.text)
+	if j := strings.IndexByte(s.text[t.Pos:], '\n'); j >= 0 {
+		end = t.Pos + j
+	}
+	return strings.TrimSpace(s.text[start:end]) == t.Text
+}
+
+// isDirectiveComment 判断块注释是否为 MySQL 的条件注释或优化器提示，这些注释需要发送给数据库
+func isDirectiveComment(text string) bool {
+	return strings.HasPrefix(text, "/*!") || strings.HasPrefix(text, "/*+")
+}
+
+// lexScript 将SQL文本拆分为词法单元，PostgreSQL 的 $$ 引用作为一个字符串
+func lexScript(text string) []sqllex.Token {
+	var tokens []sqllex.Token
+	offset := 0
+	for {
+		rest := sqllex.Lex(text[offset:])
+		quoted := false
+		for _, t := range rest {
+			t.Pos += offset
+			tag := ""
+			if strings.HasPrefix(t.Text, "$") {
+				tag = dollarTag.FindString(text[t.Pos:])
+			}
+			if tag == "" {
+				tokens = append(tokens, t)
+				continue
+			}
+			end := len(text)
+			j := strings.Index(text[t.Pos+len(tag):], tag)
+			if j >= 0 {
+				end = t.Pos + len(tag) + j + len(tag)
+			}
+			tokens = append(tokens, sqllex.Token{Kind: sqllex.String, Text: text[t.Pos:end], Pos: t.Pos, Unterminated: j < 0})
+			offset, quoted = end, true
+			break
+		}
+		if !quoted {
+			return tokens
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+// AnnotateSQLError 数据库错误带有出错位置时，在错误信息后附加出错的语句行并用^标出位置
+func AnnotateSQLError(sql string, err error) error {
+	pos, ok := db.ErrorPosition(err, sql)
+	if !ok {
+		return err
+	}
+	return fmt.Errorf("%w\n%s", err, errorMarker(sql, pos))
+}
+
+// errorMarker 返回出错位置所在的行及其下方的^标记
+func errorMarker(sql string, pos int) string {
+	pos = min(max(pos, 0), len(sql))
+	lineStart := strings.LastIndexByte(sql[:pos], '\n') + 1
+	lineEnd := len(sql)
+	if i := strings.IndexByte(sql[pos:], '\n'); i >= 0 {
+		lineEnd = pos + i
+	}
+	lineNo := strings.Count(sql[:lineStart], "\n") + 1
+
+	// 制表符按一个空格显示，保证标记位置与显示宽度一致
+	line := strings.ReplaceAll(strings.TrimRight(sql[lineStart:lineEnd], "\r"), "\t", " ")
+	prefix := fmt.Sprintf("LINE %d: ", lineNo)
+	before := strings.ReplaceAll(sql[lineStart:pos], "\t", " ")
+	indent := runewidth.StringWidth(prefix) + runewidth.StringWidth(before)
+	return prefix + line + "\n" + strings.Repeat(" ", indent) + "^"
+}
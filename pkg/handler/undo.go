@@ -0,0 +1,436 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/undo"
+)
+
+// undoMaxRows 单条语句最多保存的行数，影响更多行时不记录
+const undoMaxRows = 10000
+
+// undoJournal 执行 UPDATE、DELETE 前按主键保存将被修改的行，用于 undo 命令
+var undoJournal bool
+
+// undoUsage undo 命令的用法
+const undoUsage = "用法: undo [N] | undo list | undo apply [N] | undo clear"
+
+// errUndoTooLarge 将被修改的行超过 undoMaxRows，无法保存到撤销日志
+var errUndoTooLarge = fmt.Errorf("影响超过 %d 行", undoMaxRows)
+
+// errWriteCancelled 无法保存执行前的数据时用户取消了执行，语句没有执行
+var errWriteCancelled = errors.New("已取消执行")
+
+// undoPlan 开启 undo 设置时保存 UPDATE、DELETE 语句将修改的行的方式
+type undoPlan struct {
+	impact *WriteImpact
+	keys   []string
+	// sql 未绑定变量的语句，保存到撤销日志
+	sql string
+	// query 查询并锁定将被修改的行的语句，已绑定变量
+	query string
+	args  []interface{}
+}
+
+// planUndo 开启 undo 设置时检查 UPDATE、DELETE 语句能否撤销，返回执行时使用的计划。
+// 无法撤销时询问是否仍然执行，proceed 为 false 表示取消执行。sql 为未绑定变量的语句
+func planUndo(conn db.Connection, sql string, vars map[string]string) (plan *undoPlan, proceed bool) {
+	if !undoJournal {
+		return nil, true
+	}
+	impact, ok := AnalyzeWrite(sql)
+	if !ok || (impact.Keyword != "UPDATE" && impact.Keyword != "DELETE") {
+		return nil, true
+	}
+	plan, err := newUndoPlan(conn, impact, sql, vars)
+	if err != nil {
+		answer := readInput(fmt.Sprintf("无法保存执行前的数据: %v，仍然执行? (y/n): ", err))
+		return nil, strings.HasPrefix(strings.ToLower(answer), "y")
+	}
+	return plan, true
+}
+
+// newUndoPlan 检查语句能否按主键撤销并生成查询将被修改的行的语句
+func newUndoPlan(conn db.Connection, impact *WriteImpact, sql string, vars map[string]string) (*undoPlan, error) {
+	if impact.From == "" || impact.Table == "" {
+		return nil, errors.New("多表更新和删除不支持撤销")
+	}
+	if impact.Limited {
+		return nil, errors.New("语句带有 ORDER BY、LIMIT 或 TOP 等子句，无法确定将修改哪些行")
+	}
+	if _, ok := conn.(db.TxBeginner); !ok {
+		return nil, errors.New("当前连接不支持事务")
+	}
+	keys, identity, err := primaryKeys(conn, impact.Table)
+	if err != nil {
+		return nil, err
+	}
+	config := db.GetCurrentConfig()
+	if impact.Keyword == "DELETE" && len(identity) > 0 && !identityInsertAllowed(config.Type) {
+		return nil, fmt.Errorf("表 %s 有自增列 %s，恢复删除的行时不能插入自增列的值", impact.Table, strings.Join(identity, ", "))
+	}
+	for _, column := range impact.SetColumns {
+		for _, key := range keys {
+			if strings.EqualFold(strings.Trim(column, "`\"[]"), key) {
+				return nil, fmt.Errorf("语句修改了主键列 %s，无法按主键恢复", key)
+			}
+		}
+	}
+
+	query, args := BindVariables("SELECT * FROM "+impact.From+lockClause(config.Type), config.Type, vars)
+	return &undoPlan{impact: impact, keys: keys, sql: sql, query: query, args: args}, nil
+}
+
+// lockClause 返回查询将被修改的行时锁定这些行的子句，SQL Server 和其他数据库只依靠事务
+func lockClause(dbType string) string {
+	switch dbType {
+	case "mysql", "postgresql", "oracle", "dameng":
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// executeWithUndo 在一个事务中先查询将被修改的行再执行语句，返回影响的行数和要保存的撤销记录
+func executeWithUndo(conn db.Connection, plan *undoPlan, sql string, args []interface{}) (int64, *undo.Entry, error) {
+	ctx := context.Background()
+	tx, err := conn.(db.TxBeginner).BeginTx(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	entry, err := plan.beforeImage(ctx, tx)
+	if err != nil {
+		return 0, nil, err
+	}
+	res, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return affected, entry, nil
+}
+
+// beforeImage 在事务中按语句的 WHERE 条件查询将被修改的行
+func (p *undoPlan) beforeImage(ctx context.Context, tx *sql.Tx) (*undo.Entry, error) {
+	it, err := db.QueryInTx(ctx, tx, p.query, p.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	config := db.GetCurrentConfig()
+	entry := &undo.Entry{
+		Time:       time.Now(),
+		Profile:    settingsProfile,
+		Type:       config.Type,
+		Host:       config.Host,
+		Port:       config.Port,
+		DbName:     config.DbName,
+		Statement:  p.sql,
+		Keyword:    p.impact.Keyword,
+		Table:      p.impact.Table,
+		Columns:    it.Columns(),
+		SetColumns: p.impact.SetColumns,
+	}
+	for _, key := range p.keys {
+		for _, col := range entry.Columns {
+			if strings.EqualFold(col, key) {
+				entry.Keys = append(entry.Keys, col)
+			}
+		}
+	}
+	if len(entry.Keys) == 0 {
+		return nil, fmt.Errorf("表 %s 没有主键", p.impact.Table)
+	}
+	for {
+		row, ok, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if len(entry.Rows) == undoMaxRows {
+			return nil, errUndoTooLarge
+		}
+		values := make([]undo.Value, len(entry.Columns))
+		for i, col := range entry.Columns {
+			values[i] = undo.Value{V: row[col]}
+		}
+		entry.Rows = append(entry.Rows, values)
+	}
+	return entry, nil
+}
+
+// executeWrite 执行修改数据的语句。有撤销计划时在同一事务中先保存将被修改的行，执行成功后写入撤销日志；
+// 影响的行过多无法保存时询问是否仍然执行
+func executeWrite(conn db.Connection, plan *undoPlan, sql string, args []interface{}) (int64, error) {
+	if plan == nil {
+		return execute(conn, sql, args)
+	}
+	affected, entry, err := executeWithUndo(conn, plan, sql, args)
+	if errors.Is(err, errUndoTooLarge) {
+		answer := readInput(fmt.Sprintf("无法保存执行前的数据: %v，仍然执行? (y/n): ", err))
+		if !strings.HasPrefix(strings.ToLower(answer), "y") {
+			return 0, errWriteCancelled
+		}
+		return execute(conn, sql, args)
+	}
+	if err != nil {
+		return 0, err
+	}
+	saveUndo(entry)
+	return affected, nil
+}
+
+// primaryKeys 根据表结构返回主键列和自增列
+func primaryKeys(conn db.Connection, table string) (keys, identity []string, err error) {
+	columns, err := conn.DescribeTable(strings.Trim(table, "`\"[]"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, col := range columns {
+		name := columnField(col, "COLUMN_NAME")
+		if name == "" {
+			continue
+		}
+		if strings.Contains(strings.ToUpper(columnField(col, "CONSTRAINT_TYPE")), "PRIMARY KEY") {
+			keys = append(keys, name)
+		}
+		if strings.Contains(strings.ToUpper(columnField(col, "IDENTITY_INFO")), "IDENTITY") {
+			identity = append(identity, name)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("表 %s 没有主键", table)
+	}
+	return keys, identity, nil
+}
+
+// identityInsertAllowed 判断数据库是否允许 INSERT 直接指定自增列的值。
+// SQL Server 和达梦需要先执行 SET IDENTITY_INSERT，撤销时不支持
+func identityInsertAllowed(dbType string) bool {
+	return dbType != "mssql" && dbType != "dameng"
+}
+
+// columnAliases SQL Server 表结构中的中文列名
+var columnAliases = map[string]string{"COLUMN_NAME": "字段名", "CONSTRAINT_TYPE": "约束", "IDENTITY_INFO": "自增"}
+
+// columnField 读取表结构字段，兼容大小写不同的列名和 SQL Server 的中文列名
+func columnField(col map[string]interface{}, name string) string {
+	for _, key := range []string{name, strings.ToLower(name), columnAliases[name]} {
+		if val, ok := col[key]; ok && val != nil {
+			return fmt.Sprintf("%v", val)
+		}
+	}
+	return ""
+}
+
+// saveUndo 语句执行成功后保存执行前的数据，没有修改任何行时不保存
+func saveUndo(entry *undo.Entry) {
+	if entry == nil || len(entry.Rows) == 0 {
+		return
+	}
+	if err := undo.Append(entry); err != nil {
+		fmt.Fprintf(Output(), "保存撤销日志失败: %v\n", err)
+	}
+}
+
+// HandleUndo 处理 undo 命令：显示最近 N 条写入语句的补偿语句，list 列出撤销日志，
+// apply 在事务中执行补偿语句并从日志中删除，clear 清除当前数据库的撤销日志
+func HandleUndo(args string) error {
+	config := db.GetCurrentConfig()
+	conn := db.GetCurrentConnection()
+	if config == nil || conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+
+	fields := strings.Fields(args)
+	action := "show"
+	if len(fields) > 0 {
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			action, fields = strings.ToLower(fields[0]), fields[1:]
+		}
+	}
+	count := 1
+	if len(fields) > 1 {
+		return errors.New(undoUsage)
+	}
+	if len(fields) == 1 {
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n <= 0 {
+			return errors.New(undoUsage)
+		}
+		count = n
+	}
+
+	all, err := undo.Load()
+	if err != nil {
+		return err
+	}
+	// 当前数据库的记录在 all 中的下标，最近的在前
+	var indexes []int
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].Matches(config) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	switch action {
+	case "list":
+		if len(indexes) == 0 {
+			fmt.Fprintln(Output(), "当前数据库没有可撤销的语句")
+			return nil
+		}
+		result := &output.Result{Columns: []string{"#", "时间", "行数", "语句"}}
+		for n, i := range indexes {
+			entry := all[i]
+			result.Rows = append(result.Rows, []interface{}{n + 1, entry.Time, len(entry.Rows), entry.Statement})
+		}
+		return output.Render(Output(), output.FormatTable, result, DisplayOptions())
+	case "clear":
+		kept := all[:0]
+		for _, entry := range all {
+			if !entry.Matches(config) {
+				kept = append(kept, entry)
+			}
+		}
+		if err := undo.Save(kept); err != nil {
+			return err
+		}
+		fmt.Fprintf(Output(), "已清除当前数据库的 %d 条撤销记录\n", len(indexes))
+		return nil
+	case "show", "apply":
+	default:
+		return errors.New(undoUsage)
+	}
+
+	if len(indexes) == 0 {
+		return errors.New("当前数据库没有可撤销的语句，可用 set undo on 开启撤销日志")
+	}
+	if count > len(indexes) {
+		return fmt.Errorf("当前数据库只有 %d 条可撤销的语句", len(indexes))
+	}
+	indexes = indexes[:count]
+
+	if action == "show" {
+		for _, i := range indexes {
+			script, err := all[i].Script()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(Output(), "-- 撤销 %s: %s\n%s", output.FormatTime(all[i].Time), all[i].Statement, script)
+		}
+		return nil
+	}
+
+	if err := CheckWritable("UPDATE"); err != nil {
+		return err
+	}
+	answer := readInput(fmt.Sprintf("将在事务中执行补偿语句撤销最近 %d 条语句，确认执行? (y/n): ", count))
+	if !strings.HasPrefix(strings.ToLower(answer), "y") {
+		fmt.Fprintln(Output(), "已取消执行")
+		return nil
+	}
+	applied, err := applyUndo(conn, all, indexes)
+	if err != nil {
+		return err
+	}
+
+	applyIndexes := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		applyIndexes[i] = true
+	}
+	var kept []*undo.Entry
+	for i, entry := range all {
+		if !applyIndexes[i] {
+			kept = append(kept, entry)
+		}
+	}
+	if err := undo.Save(kept); err != nil {
+		return fmt.Errorf("已撤销，但更新撤销日志失败: %v", err)
+	}
+	fmt.Fprintf(Output(), "已撤销 %d 条语句，执行了 %d 条补偿语句\n", count, applied)
+	return nil
+}
+
+// applyUndo 按从近到远的顺序在一个事务中执行补偿语句，任一语句失败时回滚
+func applyUndo(conn db.Connection, all []*undo.Entry, indexes []int) (int, error) {
+	beginner, ok := conn.(db.TxBeginner)
+	if !ok {
+		return 0, errors.New("当前连接不支持事务")
+	}
+	ctx := context.Background()
+	tx, err := beginner.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// 补偿语句提交后才写入审计日志，回滚时记录为失败
+	type executed struct {
+		sql      string
+		start    time.Time
+		affected int64
+	}
+	var done []executed
+	rollback := func(err error) {
+		for _, e := range done {
+			RecordAudit(e.sql, e.start, e.affected, fmt.Errorf("已回滚: %v", err))
+		}
+	}
+
+	for _, i := range indexes {
+		statements, err := all[i].Statements()
+		if err != nil {
+			rollback(err)
+			return 0, err
+		}
+		for _, stmt := range statements {
+			start := time.Now()
+			var affected int64
+			if stmt.Check != nil {
+				var count int64
+				err = tx.QueryRowContext(ctx, stmt.Check.SQL, stmt.Check.Args...).Scan(&count)
+				if err == nil && count == 0 {
+					err = errors.New("要恢复的行已被删除或主键已被修改")
+				}
+			}
+			if err == nil {
+				var res sql.Result
+				if res, err = tx.ExecContext(ctx, stmt.SQL, stmt.Args...); err == nil {
+					affected, _ = res.RowsAffected()
+				}
+			}
+			if err != nil {
+				RecordAudit(stmt.SQL, start, 0, err)
+				rollback(err)
+				return 0, fmt.Errorf("执行补偿语句失败，已回滚: %v\n%s", err, stmt.SQL)
+			}
+			done = append(done, executed{stmt.SQL, start, affected})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		rollback(err)
+		return 0, err
+	}
+	for _, e := range done {
+		RecordAudit(e.sql, e.start, e.affected, nil)
+	}
+	return len(done), nil
+}
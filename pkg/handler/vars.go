@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// variableNamePattern 会话变量名：字母或下划线开头，由字母、数字和下划线组成
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variables 当前会话的变量
+var variables = make(map[string]string)
+
+// Variables 返回当前会话变量的副本
+func Variables() map[string]string {
+	vars := make(map[string]string, len(variables))
+	for name, value := range variables {
+		vars[name] = value
+	}
+	return vars
+}
+
+// SetVariable 设置会话变量
+func SetVariable(name, value string) error {
+	if !variableNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的变量名: %s，变量名只能包含字母、数字和下划线且不能以数字开头", name)
+	}
+	variables[name] = value
+	return nil
+}
+
+// UnsetVariable 删除会话变量
+func UnsetVariable(name string) {
+	delete(variables, name)
+}
+
+// HandleSetVariable 处理 \set：value 为单引号字符串时取其内容，为查询语句时取查询结果的唯一值，
+// 否则按原样保存
+func HandleSetVariable(name, value string) error {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+		value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	case strings.ContainsAny(value, " \t\n") && IsQueryStatement(value):
+		v, err := queryValue(value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	return SetVariable(name, value)
+}
+
+// HandleShowVariables 按名称顺序列出会话变量
+func HandleShowVariables() {
+	if len(variables) == 0 {
+		fmt.Fprintln(Output(), "当前没有设置任何变量")
+		return
+	}
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(Output(), "%s = '%s'\n", name, variables[name])
+	}
+}
+
+// queryValue 执行查询并返回唯一的结果值，结果不是一行一列时返回错误
+func queryValue(sql string) (string, error) {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return "", errors.New("当前未连接到任何数据库")
+	}
+	if err := CheckWritable(sql); err != nil {
+		return "", err
+	}
+	sql, args := BindVariables(sql, db.GetCurrentConfig().Type, variables)
+	result, err := QueryResultWithParams(conn, sql, args...)
+	if err != nil {
+		return "", AnnotateSQLError(sql, err)
+	}
+	if len(result.Rows) != 1 || len(result.Columns) != 1 {
+		return "", fmt.Errorf("查询应返回一行一列，实际返回 %d 行 %d 列", len(result.Rows), len(result.Columns))
+	}
+	if result.Rows[0][0] == nil {
+		return "", errors.New("查询结果为 NULL，无法设置变量")
+	}
+	// 时间等类型按显示设置转换为文本
+	text, _ := output.FormatValue(result.Rows[0][0])
+	return text, nil
+}
+
+// BindVariables 将语句中的 :name 和 :'name' 替换为对应数据库的参数占位符，
+// 返回替换后的语句和按顺序排列的参数值。字符串、注释、:: 类型转换以及未定义的变量保持不变
+func BindVariables(sql, dbType string, vars map[string]string) (string, []interface{}) {
+	if len(vars) == 0 {
+		return sql, nil
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	last := 0
+	for _, v := range sqllex.Variables(sql) {
+		value, ok := vars[v.Name]
+		if !ok {
+			continue
+		}
+		args = append(args, value)
+		sb.WriteString(sql[last:v.Start])
+		sb.WriteString(db.Placeholder(dbType, len(args)))
+		last = v.End
+	}
+	sb.WriteString(sql[last:])
+	return sb.String(), args
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+const (
+	// DefaultWatchInterval watch 默认的执行间隔
+	DefaultWatchInterval = 2 * time.Second
+	// minWatchInterval watch 允许的最小执行间隔
+	minWatchInterval = 100 * time.Millisecond
+
+	untilFlag = "--until"
+)
+
+// conditionPattern --until 条件：[列名] 运算符 值
+var conditionPattern = regexp.MustCompile(`^\s*(.*?)\s*(==|!=|<>|<=|>=|=|<|>)\s*(.*?)\s*$`)
+
+// WatchCondition watch 的停止条件，按结果第一行的某一列与值比较
+type WatchCondition struct {
+	Column string // 为空时结果必须只有一列
+	Op     string
+	Value  string
+	Empty  bool // 结果没有行时满足
+}
+
+// ParseWatchCondition 解析停止条件，如 "remaining = 0"、"= 0" 或 "empty"
+func ParseWatchCondition(s string) (*WatchCondition, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "empty") {
+		return &WatchCondition{Empty: true}, nil
+	}
+	m := conditionPattern.FindStringSubmatch(s)
+	if m == nil || m[3] == "" {
+		return nil, fmt.Errorf("无效的条件: %s，格式为 [列名] 运算符 值，运算符支持 = != <> < <= > >=，或 empty", s)
+	}
+	op := m[2]
+	if op == "==" {
+		op = "="
+	}
+	if op == "<>" {
+		op = "!="
+	}
+	return &WatchCondition{Column: m[1], Op: op, Value: trimQuotes(m[3])}, nil
+}
+
+// Satisfied 判断查询结果是否满足条件，数值按数字比较，其他按文本比较
+func (c *WatchCondition) Satisfied(result *output.Result) (bool, error) {
+	if c.Empty {
+		return len(result.Rows) == 0, nil
+	}
+	if len(result.Rows) == 0 {
+		return false, nil
+	}
+
+	col := -1
+	if c.Column == "" {
+		if len(result.Columns) != 1 {
+			return false, errors.New("结果有多列，条件中需指定列名")
+		}
+		col = 0
+	}
+	for i, name := range result.Columns {
+		if c.Column != "" && strings.EqualFold(name, c.Column) {
+			col = i
+			break
+		}
+	}
+	if col < 0 {
+		return false, fmt.Errorf("结果中没有列 %s", c.Column)
+	}
+
+	text, ok := output.FormatValue(result.Rows[0][col])
+	if !ok {
+		return strings.EqualFold(c.Value, "null") && c.Op == "=", nil
+	}
+
+	var cmp int
+	left, errLeft := strconv.ParseFloat(text, 64)
+	right, errRight := strconv.ParseFloat(c.Value, 64)
+	if errLeft == nil && errRight == nil {
+		switch {
+		case left < right:
+			cmp = -1
+		case left > right:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(text, c.Value)
+	}
+
+	switch c.Op {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// String 返回条件的文本形式
+func (c *WatchCondition) String() string {
+	if c.Empty {
+		return "empty"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", c.Column, c.Op, c.Value))
+}
+
+// ParseWatchArgs 解析 watch [间隔] <查询> [--until <条件>]，间隔为秒数或带单位的时长
+func ParseWatchArgs(args string) (time.Duration, string, *WatchCondition, error) {
+	args = strings.TrimSpace(args)
+	var until *WatchCondition
+	if i := strings.LastIndex(args, untilFlag); i >= 0 {
+		cond, err := ParseWatchCondition(trimQuotes(strings.TrimSpace(args[i+len(untilFlag):])))
+		if err != nil {
+			return 0, "", nil, err
+		}
+		until = cond
+		args = strings.TrimSpace(args[:i])
+	}
+
+	interval := DefaultWatchInterval
+	if first, rest, ok := strings.Cut(args, " "); ok {
+		if d, ok := parseInterval(first); ok {
+			interval = d
+			args = strings.TrimSpace(rest)
+		}
+	}
+	if interval < minWatchInterval {
+		return 0, "", nil, fmt.Errorf("执行间隔不能小于 %s", minWatchInterval)
+	}
+	if args == "" {
+		return 0, "", nil, errors.New("用法: watch [间隔秒数] <查询语句> [--until <条件>]")
+	}
+	if !IsQueryStatement(args) {
+		return 0, "", nil, errors.New("watch 只能执行查询语句")
+	}
+	return interval, args, until, nil
+}
+
+// parseInterval 解析秒数或 time.ParseDuration 支持的时长
+func parseInterval(s string) (time.Duration, bool) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	return 0, false
+}
+
+// trimQuotes 去除两端成对的引号
+func trimQuotes(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// HandleWatch 按间隔重复执行查询并在原位置刷新结果，突出显示与上次不同的单元格，
+// 满足 --until 条件或按 Ctrl+C 时停止
+func HandleWatch(args string) error {
+	interval, sql, until, err := ParseWatchArgs(args)
+	if err != nil {
+		return err
+	}
+	if err := CheckWritable(sql); err != nil {
+		return err
+	}
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return errors.New("当前未连接到任何数据库")
+	}
+	bound, params := BindVariables(sql, db.GetCurrentConfig().Type, variables)
+
+	format := output.FormatTable
+	if outputFormat == output.FormatVertical {
+		format = output.FormatVertical
+	}
+
+	// 监视期间 Ctrl+C 停止监视而不是退出程序
+	ctx, done := beginCancelable()
+	defer done()
+
+	var prev *output.Result
+	for run := 1; ; run++ {
+		result, truncated, err := FetchResult(ctx, conn, bound, params, maxRows, nil)
+		if ctx.Err() != nil {
+			fmt.Fprintln(Output(), "已停止监视")
+			return nil
+		}
+		if err != nil {
+			return AnnotateSQLError(bound, err)
+		}
+
+		opts := DisplayOptions()
+		opts.Highlight = changedCells(prev, result)
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "每 %s 执行: %s\n%s  第 %d 次  按 Ctrl+C 停止\n\n",
+			interval, sql, time.Now().Format("2006-01-02 15:04:05"), run)
+		if err := output.Render(&buf, format, result, opts); err != nil {
+			return err
+		}
+		writeSummary(&buf, result, truncated)
+		clearScreen()
+		Output().Write(buf.Bytes())
+
+		if until != nil {
+			satisfied, err := until.Satisfied(result)
+			if err != nil {
+				return err
+			}
+			if satisfied {
+				fmt.Fprintf(Output(), "条件 %s 已满足，停止监视\n", until)
+				return nil
+			}
+		}
+
+		prev = result
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(Output(), "已停止监视")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// changedCells 返回判断单元格与上次结果是否不同的函数，新增的行全部视为变化
+func changedCells(prev, cur *output.Result) func(row, col int) bool {
+	if prev == nil {
+		return nil
+	}
+	return func(row, col int) bool {
+		if row >= len(prev.Rows) || col >= len(prev.Columns) || prev.Columns[col] != cur.Columns[col] {
+			return true
+		}
+		before, beforeOK := output.FormatValue(prev.Rows[row][col])
+		after, afterOK := output.FormatValue(cur.Rows[row][col])
+		return before != after || beforeOK != afterOK
+	}
+}
+
+// clearScreen 输出到终端时清屏并将光标移到左上角
+func clearScreen() {
+	if console != os.Stdout {
+		return
+	}
+	if _, _, ok := utils.TerminalSize(); ok {
+		fmt.Fprint(console, "\x1b[H\x1b[2J")
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+var (
+	// console 终端输出，测试时可替换
+	console io.Writer = os.Stdout
+	// outputFile 查询结果的输出文件，为nil时输出到终端
+	outputFile *os.File
+	// teeFile 记录会话内容的日志文件，为nil时不记录
+	teeFile *os.File
+	// ansiPattern 终端颜色等控制序列，写入日志时去除
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+)
+
+// teeWriter 同时写入终端和会话日志
+type teeWriter struct{}
+
+// Write 写入终端，开启会话日志时去除控制序列后写入日志
+func (teeWriter) Write(p []byte) (int, error) {
+	n, err := console.Write(p)
+	logWrite(p)
+	return n, err
+}
+
+// Output 返回处理函数的输出，开启 tee 时内容同时写入会话日志
+func Output() io.Writer {
+	return teeWriter{}
+}
+
+// SetConsole 设置终端输出，w为nil时恢复为标准输出
+func SetConsole(w io.Writer) {
+	if w == nil {
+		w = os.Stdout
+	}
+	console = w
+}
+
+// logWrite 将内容写入会话日志
+func logWrite(p []byte) {
+	if teeFile != nil {
+		teeFile.Write(ansiPattern.ReplaceAll(p, nil))
+	}
+}
+
+// SetOutputFile 将查询结果输出到文件，path为空时恢复输出到终端
+func SetOutputFile(path string) error {
+	if outputFile != nil {
+		outputFile.Close()
+		outputFile = nil
+	}
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("无法打开输出文件: %v", err)
+	}
+	outputFile = file
+	return nil
+}
+
+// OutputFileName 返回当前的输出文件，输出到终端时为空
+func OutputFileName() string {
+	if outputFile == nil {
+		return ""
+	}
+	return outputFile.Name()
+}
+
+// StartTee 开始将会话内容追加到日志文件，包括执行的命令、输出和耗时
+func StartTee(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("无法打开日志文件: %v", err)
+	}
+	StopTee()
+	teeFile = file
+	fmt.Fprintf(teeFile, "-- 会话日志开始于 %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// StopTee 停止记录会话日志
+func StopTee() {
+	if teeFile == nil {
+		return
+	}
+	fmt.Fprintf(teeFile, "-- 会话日志结束于 %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	teeFile.Close()
+	teeFile = nil
+}
+
+// TeeFileName 返回当前的会话日志文件，未记录时为空
+func TeeFileName() string {
+	if teeFile == nil {
+		return ""
+	}
+	return teeFile.Name()
+}
+
+// LogCommand 将执行的命令写入会话日志，不输出到终端
+func LogCommand(prompt, cmd string) {
+	if teeFile != nil {
+		fmt.Fprintf(teeFile, "[%s] %s%s\n", time.Now().Format("2006-01-02 15:04:05"), prompt, cmd)
+	}
+}
+
+// printTiming 开启计时时输出从start开始的耗时，记录会话日志时总是写入日志
+func printTiming(start time.Time) {
+	line := fmt.Sprintf("耗时: %.3f ms\n", float64(time.Since(start).Microseconds())/1000)
+	if timing {
+		fmt.Fprint(Output(), line)
+	} else {
+		logWrite([]byte(line))
+	}
+}
@@ -0,0 +1,274 @@
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+const (
+	// DefaultMaxEntries 每个连接配置最多保留的历史条数
+	DefaultMaxEntries = 1000
+	// MaxEntryLength 单条历史的最大字节数，超过的命令不记录
+	MaxEntryLength = 64 * 1024
+
+	historyDirName = "history"
+	historyFileExt = ".history"
+	maskedPassword = "********"
+)
+
+// Entry 一条带编号的历史命令，编号从1开始
+type Entry struct {
+	Index int
+	Text  string
+}
+
+// History 某个连接配置的命令历史
+type History struct {
+	path       string
+	maxEntries int
+	entries    []string
+}
+
+var (
+	// unsafeChars 文件名中不允许出现的字符
+	unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._@-]+`)
+	// connectPassword 匹配 connect 命令中的密码参数
+	connectPassword = regexp.MustCompile(`(\s(?:-p|--password)(?:\s+|=))(\S+)`)
+	// configPassword 匹配 config set password 命令中的密码
+	configPassword = regexp.MustCompile(`(?i)^(\s*config\s+set\s+password\s+)(.+)$`)
+)
+
+// Open 打开指定连接配置的历史文件，文件不存在时返回空历史
+func Open(profile string) (*History, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(configDir, historyDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	h := &History{
+		path:       filepath.Join(dir, fileName(profile)),
+		maxEntries: DefaultMaxEntries,
+	}
+	entries, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	h.entries = entries
+	return h, nil
+}
+
+// fileName 根据连接配置名生成历史文件名
+func fileName(profile string) string {
+	name := unsafeChars.ReplaceAllString(profile, "_")
+	if name == "" {
+		name = "default"
+	}
+	return name + historyFileExt
+}
+
+// Path 返回历史文件路径
+func (h *History) Path() string {
+	return h.path
+}
+
+// Entries 返回全部历史，按时间从旧到新排列
+func (h *History) Entries() []string {
+	return append([]string(nil), h.entries...)
+}
+
+// Add 记录一条命令，密码会被屏蔽，与已有记录重复时只保留最新的一条
+func (h *History) Add(line string) error {
+	line = MaskPasswords(strings.TrimSpace(line))
+	if line == "" || len(line) > MaxEntryLength {
+		return nil
+	}
+
+	// 重新读取文件，合并其他会话写入的记录
+	entries, err := h.read()
+	if err != nil {
+		return err
+	}
+	h.entries = appendUnique(entries, line, h.maxEntries)
+	return h.write()
+}
+
+// Find 返回包含pattern的历史(不区分大小写)，pattern为空时返回全部
+func (h *History) Find(pattern string) []Entry {
+	pattern = strings.ToLower(pattern)
+	var result []Entry
+	for i, text := range h.entries {
+		if pattern == "" || strings.Contains(strings.ToLower(text), pattern) {
+			result = append(result, Entry{Index: i + 1, Text: text})
+		}
+	}
+	return result
+}
+
+// SearchBackward 从下标before之前向旧记录查找包含query的历史，返回下标，未找到返回-1
+func (h *History) SearchBackward(query string, before int) int {
+	before = min(before, len(h.entries))
+	query = strings.ToLower(query)
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(h.entries[i]), query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len 返回历史条数
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// At 返回下标i的历史
+func (h *History) At(i int) string {
+	return h.entries[i]
+}
+
+// passwordPattern 返回匹配命令中密码参数的正则，命令不含密码参数时返回nil
+func passwordPattern(line string) *regexp.Regexp {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	// connect 命令和 \c、\connect 元命令的参数相同
+	if strings.EqualFold(fields[0], "connect") || fields[0] == `\c` || fields[0] == `\connect` {
+		return connectPassword
+	}
+	if configPassword.MatchString(line) {
+		return configPassword
+	}
+	return nil
+}
+
+// MaskPasswords 屏蔽命令中的明文密码
+func MaskPasswords(line string) string {
+	pattern := passwordPattern(line)
+	if pattern == nil {
+		return line
+	}
+	return pattern.ReplaceAllString(line, "${1}"+maskedPassword)
+}
+
+// HasMaskedPassword 判断命令中的密码是否为屏蔽后的占位符，用于避免从历史中执行此类命令
+func HasMaskedPassword(line string) bool {
+	pattern := passwordPattern(line)
+	if pattern == nil {
+		return false
+	}
+	for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+		if strings.TrimSpace(match[2]) == maskedPassword {
+			return true
+		}
+	}
+	return false
+}
+
+// appendUnique 去掉与line相同的旧记录后追加，并只保留最新的limit条
+func appendUnique(entries []string, line string, limit int) []string {
+	result := entries[:0]
+	for _, entry := range entries {
+		if entry != line {
+			result = append(result, entry)
+		}
+	}
+	result = append(result, line)
+	if len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+// read 读取历史文件
+func (h *History) read() ([]string, error) {
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 2*MaxEntryLength+1)
+	for scanner.Scan() {
+		if line := unescape(scanner.Text()); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	}
+	return dedupe(entries, h.maxEntries), nil
+}
+
+// dedupe 去除重复记录(保留最新的一条)，并只保留最新的limit条
+func dedupe(entries []string, limit int) []string {
+	seen := make(map[string]bool, len(entries))
+	var reversed []string
+	for i := len(entries) - 1; i >= 0 && len(reversed) < limit; i-- {
+		if !seen[entries[i]] {
+			seen[entries[i]] = true
+			reversed = append(reversed, entries[i])
+		}
+	}
+	result := make([]string, len(reversed))
+	for i, entry := range reversed {
+		result[len(reversed)-1-i] = entry
+	}
+	return result
+}
+
+// write 将历史写入临时文件后替换，避免写入中断导致文件损坏
+func (h *History) write() error {
+	var sb strings.Builder
+	for _, entry := range h.entries {
+		sb.WriteString(escape(entry))
+		sb.WriteByte('\n')
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// escape 将多行命令转换为单行保存
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// unescape 还原escape转换的命令
+func unescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// jsonValue 将单元格的值转换为可JSON编码的值，数字和布尔值保持原类型。
+// JSON 不能表示 NaN 和无穷大，输出为字符串 "NaN"、"Infinity" 和 "-Infinity"
+func jsonValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case float32:
+		return jsonFloat(float64(v), v)
+	case float64:
+		return jsonFloat(v, v)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return v
+	default:
+		text, _ := FormatValue(v)
+		return text
+	}
+}
+
+// jsonFloat f 为 NaN 或无穷大时返回对应的字符串，否则返回原值 val
+func jsonFloat(f float64, val interface{}) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return val
+}
+
+// encodeRecord 按列顺序将一行编码为JSON对象
+func encodeRecord(buf *bytes.Buffer, columns []string, row []interface{}) error {
+	buf.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		val, err := json.Marshal(jsonValue(row[i]))
+		if err != nil {
+			return err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// renderJSON 以JSON对象数组输出，保持列顺序
+func renderJSON(w io.Writer, result *Result, opts Options) error {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range result.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n  ")
+		if err := encodeRecord(&buf, result.Columns, row); err != nil {
+			return err
+		}
+	}
+	if len(result.Rows) > 0 {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("]\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// renderNDJSON 每行输出一个JSON对象，便于流式处理
+func renderNDJSON(w io.Writer, result *Result, opts Options) error {
+	var buf bytes.Buffer
+	for _, row := range result.Rows {
+		if err := encodeRecord(&buf, result.Columns, row); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// markdownReplacer 转义Markdown表格中的竖线和换行
+var markdownReplacer = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "")
+
+// renderMarkdown 以Markdown表格输出
+func renderMarkdown(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for _, cell := range cells {
+			sb.WriteString(" ")
+			sb.WriteString(markdownReplacer.Replace(cell))
+			sb.WriteString(" |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(result.Columns)
+	sb.WriteString("|")
+	for range result.Columns {
+		sb.WriteString(" --- |")
+	}
+	sb.WriteString("\n")
+
+	for _, row := range result.Rows {
+		cells := make([]string, len(row))
+		for i, val := range row {
+			cells[i] = cellText(val, opts)
+		}
+		writeRow(cells)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// htmlCell 转义HTML单元格内容，换行转换为<br>
+func htmlCell(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+// renderHTML 以HTML表格输出
+func renderHTML(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("<table>\n  <thead>\n    <tr>")
+	for _, col := range result.Columns {
+		sb.WriteString("<th>" + htmlCell(col) + "</th>")
+	}
+	sb.WriteString("</tr>\n  </thead>\n  <tbody>\n")
+	for _, row := range result.Rows {
+		sb.WriteString("    <tr>")
+		for _, val := range row {
+			text, ok := FormatValue(val)
+			if !ok {
+				sb.WriteString(`<td class="null">` + htmlCell(opts.NullString) + "</td>")
+				continue
+			}
+			sb.WriteString("<td>" + htmlCell(text) + "</td>")
+		}
+		sb.WriteString("</tr>\n")
+	}
+	sb.WriteString("  </tbody>\n</table>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
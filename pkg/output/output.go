@@ -0,0 +1,139 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 输出格式
+const (
+	FormatTable    = "table"
+	FormatVertical = "vertical"
+	FormatCSV      = "csv"
+	FormatTSV      = "tsv"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Result 查询结果，列顺序固定
+type Result struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// NewResult 根据查询返回的行和列顺序构建结果
+func NewResult(columns []string, rows []map[string]interface{}) *Result {
+	result := &Result{Columns: columns}
+	for _, row := range rows {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result
+}
+
+// 超宽内容的处理方式
+const (
+	OverflowTruncate = "truncate" // 截断并以省略号结尾
+	OverflowWrap     = "wrap"     // 折行显示
+)
+
+// Options 渲染选项
+type Options struct {
+	NullString string // 表格类格式中NULL的显示文本
+	MaxWidth   int    // 表格最大显示宽度，0表示不限制
+	Overflow   string // 超出宽度时的处理方式
+	Color      bool   // 是否以颜色区分NULL
+	// Highlight 判断第row行第col列的单元格是否突出显示，仅在Color为true时对表格和纵向格式生效
+	Highlight func(row, col int) bool
+}
+
+// DefaultOptions 返回默认渲染选项
+func DefaultOptions() Options {
+	return Options{
+		NullString: "NULL",
+		Overflow:   OverflowTruncate,
+	}
+}
+
+// Renderer 结果渲染器
+type Renderer interface {
+	Render(w io.Writer, result *Result, opts Options) error
+}
+
+// RendererFunc 函数形式的渲染器
+type RendererFunc func(w io.Writer, result *Result, opts Options) error
+
+// Render 实现Renderer接口
+func (f RendererFunc) Render(w io.Writer, result *Result, opts Options) error {
+	return f(w, result, opts)
+}
+
+var (
+	renderers = make(map[string]Renderer)
+	formats   []string
+	aliases   = map[string]string{
+		"md":       FormatMarkdown,
+		"jsonl":    FormatNDJSON,
+		"expanded": FormatVertical,
+		"excel":    FormatXLSX,
+	}
+)
+
+// Register 注册输出格式，同名格式会被覆盖
+func Register(name string, renderer Renderer) {
+	name = strings.ToLower(name)
+	if _, exists := renderers[name]; !exists {
+		formats = append(formats, name)
+	}
+	renderers[name] = renderer
+}
+
+// Formats 返回支持的输出格式
+func Formats() []string {
+	return append([]string(nil), formats...)
+}
+
+// Normalize 规范化格式名称，支持别名
+func Normalize(format string) string {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if alias, ok := aliases[format]; ok {
+		return alias
+	}
+	return format
+}
+
+// IsValidFormat 判断输出格式是否受支持
+func IsValidFormat(format string) bool {
+	_, ok := renderers[Normalize(format)]
+	return ok
+}
+
+// Render 按指定格式输出结果
+func Render(w io.Writer, format string, result *Result, opts Options) error {
+	if format == "" {
+		format = FormatTable
+	}
+	renderer, ok := renderers[Normalize(format)]
+	if !ok {
+		return fmt.Errorf("不支持的输出格式: %s，支持的格式为: %s", format, strings.Join(Formats(), ", "))
+	}
+	return renderer.Render(w, result, opts)
+}
+
+func init() {
+	Register(FormatTable, RendererFunc(renderTable))
+	Register(FormatVertical, RendererFunc(renderVertical))
+	Register(FormatCSV, RendererFunc(renderCSV))
+	Register(FormatTSV, RendererFunc(renderTSV))
+	Register(FormatJSON, RendererFunc(renderJSON))
+	Register(FormatNDJSON, RendererFunc(renderNDJSON))
+	Register(FormatMarkdown, RendererFunc(renderMarkdown))
+	Register(FormatHTML, RendererFunc(renderHTML))
+	Register(FormatXLSX, RendererFunc(renderXLSX))
+}
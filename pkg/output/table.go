@@ -0,0 +1,212 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// minColumnWidth 压缩列宽时每列保留的最小显示宽度
+const minColumnWidth = 6
+
+// highlightColor 突出显示单元格使用的颜色
+var highlightColor = color.New(color.FgBlack, color.BgYellow)
+
+// tableCell 表格单元格
+type tableCell struct {
+	text      string
+	null      bool
+	highlight bool
+}
+
+// renderTable 按内容计算列宽输出表格，超出最大宽度时截断或折行
+func renderTable(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+	wrap := opts.Overflow == OverflowWrap
+
+	// 计算每列的内容宽度，并判断是否为数字列
+	widths := make([]int, len(result.Columns))
+	numeric := make([]bool, len(result.Columns))
+	hasValue := make([]bool, len(result.Columns))
+	for i, col := range result.Columns {
+		widths[i] = displayWidth(col)
+		numeric[i] = true
+	}
+
+	cells := make([][]tableCell, len(result.Rows))
+	for r, row := range result.Rows {
+		cells[r] = make([]tableCell, len(row))
+		for i, val := range row {
+			text, ok := FormatValue(val)
+			if !ok {
+				cells[r][i] = tableCell{text: opts.NullString, null: true}
+			} else {
+				if !wrap {
+					text = escapeControl(text)
+				}
+				cells[r][i] = tableCell{text: text}
+				hasValue[i] = true
+				numeric[i] = numeric[i] && isNumericValue(val)
+			}
+			cells[r][i].highlight = opts.Highlight != nil && opts.Highlight(r, i)
+			for _, line := range strings.Split(cells[r][i].text, "\n") {
+				widths[i] = max(widths[i], displayWidth(line))
+			}
+		}
+	}
+
+	widths = fitWidths(widths, opts.MaxWidth)
+
+	var sb strings.Builder
+	header := make([]tableCell, len(result.Columns))
+	for i, col := range result.Columns {
+		header[i] = tableCell{text: col}
+	}
+	writeTableRow(&sb, header, widths, nil, wrap, opts)
+
+	for i, width := range widths {
+		if i > 0 {
+			sb.WriteString("+")
+		}
+		sb.WriteString(strings.Repeat("-", width+2))
+	}
+	sb.WriteString("\n")
+
+	rightAlign := make([]bool, len(numeric))
+	for i := range numeric {
+		rightAlign[i] = numeric[i] && hasValue[i]
+	}
+	for _, row := range cells {
+		writeTableRow(&sb, row, widths, rightAlign, wrap, opts)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// fitWidths 在总宽度超出限制时，依次收窄最宽的列
+func fitWidths(widths []int, maxWidth int) []int {
+	if maxWidth <= 0 {
+		return widths
+	}
+	total := func() int {
+		sum := 3*len(widths) - 1
+		for _, width := range widths {
+			sum += width
+		}
+		return sum
+	}
+
+	for total() > maxWidth {
+		widest := -1
+		for i, width := range widths {
+			if width > minColumnWidth && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			// 所有列都已压缩到最小宽度
+			break
+		}
+		widths[widest]--
+	}
+	return widths
+}
+
+// writeTableRow 输出一行，折行模式下单元格可能占用多个物理行
+func writeTableRow(sb *strings.Builder, row []tableCell, widths []int, rightAlign []bool, wrap bool, opts Options) {
+	lines := make([][]string, len(row))
+	height := 1
+	for i, cell := range row {
+		if wrap {
+			lines[i] = wrapWidth(cell.text, widths[i])
+		} else {
+			lines[i] = []string{truncateWidth(cell.text, widths[i])}
+		}
+		height = max(height, len(lines[i]))
+	}
+
+	for l := 0; l < height; l++ {
+		var line strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString("|")
+			}
+			text := ""
+			if l < len(lines[i]) {
+				text = lines[i][l]
+			}
+			if rightAlign != nil && rightAlign[i] {
+				text = padLeft(text, widths[i])
+			} else {
+				text = padRight(text, widths[i])
+			}
+			switch {
+			case cell.highlight && opts.Color:
+				text = highlightColor.Sprint(text)
+			case cell.null && opts.Color:
+				text = color.New(color.Faint).Sprint(text)
+			}
+			line.WriteString(" " + text + " ")
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+	}
+}
+
+// renderVertical 以纵向记录形式输出，每列一行，类似psql的 \x 模式
+func renderVertical(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+
+	nameWidth := 0
+	for _, col := range result.Columns {
+		nameWidth = max(nameWidth, displayWidth(col))
+	}
+
+	valueWidth := 0
+	if opts.MaxWidth > 0 {
+		valueWidth = max(opts.MaxWidth-nameWidth-3, minColumnWidth)
+	}
+
+	for i, row := range result.Rows {
+		header := fmt.Sprintf("-[ RECORD %d ]", i+1)
+		fmt.Fprintln(w, header+strings.Repeat("-", max(nameWidth+3-displayWidth(header), 4)))
+		for j, col := range result.Columns {
+			text, ok := FormatValue(row[j])
+			if !ok {
+				text = opts.NullString
+			}
+
+			var lines []string
+			if opts.Overflow == OverflowWrap || valueWidth == 0 {
+				lines = wrapWidth(text, valueWidth)
+			} else {
+				for _, line := range strings.Split(text, "\n") {
+					lines = append(lines, truncateWidth(strings.TrimRight(line, "\r"), valueWidth))
+				}
+			}
+
+			switch {
+			case opts.Color && opts.Highlight != nil && opts.Highlight(i, j):
+				for k := range lines {
+					lines[k] = highlightColor.Sprint(lines[k])
+				}
+			case !ok && opts.Color:
+				lines[0] = color.New(color.Faint).Sprint(lines[0])
+			}
+
+			fmt.Fprintf(w, "%s | %s\n", padRight(col, nameWidth), lines[0])
+			// 多行值后续行与首行对齐
+			for _, line := range lines[1:] {
+				fmt.Fprintf(w, "%s | %s\n", strings.Repeat(" ", nameWidth), line)
+			}
+		}
+	}
+	return nil
+}
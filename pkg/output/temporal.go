@@ -0,0 +1,87 @@
+package output
+
+import (
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// DateTimeLayout 时间值的默认显示格式，值带小数秒时保留小数秒
+const DateTimeLayout = "2006-01-02 15:04:05.999999999"
+
+var (
+	// timeLayout 时间值的显示格式
+	timeLayout = DateTimeLayout
+	// timeLocation 显示时间值使用的时区，为 nil 时保持值本身的时区
+	timeLocation *time.Location
+)
+
+// parseLayouts 解析时间时在显示格式之后依次尝试的格式
+var parseLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700 MST",   // time.Time 的默认文本形式
+	"2006-01-02 15:04:05.999999999 -0700 -0700", // 没有时区名称的固定时区
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"2006/01/02 15:04:05.999999999",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// SetTimeFormat 设置时间值的显示格式和时区，loc 为 nil 时保持值本身的时区
+func SetTimeFormat(layout string, loc *time.Location) {
+	timeLayout = layout
+	timeLocation = loc
+}
+
+// NormalizeTime 将时间转换到显示时区，未设置时区时保持值本身的时区
+func NormalizeTime(t time.Time) time.Time {
+	if timeLocation != nil {
+		return t.In(timeLocation)
+	}
+	return t
+}
+
+// FormatTime 按显示格式和时区将时间转换为文本，REPL、CSV 和 Excel 使用相同的规则
+func FormatTime(t time.Time) string {
+	return NormalizeTime(t).Format(timeLayout)
+}
+
+// ParseTime 解析文本中的时间，先按显示格式，再按常见格式；
+// 不带时区的文本按显示时区解释，未设置显示时区时按本地时区解释
+func ParseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	loc := timeLocation
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, layout := range append([]string{timeLayout}, parseLayouts...) {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// excelDateFormat 返回与显示格式对应的 Excel 数字格式
+func excelDateFormat() string {
+	if !strings.Contains(timeLayout, "15") && !strings.Contains(timeLayout, "03") {
+		return "yyyy-mm-dd"
+	}
+	if strings.Contains(timeLayout, ".0") || strings.Contains(timeLayout, ".9") {
+		return "yyyy-mm-dd hh:mm:ss.000"
+	}
+	return "yyyy-mm-dd hh:mm:ss"
+}
+
+// ExcelDateStyle 在工作簿中创建时间单元格使用的样式。Excel 不保存时区，
+// 时间经 NormalizeTime 转换后按显示时区的时间写入
+func ExcelDateStyle(f *excelize.File) (int, error) {
+	format := excelDateFormat()
+	return f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// renderCSV 以CSV格式输出，NULL输出为空字段，换行由CSV引号规则保留
+func renderCSV(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(result.Columns); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		record := make([]string, len(row))
+		for i, val := range row {
+			record[i], _ = FormatValue(val)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// tsvReplacer 按PostgreSQL文本格式转义TSV中的特殊字符
+var tsvReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// renderTSV 以制表符分隔输出，NULL输出为 \N
+func renderTSV(w io.Writer, result *Result, opts Options) error {
+	if len(result.Columns) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	for i, col := range result.Columns {
+		if i > 0 {
+			sb.WriteByte('\t')
+		}
+		sb.WriteString(tsvReplacer.Replace(col))
+	}
+	sb.WriteByte('\n')
+
+	for _, row := range result.Rows {
+		for i, val := range row {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			text, ok := FormatValue(val)
+			if !ok {
+				sb.WriteString(`\N`)
+				continue
+			}
+			sb.WriteString(tsvReplacer.Replace(text))
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FormatValue 将单元格的值转换为文本，NULL返回ok=false
+// 二进制列的值([]byte)和无效UTF-8的字符串以 \x 开头的十六进制显示
+func FormatValue(val interface{}) (text string, ok bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", false
+	case string:
+		if !utf8.ValidString(v) {
+			return hexString([]byte(v)), true
+		}
+		return v, true
+	case []byte:
+		return hexString(v), true
+	case time.Time:
+		return FormatTime(v), true
+	default:
+		return fmt.Sprintf("%v", v), true
+	}
+}
+
+// hexString 以十六进制表示二进制数据
+func hexString(b []byte) string {
+	return "\\x" + hex.EncodeToString(b)
+}
+
+// cellText 返回显示用文本，NULL替换为选项中的显示文本
+func cellText(val interface{}, opts Options) string {
+	text, ok := FormatValue(val)
+	if !ok {
+		return opts.NullString
+	}
+	return text
+}
+
+// escapeControl 将换行、制表符等控制字符转义为可见形式，保证单行显示
+func escapeControl(s string) string {
+	if !strings.ContainsAny(s, "\r\n\t") {
+		return s
+	}
+	replacer := strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`)
+	return replacer.Replace(s)
+}
+
+// numericPattern 匹配十进制数字文本，带前导零的编码类文本不视为数字
+var numericPattern = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// isNumericValue 判断值是否为数字，表格中数字列右对齐
+func isNumericValue(val interface{}) bool {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	case string:
+		return numericPattern.MatchString(v)
+	default:
+		return false
+	}
+}
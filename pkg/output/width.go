@@ -0,0 +1,69 @@
+package output
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ellipsis 截断时附加的省略号
+const ellipsis = "…"
+
+// displayWidth 返回字符串在终端中的显示宽度，中日韩字符按两列计算
+func displayWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// truncateWidth 将字符串截断到指定显示宽度，超出部分以省略号表示
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	if width <= displayWidth(ellipsis) {
+		return runewidth.Truncate(s, width, "")
+	}
+	return runewidth.Truncate(s, width, ellipsis)
+}
+
+// wrapWidth 按显示宽度将字符串折行，原有换行符保留
+func wrapWidth(s string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if width <= 0 || displayWidth(line) <= width {
+			lines = append(lines, line)
+			continue
+		}
+
+		var current strings.Builder
+		currentWidth := 0
+		for _, r := range line {
+			rw := runewidth.RuneWidth(r)
+			if currentWidth+rw > width && currentWidth > 0 {
+				lines = append(lines, current.String())
+				current.Reset()
+				currentWidth = 0
+			}
+			current.WriteRune(r)
+			currentWidth += rw
+		}
+		lines = append(lines, current.String())
+	}
+	return lines
+}
+
+// padRight 在右侧补齐空格到指定显示宽度
+func padRight(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// padLeft 在左侧补齐空格到指定显示宽度
+func padLeft(s string, width int) string {
+	if n := width - displayWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FormatXLSX Excel工作簿格式，输出为二进制内容，只能写入文件
+const FormatXLSX = "xlsx"
+
+// IsBinaryFormat 判断输出格式是否为二进制格式，二进制格式不能输出到终端
+func IsBinaryFormat(format string) bool {
+	return Normalize(format) == FormatXLSX
+}
+
+// TextFormats 返回可以输出到终端的格式
+func TextFormats() []string {
+	var text []string
+	for _, format := range formats {
+		if !IsBinaryFormat(format) {
+			text = append(text, format)
+		}
+	}
+	return text
+}
+
+// renderXLSX 以Excel工作簿输出，第一行为列名，NULL输出为空单元格，时间输出为日期单元格
+func renderXLSX(w io.Writer, result *Result, opts Options) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	dateStyle, err := ExcelDateStyle(f)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(result.Columns))
+	for i, col := range result.Columns {
+		header[i] = col
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for rowIdx, row := range result.Rows {
+		values := make([]interface{}, len(row))
+		for i, val := range row {
+			text, ok := FormatValue(val)
+			switch t, isTime := val.(time.Time); {
+			case !ok:
+			case isTime:
+				// 时间写为 Excel 日期单元格
+				values[i] = excelize.Cell{StyleID: dateStyle, Value: NormalizeTime(t)}
+			case isNumericValue(val) && !isString(val):
+				// 数值按数字写入，文本形式的数字保持原样以免丢失精度
+				values[i] = val
+			default:
+				values[i] = text
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(1, rowIdx+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+// isString 判断值是否为字符串
+func isString(val interface{}) bool {
+	_, ok := val.(string)
+	return ok
+}
@@ -0,0 +1,133 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/fatih/color"
+	"github.com/yuanpli/datamgr-cli/pkg/editor"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+// editDoneKey 编辑器关闭后注入的按键，go-prompt 中没有其他用途
+const editDoneKey = prompt.F24
+
+// editDoneSequence editDoneKey 对应的输入序列
+var editDoneSequence = []byte{0x1b, 0x5b, 0x24, 0x3b, 0x32, 0x7e}
+
+// editParser 包装终端输入。go-prompt 在单独的协程中持续读取终端，
+// 编辑器必须在该协程中运行才不会与提示符争抢输入
+type editParser struct {
+	prompt.ConsoleParser
+
+	mu      sync.Mutex
+	pending *string // 等待编辑的文本
+	edited  *string // 编辑完成的文本
+}
+
+// editInput 当前提示符的输入
+var editInput *editParser
+
+// newEditParser 创建可以运行编辑器的终端输入
+func newEditParser() *editParser {
+	editInput = &editParser{ConsoleParser: prompt.NewStandardInputParser()}
+	return editInput
+}
+
+// request 请求在下一次读取输入时编辑文本
+func (p *editParser) request(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = &text
+}
+
+// takeEdited 返回编辑完成的文本
+func (p *editParser) takeEdited() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.edited == nil {
+		return "", false
+	}
+	text := *p.edited
+	p.edited = nil
+	return text, true
+}
+
+// Read 有待编辑的文本时恢复终端并运行编辑器，完成后返回 editDoneKey
+func (p *editParser) Read() ([]byte, error) {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	if pending == nil {
+		return p.ConsoleParser.Read()
+	}
+
+	if err := p.ConsoleParser.TearDown(); err != nil {
+		return nil, err
+	}
+	text, err := editor.Edit(*pending)
+	if err != nil {
+		fmt.Fprintf(handler.Output(), "\n%s%v\n", color.RedString(errorPrefix), err)
+		text = *pending
+	}
+	if err := p.ConsoleParser.Setup(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.edited = &text
+	p.mu.Unlock()
+	return editDoneSequence, nil
+}
+
+// editKeyBinds Ctrl+X 在编辑器中编辑当前输入，输入为空时编辑最近执行的查询，
+// 关闭编辑器后结果载入提示符
+func editKeyBinds() []prompt.KeyBind {
+	return []prompt.KeyBind{
+		{
+			Key: prompt.ControlX,
+			Fn: func(buf *prompt.Buffer) {
+				search.active = false
+				text := buf.Text()
+				if strings.TrimSpace(text) == "" {
+					text = lastQuery
+				}
+				editInput.request(text)
+			},
+		},
+		{
+			Key: editDoneKey,
+			Fn: func(buf *prompt.Buffer) {
+				text, ok := editInput.takeEdited()
+				if !ok {
+					return
+				}
+				buf.CursorRight(len([]rune(buf.Document().TextAfterCursor())))
+				buf.DeleteBeforeCursor(len([]rune(buf.Text())))
+				buf.InsertText(strings.TrimRight(text, " \t\r\n;"), false, true)
+			},
+		},
+	}
+}
+
+// editLastQuery 在编辑器中编辑最近执行的查询，保存后执行编辑结果
+func editLastQuery() error {
+	text, err := editor.Edit(lastQuery)
+	if err != nil {
+		return err
+	}
+	statements := handler.SplitStatements(text)
+	if len(statements) == 0 {
+		fmt.Fprintln(handler.Output(), "编辑结果为空，未执行任何语句")
+		return nil
+	}
+	for _, stmt := range statements {
+		fmt.Fprintln(handler.Output(), stmt)
+		recordHistory(stmt)
+		ExecuteCommand(stmt)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package prompt
+
+import (
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+// inputMarkerColor 输入内容使用的颜色，go-prompt 中其他位置不会以它作为前景色，
+// 用于识别接下来写出的是用户输入
+const inputMarkerColor = prompt.Cyan
+
+// highlightWriter 包装 go-prompt 的输出，对用户输入做语法高亮
+type highlightWriter struct {
+	prompt.ConsoleWriter
+	input bool // 下一次写出的是用户输入
+}
+
+// newHighlightWriter 创建语法高亮输出
+func newHighlightWriter() *highlightWriter {
+	return &highlightWriter{ConsoleWriter: prompt.NewStdoutWriter()}
+}
+
+// SetColor 遇到输入颜色时不立即设置，由WriteStr按词法单元设置颜色
+func (w *highlightWriter) SetColor(fg, bg prompt.Color, bold bool) {
+	w.input = fg == inputMarkerColor
+	if !w.input {
+		w.ConsoleWriter.SetColor(fg, bg, bold)
+	}
+}
+
+// WriteStr 写出用户输入时按词法单元着色
+func (w *highlightWriter) WriteStr(data string) {
+	if !w.input {
+		w.ConsoleWriter.WriteStr(data)
+		return
+	}
+	w.input = false
+	for _, t := range sqllex.Lex(data) {
+		fg, bold := tokenColor(t.Kind)
+		w.ConsoleWriter.SetColor(fg, prompt.DefaultColor, bold)
+		w.ConsoleWriter.WriteStr(t.Text)
+	}
+	w.ConsoleWriter.SetColor(prompt.DefaultColor, prompt.DefaultColor, false)
+}
+
+// tokenColor 返回词法单元的颜色和是否加粗
+func tokenColor(kind sqllex.Kind) (prompt.Color, bool) {
+	switch kind {
+	case sqllex.Keyword:
+		return prompt.Blue, true
+	case sqllex.String:
+		return prompt.Green, false
+	case sqllex.Number:
+		return prompt.Fuchsia, false
+	case sqllex.Identifier:
+		return prompt.Turquoise, false
+	case sqllex.Comment:
+		return prompt.DarkGray, false
+	default:
+		return prompt.DefaultColor, false
+	}
+}
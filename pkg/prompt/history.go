@@ -0,0 +1,183 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/history"
+)
+
+var (
+	// cmdHistory 当前连接配置的持久化历史，无法打开历史文件时为nil
+	cmdHistory *history.History
+	// historyProfile 当前历史对应的连接配置
+	historyProfile string
+	// search Ctrl+R 反向搜索状态
+	search reverseSearch
+)
+
+// currentProfile 返回当前连接对应的历史配置名，未连接时为default
+func currentProfile() string {
+	config := db.GetCurrentConfig()
+	if config == nil {
+		return "default"
+	}
+	return fmt.Sprintf("%s_%s@%s_%d_%s", config.Type, config.User, config.Host, config.Port, config.DbName)
+}
+
+// openHistory 打开当前连接配置的历史
+func openHistory() []string {
+	historyProfile = currentProfile()
+	h, err := history.Open(historyProfile)
+	if err != nil {
+		fmt.Fprintf(handler.Output(), "无法加载命令历史: %v\n", err)
+		cmdHistory = nil
+		return nil
+	}
+	cmdHistory = h
+	return h.Entries()
+}
+
+// recordHistory 将命令写入历史
+func recordHistory(cmd string) {
+	if cmdHistory == nil {
+		return
+	}
+	if err := cmdHistory.Add(cmd); err != nil {
+		fmt.Fprintf(handler.Output(), "保存命令历史失败: %v\n", err)
+	}
+}
+
+// handleHistory 处理 history [pattern] 命令
+func handleHistory(pattern string) error {
+	if cmdHistory == nil {
+		return fmt.Errorf("命令历史不可用")
+	}
+	entries := cmdHistory.Find(strings.TrimSpace(pattern))
+	if len(entries) == 0 {
+		fmt.Fprintln(handler.Output(), "没有匹配的历史命令")
+		return nil
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(handler.Output(), "%5d  %s\n", entry.Index, entry.Text)
+	}
+	return nil
+}
+
+// reverseSearch Ctrl+R 反向增量搜索的状态
+type reverseSearch struct {
+	active   bool
+	query    string
+	index    int    // 当前匹配的历史下标
+	shown    string // 当前显示在输入行的内容
+	original string // 开始搜索前输入行的内容
+	failed   bool
+}
+
+// prefix 返回搜索状态下的提示符
+func (s *reverseSearch) prefix() string {
+	if s.failed {
+		return fmt.Sprintf("(failed reverse-i-search)`%s': ", s.query)
+	}
+	return fmt.Sprintf("(reverse-i-search)`%s': ", s.query)
+}
+
+// start 开始搜索，或在搜索中查找更早的匹配
+func (s *reverseSearch) start(buf *prompt.Buffer) {
+	if cmdHistory == nil {
+		return
+	}
+	if !s.active {
+		*s = reverseSearch{active: true, index: cmdHistory.Len(), original: buf.Text()}
+		s.show(buf, s.original)
+		return
+	}
+	s.find(buf, s.index)
+}
+
+// find 从下标before之前查找匹配当前关键字的历史并显示
+func (s *reverseSearch) find(buf *prompt.Buffer, before int) {
+	if s.query == "" {
+		s.index, s.failed = cmdHistory.Len(), false
+		s.show(buf, s.original)
+		return
+	}
+	i := cmdHistory.SearchBackward(s.query, before)
+	s.failed = i < 0
+	if i >= 0 {
+		s.index = i
+		s.show(buf, cmdHistory.At(i))
+	} else {
+		s.show(buf, s.shown)
+	}
+}
+
+// show 替换输入行内容，光标置于末尾
+func (s *reverseSearch) show(buf *prompt.Buffer, text string) {
+	buf.CursorRight(len([]rune(buf.Document().TextAfterCursor())))
+	buf.DeleteBeforeCursor(len([]rune(buf.Text())))
+	buf.InsertText(text, false, true)
+	s.shown = text
+}
+
+// input 处理搜索中的输入字符，输入行多出的部分即新输入的内容
+func (s *reverseSearch) input(buf *prompt.Buffer) {
+	if !s.active {
+		return
+	}
+	typed := strings.TrimPrefix(buf.Text(), s.shown)
+	s.query += typed
+	s.find(buf, cmdHistory.Len())
+}
+
+// backspace 删除关键字的最后一个字符并重新搜索
+func (s *reverseSearch) backspace(buf *prompt.Buffer) {
+	if !s.active {
+		return
+	}
+	if query := []rune(s.query); len(query) > 0 {
+		s.query = string(query[:len(query)-1])
+	}
+	s.find(buf, cmdHistory.Len())
+}
+
+// accept 结束搜索，保留当前匹配以便编辑
+func (s *reverseSearch) accept(*prompt.Buffer) {
+	s.active = false
+}
+
+// cancel 结束搜索并恢复原输入
+func (s *reverseSearch) cancel(buf *prompt.Buffer) {
+	if !s.active {
+		return
+	}
+	s.show(buf, s.original)
+	s.active = false
+}
+
+// searchKeyBinds 返回反向搜索相关的按键绑定
+func searchKeyBinds() []prompt.KeyBind {
+	binds := []prompt.KeyBind{
+		{Key: prompt.ControlR, Fn: search.start},
+		{Key: prompt.NotDefined, Fn: search.input},
+		{Key: prompt.Backspace, Fn: search.backspace},
+		{Key: prompt.ControlH, Fn: search.backspace},
+		{Key: prompt.ControlG, Fn: search.cancel},
+	}
+	// 移动光标或翻阅历史时结束搜索
+	for _, key := range []prompt.Key{prompt.Escape, prompt.Left, prompt.Right, prompt.Up, prompt.Down, prompt.Home, prompt.End, prompt.ControlA, prompt.ControlE} {
+		binds = append(binds, prompt.KeyBind{Key: key, Fn: search.accept})
+	}
+	return binds
+}
+
+// livePrefix 搜索时显示搜索提示，否则显示普通提示符
+func livePrefix() (string, bool) {
+	if search.active {
+		return search.prefix(), true
+	}
+	return getPrompt()
+}
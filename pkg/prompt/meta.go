@@ -0,0 +1,264 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+// maxScriptDepth \i 嵌套执行脚本的最大层数
+const maxScriptDepth = 16
+
+var (
+	// lastQuery 最近执行的SQL语句，供 \e 编辑
+	lastQuery string
+	// scriptDepth 当前 \i 嵌套执行脚本的层数
+	scriptDepth int
+)
+
+// metaCommands 元命令及说明，用于补全和帮助
+var metaCommands = []prompt.Suggest{
+	{Text: `\?`, Description: "显示元命令帮助"},
+	{Text: `\dt`, Description: "列出表，可带名称模式"},
+	{Text: `\d`, Description: "显示表结构，可带表名和字段名模式"},
+	{Text: `\di`, Description: "列出索引，可带名称模式"},
+	{Text: `\dv`, Description: "列出视图，可带名称模式"},
+	{Text: `\dn`, Description: "列出模式"},
+	{Text: `\l`, Description: "列出数据库"},
+	{Text: `\c`, Description: "切换数据库，@名称 切换到命名配置"},
+	{Text: `\connect`, Description: "同 \\c"},
+	{Text: `\set`, Description: "设置变量，值可以是查询语句，不带参数时列出变量"},
+	{Text: `\unset`, Description: "删除变量"},
+	{Text: `\x`, Description: "切换纵向显示"},
+	{Text: `\timing`, Description: "切换显示执行耗时"},
+	{Text: `\o`, Description: "将查询结果输出到文件，不带参数恢复输出到终端"},
+	{Text: `\tee`, Description: "将会话内容记录到日志文件，off 停止记录"},
+	{Text: `\i`, Description: "执行脚本文件"},
+	{Text: `\e`, Description: "在编辑器中编辑最近的查询并执行"},
+	{Text: `\q`, Description: "退出程序"},
+}
+
+// isMetaCommand 判断是否为反斜杠开头的元命令
+func isMetaCommand(cmd string) bool {
+	return strings.HasPrefix(cmd, `\`)
+}
+
+// executeMeta 执行元命令
+func executeMeta(cmd string) error {
+	name, rest, _ := strings.Cut(cmd, " ")
+	rest = strings.TrimSpace(rest)
+	args := strings.Fields(rest)
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch name {
+	case `\?`:
+		printMetaHelp()
+	case `\dt`:
+		return handler.HandleListTables(arg(0))
+	case `\d`:
+		if len(args) == 0 {
+			return handler.HandleListTables("")
+		}
+		return handler.HandleDescribeTables(arg(0), arg(1))
+	case `\di`:
+		return handler.HandleCatalog(db.CatalogIndexes, arg(0))
+	case `\dv`:
+		return handler.HandleCatalog(db.CatalogViews, arg(0))
+	case `\dn`:
+		return handler.HandleCatalog(db.CatalogSchemas, arg(0))
+	case `\l`:
+		return handler.HandleCatalog(db.CatalogDatabases, arg(0))
+	case `\c`, `\connect`:
+		return metaConnect(rest)
+	case `\set`:
+		if len(args) == 0 {
+			handler.HandleShowVariables()
+			return nil
+		}
+		name, value, _ := strings.Cut(rest, " ")
+		return handler.HandleSetVariable(name, value)
+	case `\unset`:
+		if len(args) == 0 {
+			return errors.New(`用法: \unset <变量名>`)
+		}
+		for _, name := range args {
+			handler.UnsetVariable(name)
+		}
+	case `\x`:
+		if handler.ToggleExpanded() {
+			fmt.Fprintln(handler.Output(), "纵向显示已开启")
+		} else {
+			fmt.Fprintln(handler.Output(), "纵向显示已关闭")
+		}
+	case `\timing`:
+		if handler.ToggleTiming() {
+			fmt.Fprintln(handler.Output(), "显示执行耗时已开启")
+		} else {
+			fmt.Fprintln(handler.Output(), "显示执行耗时已关闭")
+		}
+	case `\o`:
+		if err := handler.SetOutputFile(rest); err != nil {
+			return err
+		}
+		if rest == "" {
+			fmt.Fprintln(handler.Output(), "查询结果恢复输出到终端")
+		} else {
+			fmt.Fprintf(handler.Output(), "查询结果将输出到 %s\n", rest)
+		}
+	case `\tee`:
+		return handleTee(rest)
+	case `\i`:
+		if rest == "" {
+			return errors.New(`用法: \i <文件路径>`)
+		}
+		return runScript(rest)
+	case `\e`:
+		return editLastQuery()
+	case `\q`:
+		cleanExit("再见！", 0)
+	default:
+		return fmt.Errorf(`未知的元命令: %s，输入 \? 查看元命令帮助`, name)
+	}
+	return nil
+}
+
+// printMetaHelp 输出元命令帮助
+func printMetaHelp() {
+	fmt.Fprintln(handler.Output(), "元命令:")
+	for _, c := range metaCommands {
+		fmt.Fprintf(handler.Output(), "  %-10s %s\n", c.Text, c.Description)
+	}
+	fmt.Fprintln(handler.Output(), "  表名和字段名模式支持通配符: * 或 % 匹配任意字符，? 匹配单个字符")
+	fmt.Fprintln(handler.Output(), "  语句中的 :name 或 :'name' 会以参数形式绑定变量 name 的值")
+}
+
+// metaConnect 处理 \c 和 \connect：不带参数时进入连接向导，@名称 使用命名配置，default 使用默认配置，
+// 其他名称在当前服务器上切换到该数据库，以 - 开头的参数同 connect 命令
+func metaConnect(args string) error {
+	if args == "" || strings.HasPrefix(args, "-") {
+		return handler.HandleConnect(strings.TrimSpace("connect " + args))
+	}
+	if name, ok := strings.CutPrefix(args, "@"); ok {
+		return handler.HandleConnectProfile(name)
+	}
+	if args == "default" {
+		return handler.HandleConnectProfile("")
+	}
+
+	current := db.GetCurrentConfig()
+	if current == nil {
+		return errors.New("当前未连接到任何数据库，请先使用 connect 命令连接")
+	}
+	config := *current
+	config.DbName = args
+	return connectWith(&config)
+}
+
+// connectWith 断开当前连接后使用指定配置连接
+func connectWith(config *db.DbConfig) error {
+	if db.GetCurrentConnection() != nil {
+		db.Disconnect()
+	}
+	if err := db.ConnectConfig(config); err != nil {
+		return err
+	}
+	fmt.Fprintf(handler.Output(), "已连接到 %s 数据库: %s\n", config.Type, config.DbName)
+	handler.AfterConnect(handler.SettingsProfile())
+	return nil
+}
+
+func init() {
+	// 连接后的启动脚本与 \i 使用相同的方式执行
+	handler.SetScriptRunner(runScript)
+}
+
+// runScript 执行脚本文件，元命令逐行执行，其他内容按分号拆分为语句执行
+func runScript(path string) error {
+	if scriptDepth >= maxScriptDepth {
+		return fmt.Errorf("脚本嵌套超过 %d 层，已停止执行 %s", maxScriptDepth, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("无法读取脚本文件: %v", err)
+	}
+
+	scriptDepth++
+	defer func() { scriptDepth-- }()
+
+	var pending strings.Builder
+	flush := func() {
+		for _, stmt := range handler.SplitStatements(pending.String()) {
+			ExecuteCommand(stmt)
+		}
+		pending.Reset()
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if trimmed := strings.TrimSpace(line); isMetaCommand(trimmed) {
+			flush()
+			ExecuteCommand(trimmed)
+			continue
+		}
+		pending.WriteString(line)
+		pending.WriteString("\n")
+	}
+	flush()
+	return nil
+}
+
+// metaSuggestions 返回元命令的补全候选，\d 和 \dt 之后补全表名
+func metaSuggestions(d prompt.Document) []prompt.Suggest {
+	before := d.TextBeforeCursor()
+	name, _, hasArgs := strings.Cut(before, " ")
+	if !hasArgs {
+		return prompt.FilterHasPrefix(metaCommands, d.GetWordBeforeCursor(), false)
+	}
+	if name == `\c` || name == `\connect` {
+		return prompt.FilterHasPrefix(profileSuggestions("@"), d.GetWordBeforeCursor(), true)
+	}
+	if name != `\d` && name != `\dt` {
+		return nil
+	}
+	metadata.Use(db.GetCurrentConnection())
+	var tables []prompt.Suggest
+	for _, table := range metadata.Tables() {
+		tables = append(tables, prompt.Suggest{Text: table, Description: "表"})
+	}
+	return prompt.FilterHasPrefix(tables, d.GetWordBeforeCursor(), true)
+}
+
+// handleTee 处理 tee [文件|off]：开始将命令、输出和耗时追加到日志文件，off 停止记录，
+// 不带参数时显示当前状态
+func handleTee(args string) error {
+	switch strings.ToLower(args) {
+	case "":
+		if name := handler.TeeFileName(); name != "" {
+			fmt.Fprintf(handler.Output(), "会话正在记录到 %s\n", name)
+		} else {
+			fmt.Fprintln(handler.Output(), "未记录会话，使用 tee <文件> 开始记录")
+		}
+		return nil
+	case "off":
+		name := handler.TeeFileName()
+		if name == "" {
+			return errors.New("当前未记录会话")
+		}
+		handler.StopTee()
+		fmt.Fprintf(handler.Output(), "已停止记录会话到 %s\n", name)
+		return nil
+	}
+	if err := handler.StartTee(args); err != nil {
+		return err
+	}
+	fmt.Fprintf(handler.Output(), "会话将记录到 %s\n", args)
+	return nil
+}
@@ -0,0 +1,185 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// profileUsage config profile 的用法
+const profileUsage = "用法: config profile add <名称> | list | remove <名称> | rename <原名称> <新名称> | default <名称> | readonly <名称> [on|off]"
+
+// handleProfile 处理 config profile 子命令，add 将当前连接保存为命名配置
+func handleProfile(args []string) error {
+	if len(args) == 0 {
+		return errors.New(profileUsage)
+	}
+	arg := func(i int) (string, error) {
+		if i >= len(args) {
+			return "", errors.New(profileUsage)
+		}
+		return args[i], nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "add":
+		name, err := arg(1)
+		if err != nil {
+			return err
+		}
+		current := db.GetCurrentConfig()
+		if current == nil {
+			return errors.New("当前未连接到任何数据库，请先连接后再保存为配置")
+		}
+		config := &utils.Config{
+			Type:     current.Type,
+			Host:     current.Host,
+			Port:     current.Port,
+			User:     current.User,
+			Password: current.Password,
+			DbName:   current.DbName,
+			Options:  current.Options,
+			ReadOnly: current.ReadOnly,
+		}
+		if err := utils.AddProfile(name, config); err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "已将当前连接保存为配置 %s\n", name)
+	case "list", "ls":
+		file, err := utils.LoadConfigFile()
+		if err != nil {
+			return err
+		}
+		if len(file.Profiles) == 0 {
+			fmt.Fprintln(handler.Output(), "没有保存的连接配置，可使用 'config profile add <名称>' 保存当前连接")
+			return nil
+		}
+		for _, name := range file.Names() {
+			marker := " "
+			if name == file.Default {
+				marker = "*"
+			}
+			fmt.Fprintf(handler.Output(), "%s %-16s %s\n", marker, name, file.Profiles[name].Summary())
+		}
+	case "remove", "rm":
+		name, err := arg(1)
+		if err != nil {
+			return err
+		}
+		if err := utils.RemoveProfile(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "已删除配置 %s\n", name)
+	case "rename":
+		newName, err := arg(2)
+		if err != nil {
+			return err
+		}
+		if err := utils.RenameProfile(args[1], newName); err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "已将配置 %s 重命名为 %s\n", args[1], newName)
+	case "default":
+		name, err := arg(1)
+		if err != nil {
+			return err
+		}
+		if err := utils.SetDefaultProfile(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "默认配置已设置为 %s\n", name)
+	case "readonly":
+		name, err := arg(1)
+		if err != nil {
+			return err
+		}
+		value, _ := arg(2)
+		readOnly, err := utils.ParseOnOff(value)
+		if err != nil {
+			return err
+		}
+		if err := utils.SetProfileReadOnly(name, readOnly); err != nil {
+			return err
+		}
+		if readOnly {
+			fmt.Fprintf(handler.Output(), "配置 %s 已设为只读，重新连接后生效\n", name)
+		} else {
+			fmt.Fprintf(handler.Output(), "配置 %s 已取消只读，重新连接后生效\n", name)
+		}
+	default:
+		return errors.New(profileUsage)
+	}
+	return nil
+}
+
+// handleLock 处理 config lock [--key-file [路径]]：加密配置文件中保存的密码
+func handleLock(args []string) error {
+	keyFile := ""
+	if len(args) > 0 {
+		if args[0] != "--key-file" || len(args) > 2 {
+			return errors.New("用法: config lock [--key-file [路径]]")
+		}
+		path := ""
+		if len(args) == 2 {
+			path = args[1]
+		}
+		var err error
+		if keyFile, err = utils.KeyFilePath(path); err != nil {
+			return err
+		}
+	}
+	count, err := utils.LockConfig(keyFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(handler.Output(), "已加密 %d 个配置的密码\n", count)
+	return nil
+}
+
+// profileSuggestions 返回命名配置的补全候选，prefix 为名称前的字符（如 @）
+func profileSuggestions(prefix string) []prompt.Suggest {
+	file, err := utils.LoadConfigFile()
+	if err != nil {
+		return nil
+	}
+	var suggestions []prompt.Suggest
+	for _, name := range file.Names() {
+		description := file.Profiles[name].Summary()
+		if name == file.Default {
+			description += " (默认)"
+		}
+		suggestions = append(suggestions, prompt.Suggest{Text: prefix + name, Description: description})
+	}
+	return suggestions
+}
+
+// driverSuggestions 返回已注册数据库类型的补全项
+func driverSuggestions() []prompt.Suggest {
+	var suggestions []prompt.Suggest
+	for _, driver := range db.Drivers() {
+		suggestions = append(suggestions, prompt.Suggest{Text: driver.Name, Description: driver.Title})
+	}
+	return suggestions
+}
+
+// optionSuggestions 返回默认配置的数据库类型支持的连接选项补全项
+func optionSuggestions() []prompt.Suggest {
+	config, err := utils.LoadStoredProfile("")
+	if err != nil {
+		config = utils.NewConfig()
+	}
+	driver, err := db.LookupDriver(config.Type)
+	if err != nil {
+		return nil
+	}
+	var suggestions []prompt.Suggest
+	for _, opt := range driver.Options {
+		suggestions = append(suggestions, prompt.Suggest{Text: opt.Name, Description: opt.Description})
+	}
+	return suggestions
+}
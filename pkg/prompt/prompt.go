@@ -4,18 +4,27 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/c-bata/go-prompt"
 	"github.com/fatih/color"
 	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/completion"
 	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/history"
 	"github.com/yuanpli/datamgr-cli/pkg/utils"
 )
 
 var (
+	// metadata 补全使用的表和字段缓存
+	metadata = completion.NewMetadata()
+	// sqlCommands 需要按SQL上下文补全的命令
+	sqlCommands = map[string]bool{
+		"select": true, "insert": true, "update": true, "delete": true, "with": true,
+		"desc": true, "describe": true, "browse": true, "import": true, "export": true,
+	}
+
 	successPrefix = "✓ "
 	errorPrefix   = "✗ "
 	dbPrompt      = "datamgr> "
@@ -24,7 +33,7 @@ var (
 // cleanExit 处理程序退出前的清理工作
 func cleanExit(message string, exitCode int) {
 	if message != "" {
-		fmt.Println(message)
+		fmt.Fprintln(handler.Output(), message)
 	}
 	
 	// 清理资源
@@ -45,13 +54,35 @@ func ExecuteCommand(cmd string) {
 		return
 	}
 
+	// 写入会话日志前屏蔽密码
+	prefix, _ := getPrompt()
+	handler.LogCommand(prefix, history.MaskPasswords(cmd))
+
 	// 判断是否为退出命令
 	if strings.ToLower(cmd) == "exit" || strings.ToLower(cmd) == "quit" {
 		cleanExit("再见！", 0)
 	}
 
-	// 删除末尾的分号（如果有）
-	cmd = strings.TrimSuffix(cmd, ";")
+	// ! 开头的命令交给 shell 执行
+	if strings.HasPrefix(cmd, "!") {
+		if err := handler.HandleShell(cmd[1:]); err != nil {
+			fmt.Fprintf(handler.Output(), "%s%v\n", color.RedString(errorPrefix), err)
+		}
+		return
+	}
+
+	// 删除末尾的分号（如果有），过程块保留 END 之后的分号
+	if !handler.IsBlockStatement(cmd) {
+		cmd = strings.TrimSuffix(cmd, ";")
+	}
+
+	// 反斜杠开头的元命令
+	if isMetaCommand(cmd) {
+		if err := executeMeta(cmd); err != nil {
+			fmt.Fprintf(handler.Output(), "%s%v\n", color.RedString(errorPrefix), err)
+		}
+		return
+	}
 
 	// 根据命令的第一个词来确定要调用的处理函数
 	cmdParts := strings.Fields(cmd)
@@ -72,33 +103,87 @@ func ExecuteCommand(cmd string) {
 		err = handler.HandleConnect(cmd)
 	case "config":
 		err = handleConfig(cmdParts[1:])
+	case "set":
+		if args := strings.TrimSpace(cmd[len(cmdParts[0]):]); isSettingCommand(args) {
+			err = handler.HandleSet(args)
+		} else {
+			// SET search_path、SET NAMES 等数据库的会话设置
+			err = handler.HandleSQL(cmd)
+		}
+	case "use":
+		err = handler.HandleSQL(cmd)
 	case "show":
 		if len(cmdParts) > 1 && strings.ToLower(cmdParts[1]) == "tables" {
 			err = handler.HandleShowTables()
+		} else if len(cmdParts) > 1 && strings.ToLower(cmdParts[1]) == "settings" {
+			err = handler.HandleShowSettings()
 		} else {
-			fmt.Println("未知的 show 命令。尝试使用 'show tables'。")
+			fmt.Fprintln(handler.Output(), "未知的 show 命令。尝试使用 'show tables' 或 'show settings'。")
 		}
 	case "desc", "describe":
 		if len(cmdParts) > 2 && strings.ToLower(cmdParts[1]) == "table" {
 			err = handler.HandleDescribeTable(cmdParts[2])
 		} else {
-			fmt.Println("用法: desc table <表名>")
+			fmt.Fprintln(handler.Output(), "用法: desc table <表名>")
 		}
-	case "select", "insert", "update", "delete":
-		err = executeSQL(cmd)
+	case "refresh":
+		err = handleRefresh()
+	case "tee":
+		err = handleTee(strings.TrimSpace(cmd[len(cmdParts[0]):]))
+	case "history":
+		err = handleHistory(strings.TrimSpace(cmd[len(cmdParts[0]):]))
+	case "edit":
+		err = editLastQuery()
+	case "save":
+		if len(cmdParts) > 1 && strings.ToLower(cmdParts[1]) == "query" {
+			err = handleSaveQuery(strings.TrimSpace(cmd[strings.Index(strings.ToLower(cmd), "query")+len("query"):]))
+		} else {
+			fmt.Fprintln(handler.Output(), `用法: save query <名称> [-d "说明"] [SQL语句]`)
+		}
+	case "query":
+		err = handleQuery(cmd[len(cmdParts[0]):])
+	case "watch":
+		err = handler.HandleWatch(cmd[len(cmdParts[0]):])
+	case "undo":
+		err = handler.HandleUndo(strings.TrimSpace(cmd[len(cmdParts[0]):]))
+	case "audit":
+		err = handler.HandleAudit(cmd[len(cmdParts[0]):])
+	case "browse":
+		if len(cmdParts) > 1 {
+			err = handler.HandleBrowse(cmdParts[1])
+		} else {
+			fmt.Fprintln(handler.Output(), "用法: browse <表名>")
+		}
+	case "select", "insert", "update", "delete", "with", "explain", "merge", "create", "alter", "drop", "truncate",
+		"begin", "declare", "call":
+		lastQuery = cmd
+		err = handler.HandleSQL(cmd)
 	case "import":
 		err = handler.HandleImport(cmd)
 	case "export":
 		err = handler.HandleExport(cmd)
 	default:
-		fmt.Printf("未知命令: %s\n", cmd)
+		fmt.Fprintf(handler.Output(), "未知命令: %s\n", cmd)
 	}
 
 	if err != nil {
-		fmt.Printf("%s%v\n", color.RedString(errorPrefix), err)
+		fmt.Fprintf(handler.Output(), "%s%v\n", color.RedString(errorPrefix), err)
 	}
 }
 
+// isSettingCommand 判断 set 之后的参数是否为命令行工具的设置，其他 SET 语句发送给数据库。
+// 设置名之后是 TO 或 = 时按 SQL 处理，如 PostgreSQL 的 SET timezone TO 'UTC'
+func isSettingCommand(args string) bool {
+	fields := strings.Fields(args)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "--") {
+		return true
+	}
+	if _, err := handler.LookupSetting(fields[0]); err != nil {
+		return false
+	}
+	return len(fields) < 2 || !strings.EqualFold(fields[1], "to") && !strings.HasPrefix(fields[1], "=")
+}
+
 // 保存当前连接为配置
 func saveCurrentConnectionAsConfig() error {
 	currentConfig := db.GetCurrentConfig()
@@ -113,13 +198,14 @@ func saveCurrentConnectionAsConfig() error {
 		User:     currentConfig.User,
 		Password: currentConfig.Password,
 		DbName:   currentConfig.DbName,
+		Options:  currentConfig.Options,
 	}
 
 	if err := utils.SaveConfig(config); err != nil {
 		return fmt.Errorf("保存配置失败: %v", err)
 	}
 
-	fmt.Println("已将当前连接信息保存为默认配置")
+	fmt.Fprintln(handler.Output(), "已将当前连接信息保存为默认配置")
 	return nil
 }
 
@@ -139,62 +225,88 @@ func handleConfig(args []string) error {
 		// 保存当前连接为默认配置
 		return saveCurrentConnectionAsConfig()
 
+	case "profile":
+		return handleProfile(args[1:])
+
+	case "lock":
+		return handleLock(args[1:])
+
+	case "resolve":
+		name := ""
+		if len(args) > 1 {
+			name = strings.TrimPrefix(args[1], "@")
+		}
+		res, err := utils.ResolveSources(name, nil)
+		if err != nil {
+			return err
+		}
+		utils.PrintResolution(handler.Output(), res)
+		return nil
+
+	case "trust", "untrust":
+		if len(args) > 2 {
+			return fmt.Errorf("用法: config %s [路径]", strings.ToLower(args[0]))
+		}
+		path := ""
+		if len(args) > 1 {
+			path = args[1]
+		}
+		trusted := strings.ToLower(args[0]) == "trust"
+		path, err := utils.TrustProject(path, trusted)
+		if err != nil {
+			return err
+		}
+		if trusted {
+			fmt.Fprintf(handler.Output(), "已信任项目配置 %s\n", path)
+		} else {
+			fmt.Fprintf(handler.Output(), "已取消信任项目配置 %s\n", path)
+		}
+		return nil
+
+	case "unlock":
+		count, err := utils.UnlockConfig()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "已解密 %d 个配置的密码，密码现以明文保存\n", count)
+		return nil
+
 	case "clear":
 		// 清除配置
 		if err := utils.ClearConfig(); err != nil {
 			return fmt.Errorf("清除配置失败: %v", err)
 		}
-		fmt.Println("已清除默认配置")
+		fmt.Fprintln(handler.Output(), "已清除默认配置")
 		return nil
 
 	case "set":
 		// 更新配置
 		if len(args) < 2 {
-			return fmt.Errorf("用法: config set [type|host|port|user|password|dbname] 值")
+			return fmt.Errorf("用法: config set [%s|<连接选项>] 值", strings.Join(utils.ConfigFields, "|"))
 		}
 
 		// 先尝试加载现有配置
 		var config *utils.Config
-		existingConfig, err := utils.LoadConfig()
+		existingConfig, err := utils.LoadStoredProfile("")
 		if err == nil {
 			// 有现有配置，以它为基础修改
 			config = existingConfig
 		} else {
-			// 没有现有配置，创建新的
-			config = &utils.Config{
-				Type: "dameng", // 默认使用达梦数据库
-				Port: 5236,     // 默认端口
-			}
+			// 没有现有配置，使用默认数据库类型及其默认端口
+			config = utils.NewConfig()
 		}
 
-		// 根据参数设置不同的字段
+		// 设置字段或当前数据库类型的连接选项，修改类型时端口随之改为新类型的默认端口
 		if len(args) >= 3 {
-			switch strings.ToLower(args[1]) {
-			case "type":
-				config.Type = args[2]
-			case "host":
-				config.Host = args[2]
-			case "port":
-				port, err := strconv.Atoi(args[2])
-				if err != nil {
-					return fmt.Errorf("端口格式错误: %v", err)
-				}
-				config.Port = port
-			case "user":
-				config.User = args[2]
-			case "password":
-				config.Password = args[2]
-			case "dbname":
-				config.DbName = args[2]
-			default:
-				return fmt.Errorf("未知的配置项: %s", args[1])
+			if err := config.Apply(map[string]string{strings.ToLower(args[1]): args[2]}); err != nil {
+				return err
 			}
 
 			// 保存配置
 			if err := utils.SaveConfig(config); err != nil {
 				return fmt.Errorf("保存配置失败: %v", err)
 			}
-			fmt.Printf("配置已更新: %s = %s\n", args[1], args[2])
+			fmt.Fprintf(handler.Output(), "配置已更新: %s = %s\n", args[1], args[2])
 		} else {
 			return fmt.Errorf("需要指定配置项的值")
 		}
@@ -212,14 +324,42 @@ func completer(d prompt.Document) []prompt.Suggest {
 		{Text: "exit", Description: "退出程序"},
 		{Text: "quit", Description: "退出程序"},
 		{Text: "clear", Description: "清屏"},
+		{Text: "history", Description: "显示或搜索命令历史"},
+		{Text: "tee", Description: "将会话内容记录到日志文件"},
+		{Text: "refresh", Description: "重新加载补全使用的表和字段信息"},
 		{Text: "status", Description: "显示连接状态"},
 		{Text: "connect", Description: "连接到数据库"},
 		{Text: "config", Description: "管理默认连接配置"},
 		{Text: "config save", Description: "保存当前连接为默认配置"},
 		{Text: "config set", Description: "修改默认配置"},
 		{Text: "config clear", Description: "清除默认配置"},
+		{Text: "config lock", Description: "加密配置文件中保存的密码"},
+		{Text: "config unlock", Description: "解密配置文件中的密码"},
+		{Text: "config resolve", Description: "显示合并后的连接配置及每个值的来源"},
+		{Text: "config trust", Description: "信任当前目录的项目配置文件"},
+		{Text: "config untrust", Description: "取消信任项目配置文件"},
+		{Text: "config profile add", Description: "将当前连接保存为命名配置"},
+		{Text: "config profile list", Description: "列出命名配置"},
+		{Text: "config profile remove", Description: "删除命名配置"},
+		{Text: "config profile rename", Description: "重命名配置"},
+		{Text: "config profile default", Description: "设置默认配置"},
+		{Text: "config profile readonly", Description: "将配置设为只读或取消只读"},
+		{Text: "set", Description: "查看或修改会话设置"},
 		{Text: "show tables", Description: "列出所有表"},
+		{Text: "show settings", Description: "列出所有设置的值和来源"},
 		{Text: "desc table", Description: "显示表结构"},
+		{Text: "browse", Description: "全屏浏览和编辑表数据"},
+		{Text: "watch", Description: "按间隔重复执行查询并突出显示变化"},
+		{Text: "undo", Description: "显示或执行撤销最近写入语句的补偿语句"},
+		{Text: "audit show", Description: "显示审计日志"},
+		{Text: "audit verify", Description: "校验审计日志是否被修改"},
+		{Text: "edit", Description: "在编辑器中编辑最近的查询并执行"},
+		{Text: "save query", Description: "保存最近的查询"},
+		{Text: "query save", Description: "保存查询到查询库"},
+		{Text: "query list", Description: "列出保存的查询"},
+		{Text: "query show", Description: "显示保存的查询"},
+		{Text: "query run", Description: "执行保存的查询"},
+		{Text: "query delete", Description: "删除保存的查询"},
 		{Text: "select", Description: "查询数据"},
 		{Text: "insert", Description: "插入数据"},
 		{Text: "update", Description: "更新数据"},
@@ -228,6 +368,19 @@ func completer(d prompt.Document) []prompt.Suggest {
 		{Text: "export", Description: "导出数据"},
 	}
 
+	if strings.HasPrefix(d.TextBeforeCursor(), `\`) {
+		return metaSuggestions(d)
+	}
+
+	if suggestions, ok := querySuggestions(d); ok {
+		return suggestions
+	}
+
+	// 数据库类型补全
+	if strings.HasPrefix(d.TextBeforeCursor(), "config set type ") {
+		return prompt.FilterHasPrefix(driverSuggestions(), d.GetWordBeforeCursor(), true)
+	}
+
 	// 添加config set子命令补全
 	if strings.HasPrefix(d.TextBeforeCursor(), "config set ") {
 		configItems := []prompt.Suggest{
@@ -238,29 +391,66 @@ func completer(d prompt.Document) []prompt.Suggest {
 			{Text: "password", Description: "设置密码"},
 			{Text: "dbname", Description: "设置数据库名"},
 		}
+		configItems = append(configItems, optionSuggestions()...)
 		return prompt.FilterHasPrefix(configItems, d.GetWordBeforeCursor(), true)
 	}
 
-	// 添加表名补全
-	if strings.HasPrefix(d.TextBeforeCursor(), "desc table ") ||
-		strings.HasPrefix(d.TextBeforeCursor(), "select * from ") {
-		conn := db.GetCurrentConnection()
-		if conn != nil {
-			tables, err := conn.GetTables()
-			if err == nil {
-				for _, table := range tables {
-					s = append(s, prompt.Suggest{
-						Text:        table,
-						Description: "表名",
-					})
-				}
-			}
+	// 命名配置补全
+	if strings.HasPrefix(d.TextBeforeCursor(), "connect @") || strings.HasPrefix(d.TextBeforeCursor(), "config resolve @") {
+		return prompt.FilterHasPrefix(profileSuggestions("@"), d.GetWordBeforeCursor(), true)
+	}
+	for _, sub := range []string{"remove ", "rm ", "rename ", "default "} {
+		if strings.HasPrefix(d.TextBeforeCursor(), "config profile "+sub) {
+			return prompt.FilterHasPrefix(profileSuggestions(""), d.GetWordBeforeCursor(), true)
+		}
+	}
+
+	// 会话设置补全
+	if strings.HasPrefix(d.TextBeforeCursor(), "set ") {
+		return settingSuggestions(d)
+	}
+
+	// SQL语句按上下文补全表名、字段、关键字和函数
+	if fields := strings.Fields(d.TextBeforeCursor()); len(fields) > 1 || len(fields) == 1 && strings.HasSuffix(d.TextBeforeCursor(), " ") {
+		if sqlCommands[strings.ToLower(fields[0])] {
+			return sqlSuggestions(d)
 		}
 	}
 
 	return prompt.FilterHasPrefix(s, d.GetWordBeforeCursor(), true)
 }
 
+// sqlSuggestions 根据SQL上下文返回补全候选
+func sqlSuggestions(d prompt.Document) []prompt.Suggest {
+	conn := db.GetCurrentConnection()
+	metadata.Use(conn)
+	dialect := ""
+	if config := db.GetCurrentConfig(); config != nil {
+		dialect = config.Type
+	}
+
+	var suggestions []prompt.Suggest
+	for _, s := range completion.Complete(metadata, dialect, d.TextBeforeCursor(), d.TextAfterCursor()) {
+		suggestions = append(suggestions, prompt.Suggest{Text: s.Text, Description: s.Description})
+	}
+	return suggestions
+}
+
+// handleRefresh 重新加载补全使用的表和字段信息
+func handleRefresh() error {
+	conn := db.GetCurrentConnection()
+	if conn == nil {
+		return fmt.Errorf("当前未连接到任何数据库")
+	}
+	metadata.Use(conn)
+	count, err := metadata.Refresh()
+	if err != nil {
+		return fmt.Errorf("刷新元数据失败: %v", err)
+	}
+	fmt.Fprintf(handler.Output(), "已刷新元数据，共 %d 张表\n", count)
+	return nil
+}
+
 // getPrompt 获取命令提示符
 func getPrompt() (string, bool) {
 	config := db.GetCurrentConfig()
@@ -275,234 +465,93 @@ func setupSignalHandler() {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
-		<-c
-		cleanExit("\n收到终止信号，程序退出...", 0)
+		for sig := range c {
+			// 执行查询期间 Ctrl+C 只取消当前查询
+			if sig == os.Interrupt && handler.CancelRunning() {
+				continue
+			}
+			cleanExit("\n收到终止信号，程序退出...", 0)
+		}
 	}()
 }
 
 // Start 启动交互式命令行
 func Start() {
-	fmt.Println("欢迎使用通用数据管理工具！输入 'help' 查看帮助信息。")
-	fmt.Println("输入 'exit' 或 'quit' 退出程序")
-	fmt.Println("按 Ctrl+C 也可以终止程序")
+	fmt.Fprintln(handler.Output(), "欢迎使用通用数据管理工具！输入 'help' 查看帮助信息。")
+	fmt.Fprintln(handler.Output(), "输入 'exit' 或 'quit' 退出程序")
+	fmt.Fprintln(handler.Output(), "按 Ctrl+C 也可以终止程序")
 	
 	// 检查是否已经连接到数据库（通过默认配置）
 	if config := db.GetCurrentConfig(); config != nil {
-		fmt.Printf("当前已连接到 %s 数据库: %s\n", config.Type, config.DbName)
+		fmt.Fprintf(handler.Output(), "当前已连接到 %s 数据库: %s\n", config.Type, config.DbName)
 	} else {
 		// 提示用户连接数据库
-		fmt.Println("当前未连接到数据库，请使用 'connect' 命令连接")
+		fmt.Fprintln(handler.Output(), "当前未连接到数据库，请使用 'connect' 命令连接")
 		
 		// 检查是否有默认配置可用
-		defaultConfig, err := utils.LoadConfig()
-		if err == nil && defaultConfig != nil {
-			fmt.Println("发现默认配置信息，可以使用 'connect' 命令快速连接")
+		// 只检查是否存在，不解密密码
+		if _, err := utils.LoadStoredProfile(""); err == nil {
+			fmt.Fprintln(handler.Output(), "发现默认配置信息，可以使用 'connect' 命令快速连接")
 		}
 	}
 	
 	// 设置信号处理
 	setupSignalHandler()
 
-	p := prompt.New(
-		ExecuteCommand,
-		completer,
-		prompt.OptionPrefix(dbPrompt),
-		prompt.OptionTitle("BWTY 数据管理工具"),
-		prompt.OptionLivePrefix(getPrompt),
-		prompt.OptionInputTextColor(prompt.Blue),
-		prompt.OptionPrefixTextColor(prompt.Blue),
-		// 下面的选项提高了终端兼容性
-		prompt.OptionMaxSuggestion(8),
-		prompt.OptionSuggestionBGColor(prompt.LightGray),
-		prompt.OptionSuggestionTextColor(prompt.Black),
-		prompt.OptionDescriptionBGColor(prompt.White),
-		prompt.OptionDescriptionTextColor(prompt.Black),
-		// 启用中断处理
-		prompt.OptionAddKeyBind(
-			prompt.KeyBind{
-				Key: prompt.ControlC,
-				Fn: func(buf *prompt.Buffer) {
-					cleanExit("\n程序被中断，正在退出...", 0)
+	for {
+		// 切换连接后重新创建提示符，以加载对应连接的命令历史
+		switching := false
+		p := prompt.New(
+			executor,
+			completer,
+			prompt.OptionPrefix(dbPrompt),
+			prompt.OptionTitle("BWTY 数据管理工具"),
+			prompt.OptionLivePrefix(livePrefix),
+			prompt.OptionWriter(newHighlightWriter()),
+			prompt.OptionInputTextColor(inputMarkerColor),
+			prompt.OptionPrefixTextColor(prompt.Blue),
+			prompt.OptionHistory(openHistory()),
+			prompt.OptionCompletionWordSeparator(completion.WordSeparators),
+			// 下面的选项提高了终端兼容性
+			prompt.OptionMaxSuggestion(8),
+			prompt.OptionSuggestionBGColor(prompt.LightGray),
+			prompt.OptionSuggestionTextColor(prompt.Black),
+			prompt.OptionDescriptionBGColor(prompt.White),
+			prompt.OptionDescriptionTextColor(prompt.Black),
+			// 启用中断处理
+			prompt.OptionAddKeyBind(
+				prompt.KeyBind{
+					Key: prompt.ControlC,
+					Fn: func(buf *prompt.Buffer) {
+						cleanExit("\n程序被中断，正在退出...", 0)
+					},
 				},
-			},
-		),
-	)
-
-	p.Run()
-}
-
-// executeSQL 执行SQL语句
-func executeSQL(sql string) error {
-	conn := db.GetCurrentConnection()
-	if conn == nil {
-		return fmt.Errorf("当前未连接到任何数据库")
-	}
-
-	// 判断是查询语句还是更新语句
-	sqlLower := strings.ToLower(sql)
-	if strings.HasPrefix(sqlLower, "select") {
-		// 直接执行查询
-		results, err := conn.Query(sql)
-		if err != nil {
-			return err
-		}
-
-		if len(results) == 0 {
-			fmt.Println("查询没有返回结果")
-			return nil
-		}
-
-		// 处理列排序
-		isSelectStar := isSelectAllQuery(sqlLower)
-		columns := getOrderedColumns(results[0], sqlLower, conn, isSelectStar)
-
-		// 打印表头
-		headerFmt := ""
-		for range columns {
-			headerFmt += fmt.Sprintf("%%-%ds ", 20)
-		}
-		headerFmt += "\n"
-
-		// 将列名转换为接口切片
-		headerValues := make([]interface{}, len(columns))
-		for i, v := range columns {
-			headerValues[i] = v
-		}
-		
-		fmt.Printf(headerFmt, headerValues...)
-		fmt.Println(strings.Repeat("-", 20*len(columns)))
-
-		// 打印每一行
-		rowFmt := ""
-		for range columns {
-			rowFmt += fmt.Sprintf("%%-%dv ", 20)
-		}
-		rowFmt += "\n"
-
-		for _, row := range results {
-			values := make([]interface{}, len(columns))
-			for i, col := range columns {
-				values[i] = row[col]
-			}
-			fmt.Printf(rowFmt, values...)
-		}
-
-		fmt.Printf("\n共 %d 行结果\n", len(results))
-
-	} else {
-		// 直接执行更新操作
-		affected, err := conn.Execute(sql)
-		if err != nil {
-			return err
-		}
-		
-		fmt.Printf("操作成功，影响了 %d 行数据\n", affected)
-	}
-
-	return nil
-}
-
-// isSelectAllQuery 判断是否为SELECT *查询
-func isSelectAllQuery(sqlLower string) bool {
-	// 去除多余空格
-	sqlLower = strings.TrimSpace(sqlLower)
-	
-	// 检查是否以SELECT *开头
-	if strings.HasPrefix(sqlLower, "select *") {
-		return true
-	}
-	
-	// 检查是否有SELECT和FROM之间只有*（可能有空格）
-	selectIndex := strings.Index(sqlLower, "select")
-	fromIndex := strings.Index(sqlLower, "from")
-	
-	if selectIndex >= 0 && fromIndex > selectIndex {
-		between := strings.TrimSpace(sqlLower[selectIndex+6:fromIndex])
-		if between == "*" {
-			return true
+			),
+			prompt.OptionAddKeyBind(searchKeyBinds()...),
+			prompt.OptionAddKeyBind(editKeyBinds()...),
+			prompt.OptionParser(newEditParser()),
+			prompt.OptionSetExitCheckerOnInput(func(in string, breakline bool) bool {
+				switching = breakline && currentProfile() != historyProfile
+				return switching
+			}),
+		)
+
+		p.Run()
+		if !switching {
+			return
 		}
+		// 清除旧提示符已输出的提示行
+		fmt.Print("\r\x1b[2K")
 	}
-	
-	return false
 }
 
-// getTableNameFromSQL 从SQL语句中提取表名
-func getTableNameFromSQL(sqlLower string) string {
-	fromIndex := strings.Index(sqlLower, "from")
-	if fromIndex < 0 {
-		return ""
-	}
-	
-	afterFrom := sqlLower[fromIndex+4:]
-	parts := strings.Fields(afterFrom)
-	if len(parts) == 0 {
-		return ""
+// executor 记录命令历史后执行命令
+func executor(cmd string) {
+	search.active = false
+	if history.HasMaskedPassword(cmd) {
+		fmt.Fprintf(handler.Output(), "%s命令中的密码来自历史记录且已被屏蔽，请输入实际密码后重新执行\n", color.RedString(errorPrefix))
+		return
 	}
-	
-	// 处理表名可能有的别名、WHERE子句等
-	tableName := parts[0]
-	// 移除可能的逗号、括号等
-	tableName = strings.TrimRight(tableName, ",();")
-	
-	return tableName
+	recordHistory(cmd)
+	ExecuteCommand(cmd)
 }
-
-// getOrderedColumns 根据查询类型获取有序的列名
-func getOrderedColumns(resultRow map[string]interface{}, sqlLower string, conn db.Connection, isSelectStar bool) []string {
-	// 如果不是SELECT *查询，保持原始顺序
-	if !isSelectStar {
-		var columns []string
-		for col := range resultRow {
-			columns = append(columns, col)
-		}
-		return columns
-	}
-	
-	// 对于SELECT *查询，尝试按表结构排序
-	tableName := getTableNameFromSQL(sqlLower)
-	if tableName == "" {
-		// 无法确定表名，使用原始顺序
-		var columns []string
-		for col := range resultRow {
-			columns = append(columns, col)
-		}
-		return columns
-	}
-	
-	// 获取表的列顺序
-	tableColumns, err := conn.GetTableColumns(tableName)
-	if err != nil || len(tableColumns) == 0 {
-		// 获取列顺序失败，使用原始顺序
-		var columns []string
-		for col := range resultRow {
-			columns = append(columns, col)
-		}
-		return columns
-	}
-	
-	// 使用表结构顺序排序结果列
-	var orderedColumns []string
-	
-	// 首先添加按表结构顺序的列
-	for _, col := range tableColumns {
-		if _, exists := resultRow[col]; exists {
-			orderedColumns = append(orderedColumns, col)
-		}
-	}
-	
-	// 添加可能的额外列（不在表结构中的列）
-	for col := range resultRow {
-		found := false
-		for _, orderedCol := range orderedColumns {
-			if col == orderedCol {
-				found = true
-				break
-			}
-		}
-		if !found {
-			orderedColumns = append(orderedColumns, col)
-		}
-	}
-	
-	return orderedColumns
-} 
\ No newline at end of file
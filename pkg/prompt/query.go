@@ -0,0 +1,181 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/queries"
+)
+
+// queryUsage query 命令的用法
+const queryUsage = `用法: query save <名称> [-d "说明"] [SQL语句] | query list | query show <名称> | query run <名称> [参数=值 ...] | query delete <名称>`
+
+// handleQuery 处理 query 子命令，args 为 query 之后的原始内容
+func handleQuery(args string) error {
+	sub, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(sub) {
+	case "save":
+		return handleSaveQuery(rest)
+	case "list", "ls":
+		return handleListQueries()
+	case "show":
+		return handleShowQuery(rest)
+	case "run":
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return errors.New("用法: query run <名称> [参数=值 ...]")
+		}
+		return handleRunQuery(fields[0], fields[1:])
+	case "delete", "rm":
+		if rest == "" {
+			return errors.New("用法: query delete <名称>")
+		}
+		if err := queries.Delete(rest); err != nil {
+			return err
+		}
+		fmt.Fprintf(handler.Output(), "已删除查询 %s\n", rest)
+		return nil
+	default:
+		return errors.New(queryUsage)
+	}
+}
+
+// handleSaveQuery 处理 save query <名称> [-d "说明"] [SQL]，未给出SQL时保存最近执行的查询
+func handleSaveQuery(args string) error {
+	name, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	if name == "" {
+		return errors.New(`用法: query save <名称> [-d "说明"] [SQL语句]`)
+	}
+	description, sql, err := cutDescription(strings.TrimSpace(rest))
+	if err != nil {
+		return err
+	}
+	if sql == "" {
+		sql = lastQuery
+	}
+	if sql == "" {
+		return errors.New("没有可保存的查询，请先执行查询或在命令中给出SQL语句")
+	}
+	path, err := queries.Save(name, description, sql)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(handler.Output(), "查询已保存到 %s\n", path)
+	return nil
+}
+
+// cutDescription 解析开头的 -d/--desc 说明，说明含空格时需用引号括起
+func cutDescription(s string) (description, rest string, err error) {
+	flag, after, _ := strings.Cut(s, " ")
+	if flag != "-d" && flag != "--desc" {
+		return "", s, nil
+	}
+	after = strings.TrimSpace(after)
+	if after == "" {
+		return "", "", errors.New("-d 之后缺少说明")
+	}
+	if quote := after[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(after[1:], quote)
+		if end < 0 {
+			return "", "", errors.New("说明缺少结束引号")
+		}
+		return after[1 : end+1], strings.TrimSpace(after[end+2:]), nil
+	}
+	description, rest, _ = strings.Cut(after, " ")
+	return description, strings.TrimSpace(rest), nil
+}
+
+// handleListQueries 列出保存的查询及其参数和说明
+func handleListQueries() error {
+	list, err := queries.List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		dir, _ := queries.Dir()
+		fmt.Fprintf(handler.Output(), "%s 中没有保存的查询\n", dir)
+		return nil
+	}
+	for _, q := range list {
+		line := q.Name
+		if params := q.Params(); len(params) > 0 {
+			line += " (" + strings.Join(params, ", ") + ")"
+		}
+		if q.Description != "" {
+			line += " - " + q.Description
+		}
+		fmt.Fprintln(handler.Output(), line)
+	}
+	return nil
+}
+
+// handleShowQuery 显示保存的查询内容
+func handleShowQuery(name string) error {
+	if name == "" {
+		return errors.New("用法: query show <名称>")
+	}
+	q, err := queries.Load(name)
+	if err != nil {
+		return err
+	}
+	if q.Description != "" {
+		fmt.Fprintf(handler.Output(), "说明: %s\n", q.Description)
+	}
+	if params := q.Params(); len(params) > 0 {
+		fmt.Fprintf(handler.Output(), "参数: %s\n", strings.Join(params, ", "))
+	}
+	fmt.Fprintln(handler.Output(), q.SQL)
+	return nil
+}
+
+// handleRunQuery 使用参数执行保存的查询，未给出的参数取自同名的会话变量
+func handleRunQuery(name string, args []string) error {
+	q, err := queries.Load(name)
+	if err != nil {
+		return err
+	}
+	params, err := queries.ParseParams(args)
+	if err != nil {
+		return err
+	}
+	for key, value := range handler.Variables() {
+		if _, ok := params[key]; !ok {
+			params[key] = value
+		}
+	}
+	sql, err := q.Render(params)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range handler.SplitStatements(sql) {
+		if err := handler.HandleSQLWithParams(stmt, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// querySuggestions 在 query run/show/delete 之后补全保存的查询名
+func querySuggestions(d prompt.Document) ([]prompt.Suggest, bool) {
+	fields := strings.Fields(strings.ToLower(d.TextBeforeCursor()))
+	word := d.GetWordBeforeCursor()
+	if word != "" {
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) != 2 || fields[0] != "query" || fields[1] != "run" && fields[1] != "show" && fields[1] != "delete" {
+		return nil, false
+	}
+	list, err := queries.List()
+	if err != nil {
+		return nil, true
+	}
+	var suggestions []prompt.Suggest
+	for _, q := range list {
+		suggestions = append(suggestions, prompt.Suggest{Text: q.Name, Description: q.Description})
+	}
+	return prompt.FilterHasPrefix(suggestions, word, true), true
+}
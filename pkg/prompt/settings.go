@@ -0,0 +1,45 @@
+package prompt
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+// settingSuggestions 补全 set 命令的范围选项、设置名和可选值
+func settingSuggestions(d prompt.Document) []prompt.Suggest {
+	fields := strings.Fields(d.TextBeforeCursor())[1:]
+	if !strings.HasSuffix(d.TextBeforeCursor(), " ") && len(fields) > 0 {
+		fields = fields[:len(fields)-1]
+	}
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+
+	var suggestions []prompt.Suggest
+	switch len(fields) {
+	case 0:
+		suggestions = []prompt.Suggest{
+			{Text: "--global", Description: "保存为全局设置"},
+			{Text: "--profile", Description: "保存到当前连接使用的配置"},
+		}
+		for _, s := range handler.Settings() {
+			suggestions = append(suggestions, prompt.Suggest{Text: s.Name, Description: s.Description})
+		}
+	case 1:
+		s, err := handler.LookupSetting(fields[0])
+		if err != nil {
+			return nil
+		}
+		values := s.Values
+		if s.Type == handler.SettingBool {
+			values = []string{"on", "off"}
+		}
+		for _, v := range values {
+			suggestions = append(suggestions, prompt.Suggest{Text: v})
+		}
+		suggestions = append(suggestions, prompt.Suggest{Text: "default", Description: "恢复为默认值"})
+	}
+	return prompt.FilterHasPrefix(suggestions, d.GetWordBeforeCursor(), true)
+}
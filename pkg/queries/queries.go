@@ -0,0 +1,244 @@
+package queries
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+const (
+	// DirEnv 指定查询目录的环境变量，可指向团队共享并纳入git管理的目录
+	DirEnv = "DATAMGR_QUERIES_DIR"
+
+	queriesDirName    = "queries"
+	queryFileExt      = ".sql"
+	descriptionPrefix = "-- description:"
+)
+
+var (
+	// namePattern 查询名：字母、数字、下划线、点和连字符
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+	// templateFieldPattern Go模板中引用的参数，如 {{.month}}
+	templateFieldPattern = regexp.MustCompile(`{{-?\s*\.([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// Query 保存的查询。文件开头以 "-- description:" 开始的注释行为查询说明
+type Query struct {
+	Name        string
+	Description string
+	SQL         string
+	Path        string
+}
+
+// Dir 返回保存查询的目录，设置了 DATAMGR_QUERIES_DIR 时使用该目录
+func Dir() (string, error) {
+	if dir := strings.TrimSpace(os.Getenv(DirEnv)); dir != "" {
+		return dir, nil
+	}
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, queriesDirName), nil
+}
+
+// ValidateName 检查查询名是否可以作为文件名使用
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("无效的查询名: %s，只能包含字母、数字、下划线、点和连字符", name)
+	}
+	return nil
+}
+
+// path 返回查询文件路径
+func path(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+queryFileExt), nil
+}
+
+// Save 将查询保存为查询目录下的 <name>.sql 文件，返回文件路径
+func Save(name, description, sql string) (string, error) {
+	file, err := path(name)
+	if err != nil {
+		return "", err
+	}
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return "", errors.New("查询内容为空")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", fmt.Errorf("无法创建查询目录: %v", err)
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sb.WriteString(descriptionPrefix + " " + line + "\n")
+		}
+	}
+	sb.WriteString(sql + "\n")
+	if err := os.WriteFile(file, []byte(sb.String()), 0644); err != nil {
+		return "", fmt.Errorf("保存查询失败: %v", err)
+	}
+	return file, nil
+}
+
+// Load 读取指定名称的查询
+func Load(name string) (*Query, error) {
+	file, err := path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("查询 %s 不存在", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取查询失败: %v", err)
+	}
+	return parse(name, file, string(data)), nil
+}
+
+// List 返回查询目录下的所有查询，按名称排序
+func List() ([]*Query, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取查询目录失败: %v", err)
+	}
+
+	var list []*Query
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), queryFileExt)
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), queryFileExt) || ValidateName(name) != nil {
+			continue
+		}
+		q, err := Load(name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, q)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Delete 删除指定名称的查询
+func Delete(name string) error {
+	file, err := path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("查询 %s 不存在", name)
+		}
+		return fmt.Errorf("删除查询失败: %v", err)
+	}
+	return nil
+}
+
+// parse 解析查询文件内容，开头的说明注释不计入SQL
+func parse(name, file, content string) *Query {
+	q := &Query{Name: name, Path: file}
+	var descriptions []string
+	var body []string
+	inHeader := true
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if inHeader && strings.HasPrefix(strings.ToLower(trimmed), descriptionPrefix) {
+			descriptions = append(descriptions, strings.TrimSpace(trimmed[len(descriptionPrefix):]))
+			continue
+		}
+		inHeader = false
+		body = append(body, line)
+	}
+	q.Description = strings.Join(descriptions, " ")
+	q.SQL = strings.TrimSpace(strings.Join(body, "\n"))
+	return q
+}
+
+// Params 返回查询中引用的参数，包括 :name 占位符和 {{.name}} 模板参数
+func (q *Query) Params() []string {
+	seen := make(map[string]bool)
+	var params []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+	}
+	for _, m := range templateFieldPattern.FindAllStringSubmatch(q.SQL, -1) {
+		add(m[1])
+	}
+	for _, v := range sqllex.Variables(q.SQL) {
+		add(v.Name)
+	}
+	return params
+}
+
+// Render 使用参数展开查询中的Go模板，并检查 :name 占位符都有对应的参数。
+// 模板按文本替换，:name 占位符保留在语句中，由调用方作为参数绑定
+func (q *Query) Render(params map[string]string) (string, error) {
+	sql := q.SQL
+	if strings.Contains(sql, "{{") {
+		tmpl, err := template.New(q.Name).Option("missingkey=error").Parse(sql)
+		if err != nil {
+			return "", fmt.Errorf("查询 %s 的模板有误: %v", q.Name, err)
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, params); err != nil {
+			return "", fmt.Errorf("展开查询 %s 失败: %v", q.Name, err)
+		}
+		sql = sb.String()
+	}
+
+	var missing []string
+	for _, v := range sqllex.Variables(sql) {
+		if _, ok := params[v.Name]; !ok && !slices.Contains(missing, v.Name) {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("缺少参数: %s", strings.Join(missing, ", "))
+	}
+	return sql, nil
+}
+
+// ParseParams 解析 key=value 形式的参数
+func ParseParams(args []string) (map[string]string, error) {
+	params := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("无效的参数: %s，应为 key=value 形式", arg)
+		}
+		params[strings.TrimSpace(key)] = value
+	}
+	return params, nil
+}
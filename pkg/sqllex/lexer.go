@@ -0,0 +1,230 @@
+package sqllex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind 词法单元类型
+type Kind int
+
+// 词法单元类型
+const (
+	Whitespace Kind = iota
+	Comment
+	String
+	Number
+	Keyword
+	Identifier
+	Operator
+)
+
+// Token 词法单元，所有词法单元的Text依次拼接即为原文本
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  int // 在原文本中的字节偏移
+	// Unterminated 字符串、带引号的标识符或块注释未闭合
+	Unterminated bool
+}
+
+// keywords 高亮显示的SQL关键字
+var keywords = make(map[string]bool)
+
+func init() {
+	for _, word := range strings.Fields(`
+		add all alter and any as asc between by case cast check column constraint create cross
+		database default delete desc describe distinct drop else end escape except exists explain
+		fetch first for foreign from full group having if ilike in index inner insert intersect
+		into is join key left like limit minus natural not null nulls offset on only or order
+		outer over partition primary references returning right rows select set show table
+		tables then top truncate union unique update using values view when where with`) {
+		keywords[word] = true
+	}
+}
+
+// IsKeyword 判断单词是否为SQL关键字，不区分大小写
+func IsKeyword(word string) bool {
+	return keywords[strings.ToLower(word)]
+}
+
+// Lex 将SQL文本拆分为词法单元，不会丢弃任何字符
+func Lex(s string) []Token {
+	var tokens []Token
+	for i := 0; i < len(s); {
+		kind, end, unterminated := scan(s, i)
+		tokens = append(tokens, Token{Kind: kind, Text: s[i:end], Pos: i, Unterminated: unterminated})
+		i = end
+	}
+	return tokens
+}
+
+// scan 读取从i开始的一个词法单元，返回类型、结束位置和是否未闭合
+func scan(s string, i int) (Kind, int, bool) {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	switch {
+	case unicode.IsSpace(r):
+		end := i + size
+		for end < len(s) {
+			r, size := utf8.DecodeRuneInString(s[end:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		return Whitespace, end, false
+	case strings.HasPrefix(s[i:], "--"):
+		if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+			return Comment, i + j, false
+		}
+		return Comment, len(s), false
+	case strings.HasPrefix(s[i:], "/*"):
+		if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+			return Comment, i + 2 + j + 2, false
+		}
+		return Comment, len(s), true
+	case r == '\'':
+		end, ok := closeQuote(s, i, '\'')
+		return String, end, !ok
+	case unicode.IsDigit(r):
+		return Number, scanNumber(s, i), false
+	case isWordRune(r) || r == '"' || r == '`' || r == '[':
+		end, ok := scanWord(s, i)
+		kind := Identifier
+		if ok && IsKeyword(s[i:end]) {
+			kind = Keyword
+		}
+		return kind, end, !ok
+	default:
+		return Operator, i + size, false
+	}
+}
+
+// closeQuote 查找从start开始的引号字符串的结束位置，两个连续的引号表示转义
+func closeQuote(s string, start int, quote byte) (int, bool) {
+	end := start + 1
+	for {
+		j := strings.IndexByte(s[end:], quote)
+		if j < 0 {
+			return len(s), false
+		}
+		end += j + 1
+		if end < len(s) && s[end] == quote {
+			end++
+			continue
+		}
+		return end, true
+	}
+}
+
+// scanNumber 读取数字，支持小数和科学计数法
+func scanNumber(s string, start int) int {
+	i := start
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9', c == '.':
+			i++
+		case (c == 'e' || c == 'E') && i+1 < len(s):
+			next := s[i+1]
+			if next >= '0' && next <= '9' {
+				i++
+			} else if (next == '+' || next == '-') && i+2 < len(s) && s[i+2] >= '0' && s[i+2] <= '9' {
+				i += 2
+			} else {
+				return i
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// isWordRune 判断字符是否可以出现在标识符中
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '#' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// scanWord 读取标识符，支持 schema.table 形式和带引号的标识符，返回结束位置和引号是否闭合
+func scanWord(s string, start int) (int, bool) {
+	i := start
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		var closing byte
+		switch r {
+		case '"':
+			closing = '"'
+		case '`':
+			closing = '`'
+		case '[':
+			closing = ']'
+		}
+		if closing != 0 {
+			j := strings.IndexByte(s[i+1:], closing)
+			if j < 0 {
+				return len(s), false
+			}
+			i += j + 2
+			continue
+		}
+		if !isWordRune(r) {
+			break
+		}
+		i += size
+	}
+	return i, true
+}
+
+// Variable 语句中以 :name 或 :'name' 形式引用的变量
+type Variable struct {
+	Name  string
+	Start int // 引用开始的字节偏移，指向冒号
+	End   int // 引用结束的字节偏移
+}
+
+// Variables 返回语句中引用的变量，字符串、注释中的内容以及 :: 类型转换不作为变量
+func Variables(s string) []Variable {
+	if !strings.Contains(s, ":") {
+		return nil
+	}
+	tokens := Lex(s)
+	var vars []Variable
+	for i := 0; i+1 < len(tokens); i++ {
+		t := tokens[i]
+		if t.Kind != Operator || t.Text != ":" || i > 0 && tokens[i-1].Text == ":" {
+			continue
+		}
+		next := tokens[i+1]
+		var name string
+		switch {
+		case next.Kind == Identifier:
+			name = next.Text
+		case next.Kind == String && !next.Unterminated:
+			name = next.Text[1 : len(next.Text)-1]
+		}
+		if !isVariableName(name) {
+			continue
+		}
+		vars = append(vars, Variable{Name: name, Start: t.Pos, End: next.Pos + len(next.Text)})
+		i++
+	}
+	return vars
+}
+
+// isVariableName 判断是否为合法的变量名：字母或下划线开头，由字母、数字和下划线组成
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,324 @@
+package undo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+const (
+	// MaxEntries 撤销日志最多保留的语句数，超过时删除最早的记录
+	MaxEntries = 100
+
+	journalFileName = "undo.jsonl"
+)
+
+// Entry 一条 UPDATE 或 DELETE 语句执行前受影响的行
+type Entry struct {
+	Time time.Time `json:"time"`
+	// 执行语句时的连接，只能在同一数据库上撤销
+	Profile string `json:"profile,omitempty"`
+	Type    string `json:"type"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	DbName  string `json:"dbname"`
+
+	Statement string `json:"statement"`
+	// Keyword 语句的关键字：UPDATE 或 DELETE
+	Keyword string   `json:"keyword"`
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	// Keys 主键列，UPDATE 按主键恢复原值
+	Keys []string `json:"keys"`
+	// SetColumns UPDATE 语句赋值的列，只恢复这些列；为空时恢复所有非主键列
+	SetColumns []string  `json:"set_columns,omitempty"`
+	Rows       [][]Value `json:"rows"`
+}
+
+// Value 行中的值，时间和二进制数据保存为带类型的对象以便原样恢复
+type Value struct {
+	V interface{}
+}
+
+// jsonValue 时间和二进制数据在日志中的形式
+type jsonValue struct {
+	Time  *time.Time `json:"time,omitempty"`
+	Bytes []byte     `json:"bytes,omitempty"`
+}
+
+// MarshalJSON 时间保存为 {"time": ...}，二进制数据保存为 {"bytes": base64}
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch val := v.V.(type) {
+	case time.Time:
+		return json.Marshal(jsonValue{Time: &val})
+	case []byte:
+		return json.Marshal(jsonValue{Bytes: val})
+	case string:
+		if !utf8.ValidString(val) {
+			return json.Marshal(jsonValue{Bytes: []byte(val)})
+		}
+	}
+	return json.Marshal(v.V)
+}
+
+// UnmarshalJSON 整数恢复为 int64，其他数字保持为文本以免丢失精度
+func (v *Value) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var raw interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	switch val := raw.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			v.V = n
+		} else {
+			v.V = val.String()
+		}
+	case map[string]interface{}:
+		var typed jsonValue
+		if err := json.Unmarshal(data, &typed); err != nil {
+			return err
+		}
+		switch {
+		case typed.Time != nil:
+			v.V = *typed.Time
+		case typed.Bytes != nil:
+			v.V = typed.Bytes
+		default:
+			return fmt.Errorf("无效的值: %s", data)
+		}
+	default:
+		v.V = raw
+	}
+	return nil
+}
+
+// Matches 判断记录是否属于指定的连接
+func (e *Entry) Matches(config *db.DbConfig) bool {
+	return config != nil && e.Type == config.Type && e.Host == config.Host &&
+		e.Port == config.Port && e.DbName == config.DbName
+}
+
+// Statement 一条补偿语句，SQL 中使用数据库类型的占位符
+type Statement struct {
+	SQL  string
+	Args []interface{}
+	// Check 执行前确认要恢复的行仍然存在的 COUNT 查询，为 nil 时不检查。
+	// MySQL 在值未改变时报告修改了 0 行，不能用影响的行数判断行是否存在
+	Check *Statement
+}
+
+// commentEscaper 转义注释中的换行
+var commentEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// Script 返回 undo apply 将执行的补偿语句，用于显示。语句与执行时相同，
+// 参数以字面值列在每条语句后的注释中
+func (e *Entry) Script() (string, error) {
+	statements, err := e.Statements()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, stmt := range statements {
+		params := make([]string, len(stmt.Args))
+		for i, arg := range stmt.Args {
+			params[i] = literal(arg)
+		}
+		sb.WriteString(stmt.SQL + "; -- " + commentEscaper.Replace(strings.Join(params, ", ")) + "\n")
+	}
+	return sb.String(), nil
+}
+
+// Statements 返回恢复执行前数据的补偿语句：DELETE 的行重新插入，UPDATE 的行按主键恢复原值
+func (e *Entry) Statements() ([]Statement, error) {
+	var statements []Statement
+	for _, row := range e.Rows {
+		if len(row) != len(e.Columns) {
+			return nil, errors.New("撤销日志中的行与列数不一致")
+		}
+		var stmt Statement
+		// arg 添加参数并返回其在语句中的形式
+		arg := func(value interface{}) string {
+			stmt.Args = append(stmt.Args, value)
+			return db.Placeholder(e.Type, len(stmt.Args))
+		}
+		switch e.Keyword {
+		case "DELETE":
+			var values []string
+			for _, value := range row {
+				values = append(values, arg(value.V))
+			}
+			stmt.SQL = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				e.Table, strings.Join(e.Columns, ", "), strings.Join(values, ", "))
+		case "UPDATE":
+			var sets, conditions []string
+			for i, col := range e.Columns {
+				if !e.isKey(col) && e.isSet(col) {
+					sets = append(sets, fmt.Sprintf("%s = %s", col, arg(row[i].V)))
+				}
+			}
+			for i, col := range e.Columns {
+				if e.isKey(col) {
+					conditions = append(conditions, fmt.Sprintf("%s = %s", col, arg(row[i].V)))
+				}
+			}
+			if len(conditions) == 0 {
+				return nil, fmt.Errorf("表 %s 没有主键，无法恢复", e.Table)
+			}
+			if len(sets) == 0 {
+				continue
+			}
+			stmt.SQL = fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+				e.Table, strings.Join(sets, ", "), strings.Join(conditions, " AND "))
+			stmt.Check = e.keyCount(row)
+		default:
+			return nil, fmt.Errorf("不支持撤销 %s 语句", e.Keyword)
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+// keyCount 返回按主键查询行数的语句
+func (e *Entry) keyCount(row []Value) *Statement {
+	check := &Statement{}
+	var conditions []string
+	for i, col := range e.Columns {
+		if e.isKey(col) {
+			check.Args = append(check.Args, row[i].V)
+			conditions = append(conditions, fmt.Sprintf("%s = %s", col, db.Placeholder(e.Type, len(check.Args))))
+		}
+	}
+	check.SQL = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", e.Table, strings.Join(conditions, " AND "))
+	return check
+}
+
+// literal 返回值的 SQL 字面值，时间包含时区偏移
+func literal(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return fmt.Sprintf("%d", v)
+	case float64:
+		return fmt.Sprintf("%v", v)
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999999-07:00") + "'"
+	case []byte:
+		return fmt.Sprintf("X'%X'", v)
+	}
+	return "'" + strings.ReplaceAll(fmt.Sprintf("%v", val), "'", "''") + "'"
+}
+
+// isKey 判断列是否为主键列
+func (e *Entry) isKey(column string) bool {
+	for _, key := range e.Keys {
+		if strings.EqualFold(key, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSet 判断 UPDATE 语句是否为列赋值，没有记录赋值的列时视为所有列都被赋值
+func (e *Entry) isSet(column string) bool {
+	if len(e.SetColumns) == 0 {
+		return true
+	}
+	for _, set := range e.SetColumns {
+		if strings.EqualFold(strings.Trim(set, "`\"[]"), column) {
+			return true
+		}
+	}
+	return false
+}
+
+// path 返回撤销日志文件路径
+func path() (string, error) {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, journalFileName), nil
+}
+
+// Load 读取撤销日志中的所有记录，按执行顺序排列，文件不存在时返回空
+func Load() ([]*Entry, error) {
+	file, err := path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry := &Entry{}
+		if err := json.Unmarshal([]byte(line), entry); err != nil {
+			return nil, fmt.Errorf("撤销日志格式错误: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Save 用指定的记录替换撤销日志，只保留最近的 MaxEntries 条。日志中包含表数据，只允许当前用户读写
+func Save(entries []*Entry) error {
+	file, err := path()
+	if err != nil {
+		return err
+	}
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+	var sb strings.Builder
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+	if err := os.WriteFile(file, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(file, 0600)
+}
+
+// Append 在撤销日志末尾添加一条记录
+func Append(entry *Entry) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+	return Save(append(entries, entry))
+}
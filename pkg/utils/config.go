@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strings"
 )
 
 // Config 存储数据库连接的配置信息
@@ -17,6 +20,12 @@ type Config struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	DbName   string `json:"dbname"`
+	// Options 驱动专用的连接选项，如 PostgreSQL 的 sslmode
+	Options map[string]string `json:"options,omitempty"`
+	// Settings 使用该配置连接时的会话设置，覆盖全局设置
+	Settings map[string]string `json:"settings,omitempty"`
+	// ReadOnly 只读配置，连接后拒绝执行写入语句、DDL 和导入
+	ReadOnly bool `json:"readonly,omitempty"`
 }
 
 const (
@@ -63,76 +72,363 @@ func GetConfigFilePath() (string, error) {
 	return filepath.Join(configDir, configFileName), nil
 }
 
-// SaveConfig 保存配置到文件
-func SaveConfig(config *Config) error {
+// DefaultProfileName 由旧版单一配置迁移而来的配置名
+const DefaultProfileName = "default"
+
+// profileNamePattern 配置名：字母、数字、下划线、点和连字符
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// ConfigFile 配置文件内容，保存多个命名的连接配置，Default 为默认使用的配置名
+type ConfigFile struct {
+	Default    string             `json:"default,omitempty"`
+	Profiles   map[string]*Config `json:"profiles"`
+	Encryption *Encryption        `json:"encryption,omitempty"`
+	// Settings 全局的会话设置，见 set 命令
+	Settings map[string]string `json:"settings,omitempty"`
+	// TrustedProjects 信任的项目配置文件及信任时文件内容的 SHA-256，文件修改后需重新信任
+	TrustedProjects map[string]string `json:"trusted_projects,omitempty"`
+}
+
+// ErrNoDefaultConfig 没有默认配置
+var ErrNoDefaultConfig = errors.New("默认配置不存在")
+
+// Summary 返回不含密码的连接信息摘要
+func (c *Config) Summary() string {
+	summary := fmt.Sprintf("%-10s %s@%s:%d/%s", c.Type, c.User, c.Host, c.Port, c.DbName)
+	if c.ReadOnly {
+		summary += " [只读]"
+	}
+	return summary
+}
+
+// ValidateProfileName 检查配置名是否有效
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("无效的配置名: %s，只能包含字母、数字、下划线、点和连字符", name)
+	}
+	return nil
+}
+
+// LoadConfigFile 读取配置文件，文件不存在时返回空配置。
+// 旧版只保存一个连接的配置文件会自动迁移为名为 default 的默认配置
+func LoadConfigFile() (*ConfigFile, error) {
 	configPath, err := GetConfigFilePath()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	data, err := json.MarshalIndent(config, "", "  ")
+	file := &ConfigFile{Profiles: make(map[string]*Config)}
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("配置文件格式错误: %v", err)
+	}
+	if _, ok := fields["profiles"]; !ok {
+		// 旧版配置文件，内容即为一个连接配置
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("配置文件格式错误: %v", err)
+		}
+		file.Profiles[DefaultProfileName] = &config
+		file.Default = DefaultProfileName
+		if err := SaveConfigFile(file); err != nil {
+			return nil, fmt.Errorf("迁移配置文件失败: %v", err)
+		}
+		return file, nil
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("配置文件格式错误: %v", err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]*Config)
+	}
+	return file, nil
 }
 
-// LoadConfig 从文件加载配置
-func LoadConfig() (*Config, error) {
+// SaveConfigFile 保存配置文件
+func SaveConfigFile(file *ConfigFile) error {
 	configPath, err := GetConfigFilePath()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, errors.New("默认配置不存在")
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
 	}
 
-	data, err := os.ReadFile(configPath)
+	// 配置中包含密码，只允许当前用户读写
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(configPath, 0600)
+}
+
+// Names 返回所有配置名，按名称排序
+func (f *ConfigFile) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile 返回指定名称的配置在文件中保存的内容（密码可能已加密或为占位符），name 为空时返回默认配置
+func (f *ConfigFile) Profile(name string) (*Config, error) {
+	if name == "" {
+		if f.Default == "" || f.Profiles[f.Default] == nil {
+			return nil, ErrNoDefaultConfig
+		}
+		name = f.Default
+	}
+	config, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("配置 %s 不存在，可用 'config profile list' 查看", name)
+	}
+	return config, nil
+}
+
+// LoadProfile 加载用于连接的配置，按 ResolveConfig 的层级合并项目配置和环境变量，
+// 解密密码并展开占位符，name 为空时加载默认配置
+func LoadProfile(name string) (*Config, error) {
+	res, err := ResolveConfig(name, nil)
 	if err != nil {
 		return nil, err
 	}
+	return res.Config, nil
+}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
+// LoadStoredProfile 加载文件中保存的配置内容，用于修改后重新保存，name 为空时加载默认配置
+func LoadStoredProfile(name string) (*Config, error) {
+	file, err := LoadConfigFile()
+	if err != nil {
 		return nil, err
 	}
+	config, err := file.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	stored := *config
+	return &stored, nil
+}
 
-	return &config, nil
+// AddProfile 添加命名配置，没有默认配置时将其设为默认
+func AddProfile(name string, config *Config) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; ok {
+		return fmt.Errorf("配置 %s 已存在", name)
+	}
+	if err := file.setProfile(name, config); err != nil {
+		return err
+	}
+	if file.Default == "" {
+		file.Default = name
+	}
+	return SaveConfigFile(file)
 }
 
-// ClearConfig 清除配置文件
-func ClearConfig() error {
-	configPath, err := GetConfigFilePath()
+// SaveProfile 保存指定名称的配置，已存在时覆盖，name 为空时保存为默认配置
+func SaveProfile(name string, config *Config) error {
+	file, err := LoadConfigFile()
 	if err != nil {
 		return err
 	}
+	if name == "" {
+		name = file.Default
+		if name == "" {
+			name = DefaultProfileName
+		}
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if err := file.setProfile(name, config); err != nil {
+		return err
+	}
+	if file.Default == "" {
+		file.Default = name
+	}
+	return SaveConfigFile(file)
+}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return errors.New("默认配置不存在")
+// setProfile 设置配置，配置已加密时密码加密后保存
+func (f *ConfigFile) setProfile(name string, config *Config) error {
+	stored := *config
+	password, err := f.storedPassword(stored.Password)
+	if err != nil {
+		return err
 	}
+	stored.Password = password
+	if old, ok := f.Profiles[name]; ok && stored.Settings == nil {
+		// 用当前连接覆盖配置时保留该配置的会话设置
+		stored.Settings = old.Settings
+	}
+	f.Profiles[name] = &stored
+	return nil
+}
 
-	// 删除配置文件
-	return os.Remove(configPath)
+// RemoveProfile 删除命名配置，删除默认配置时不再有默认配置
+func RemoveProfile(name string) error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; !ok {
+		return fmt.Errorf("配置 %s 不存在", name)
+	}
+	delete(file.Profiles, name)
+	if file.Default == name {
+		file.Default = ""
+	}
+	return SaveConfigFile(file)
+}
+
+// RenameProfile 重命名配置，默认配置重命名后仍为默认，配置的启动脚本一并重命名
+func RenameProfile(oldName, newName string) error {
+	if err := ValidateProfileName(newName); err != nil {
+		return err
+	}
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	config, ok := file.Profiles[oldName]
+	if !ok {
+		return fmt.Errorf("配置 %s 不存在", oldName)
+	}
+	if _, ok := file.Profiles[newName]; ok {
+		return fmt.Errorf("配置 %s 已存在", newName)
+	}
+	delete(file.Profiles, oldName)
+	file.Profiles[newName] = config
+	if file.Default == oldName {
+		file.Default = newName
+	}
+	if err := SaveConfigFile(file); err != nil {
+		return err
+	}
+	return renameRCFile(oldName, newName)
+}
+
+// SetDefaultProfile 将指定配置设为默认配置
+func SetDefaultProfile(name string) error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Profiles[name]; !ok {
+		return fmt.Errorf("配置 %s 不存在", name)
+	}
+	file.Default = name
+	return SaveConfigFile(file)
+}
+
+// ParseOnOff 解析 on/off 开关，省略时为 on
+func ParseOnOff(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("无效的开关值: %s，应为 on 或 off", value)
+}
+
+// SetProfileReadOnly 将配置设为只读或取消只读
+func SetProfileReadOnly(name string, readOnly bool) error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	config, ok := file.Profiles[name]
+	if !ok {
+		return fmt.Errorf("配置 %s 不存在", name)
+	}
+	config.ReadOnly = readOnly
+	return SaveConfigFile(file)
+}
+
+// SaveConfig 保存为默认配置
+func SaveConfig(config *Config) error {
+	return SaveProfile("", config)
+}
+
+// LoadConfig 加载默认配置
+func LoadConfig() (*Config, error) {
+	return LoadProfile("")
+}
+
+// ClearConfig 删除默认配置，其他命名配置保留
+func ClearConfig() error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	if file.Default == "" || file.Profiles[file.Default] == nil {
+		return ErrNoDefaultConfig
+	}
+	return RemoveProfile(file.Default)
 }
 
 // DisplayConfig 显示当前配置信息
 func DisplayConfig() (*Config, error) {
-	config, err := LoadConfig()
+	return DisplayProfile("")
+}
+
+// DisplayProfile 显示指定名称的配置信息，name 为空时显示默认配置
+func DisplayProfile(name string) (*Config, error) {
+	file, err := LoadConfigFile()
 	if err != nil {
 		return nil, err
 	}
-	
-	fmt.Println("当前默认配置:")
+	config, err := file.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = file.Default
+	}
+
+	if name == file.Default {
+		fmt.Printf("当前默认配置 (%s):\n", name)
+	} else {
+		fmt.Printf("配置 %s:\n", name)
+	}
 	fmt.Printf("  数据库类型: %s\n", config.Type)
 	fmt.Printf("  主机地址: %s\n", config.Host)
 	fmt.Printf("  端口: %d\n", config.Port)
 	fmt.Printf("  用户名: %s\n", config.User)
-	fmt.Printf("  密码: %s\n", "********") // 不直接显示密码
+	switch {
+	case HasPlaceholder(config.Password):
+		// 占位符只是引用，可以显示
+		fmt.Printf("  密码: %s\n", config.Password)
+	case IsEncrypted(config.Password):
+		fmt.Printf("  密码: %s\n", "******** (已加密)")
+	default:
+		fmt.Printf("  密码: %s\n", "********") // 不直接显示密码
+	}
 	fmt.Printf("  数据库名: %s\n", config.DbName)
+	for _, name := range config.OptionNames() {
+		fmt.Printf("  %s: %s\n", name, config.Options[name])
+	}
+	if config.ReadOnly {
+		fmt.Printf("  只读: 是\n")
+	}
 	
 	return config, nil
 } 
\ No newline at end of file
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HasWildcard 判断模式中是否包含通配符
+func HasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?%")
+}
+
+// MatchPattern 判断名称是否匹配模式，不区分大小写。
+// 模式中 * 和 % 匹配任意多个字符，? 匹配单个字符，空模式匹配所有名称
+func MatchPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	var sb strings.Builder
+	sb.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '*', '%':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	matched, err := regexp.MatchString(sb.String(), name)
+	return err == nil && matched
+}
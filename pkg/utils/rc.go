@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// rcFileName 每次连接后执行的启动脚本
+	rcFileName = "rc.sql"
+	// rcDirName 保存各配置启动脚本的目录，脚本名为 <配置名>.sql
+	rcDirName = "rc"
+)
+
+// RCFilePath 返回启动脚本的路径，profile 为空时为所有连接共用的 rc.sql，否则为该配置的脚本
+func RCFilePath(profile string) (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		return filepath.Join(configDir, rcFileName), nil
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, rcDirName, profile+".sql"), nil
+}
+
+// RCFiles 返回连接后需要执行的启动脚本：先是 rc.sql，再是 profile 的脚本，不存在的脚本跳过
+func RCFiles(profile string) ([]string, error) {
+	names := []string{""}
+	if profile != "" {
+		names = append(names, profile)
+	}
+
+	var files []string
+	for _, name := range names {
+		path, err := RCFilePath(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// renameRCFile 重命名配置时一并重命名其启动脚本
+func renameRCFile(oldName, newName string) error {
+	oldPath, err := RCFilePath(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := RCFilePath(newName)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,498 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+const (
+	// ProjectConfigFileName 项目目录中的连接配置文件，从当前目录向上查找
+	ProjectConfigFileName = ".datamgr.json"
+	// ProfileEnv 选择连接配置的环境变量
+	ProfileEnv = "DATAMGR_PROFILE"
+	// envPrefix 覆盖连接参数的环境变量前缀，如 DATAMGR_HOST
+	envPrefix = "DATAMGR_"
+)
+
+// 配置层级，后面的覆盖前面的
+const (
+	LayerDefault = "默认值"
+	LayerUser    = "用户配置"
+	LayerProject = "项目配置"
+	LayerEnv     = "环境变量"
+	LayerFlag    = "命令行参数"
+)
+
+// ConfigFields 连接配置的字段名，与 config set 的配置项相同，驱动的连接选项也可以用 config set 设置
+var ConfigFields = []string{"type", "host", "port", "user", "password", "dbname"}
+
+// isConfigField 判断是否为连接配置字段而不是连接选项
+func isConfigField(name string) bool {
+	for _, field := range ConfigFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewConfig 返回使用默认数据库类型及其默认端口的空配置
+func NewConfig() *Config {
+	config := &Config{Type: db.DefaultDriver}
+	if driver, err := db.LookupDriver(config.Type); err == nil {
+		config.Port = driver.DefaultPort
+	}
+	return config
+}
+
+// DbConfig 转换为连接数据库使用的配置
+func (c *Config) DbConfig() *db.DbConfig {
+	return &db.DbConfig{
+		Type:     c.Type,
+		Host:     c.Host,
+		Port:     c.Port,
+		User:     c.User,
+		Password: c.Password,
+		DbName:   c.DbName,
+		Options:  c.Options,
+		ReadOnly: c.ReadOnly,
+	}
+}
+
+// OptionNames 返回已设置的连接选项名称，按名称排序
+func (c *Config) OptionNames() []string {
+	names := make([]string, 0, len(c.Options))
+	for name := range c.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetType 修改数据库类型：端口为原类型的默认端口时改为新类型的默认端口，并去掉新类型不支持的连接选项
+func (c *Config) SetType(dbType string) error {
+	driver, err := db.LookupDriver(dbType)
+	if err != nil {
+		return err
+	}
+	if old, err := db.LookupDriver(c.Type); c.Port == 0 || (err == nil && c.Port == old.DefaultPort) {
+		c.Port = driver.DefaultPort
+	}
+	for name := range c.Options {
+		if _, ok := driver.Option(name); !ok {
+			delete(c.Options, name)
+		}
+	}
+	c.Type = dbType
+	return nil
+}
+
+// Apply 按 type、其他连接字段、连接选项的顺序设置多个值，修改类型时按 SetType 调整端口和选项
+func (c *Config) Apply(values map[string]string) error {
+	if dbType, ok := values["type"]; ok {
+		if err := c.SetType(dbType); err != nil {
+			return err
+		}
+	}
+	var options []string
+	for name := range values {
+		if !isConfigField(name) {
+			options = append(options, name)
+		}
+	}
+	sort.Strings(options)
+	for _, name := range append(ConfigFields[1:], options...) {
+		if value, ok := values[name]; ok {
+			if err := c.SetField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Field 返回指定字段或连接选项的值
+func (c *Config) Field(name string) string {
+	switch name {
+	case "type":
+		return c.Type
+	case "host":
+		return c.Host
+	case "port":
+		if c.Port == 0 {
+			return ""
+		}
+		return strconv.Itoa(c.Port)
+	case "user":
+		return c.User
+	case "password":
+		return c.Password
+	case "dbname":
+		return c.DbName
+	}
+	return c.Options[name]
+}
+
+// SetField 设置指定字段的值，name 不是连接配置字段时作为当前数据库类型的连接选项设置，值为空时删除该选项
+func (c *Config) SetField(name, value string) error {
+	switch name {
+	case "type":
+		if _, err := db.LookupDriver(value); err != nil {
+			return err
+		}
+		c.Type = value
+	case "host":
+		c.Host = value
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("端口格式错误: %v", err)
+		}
+		c.Port = port
+	case "user":
+		c.User = value
+	case "password":
+		c.Password = value
+	case "dbname":
+		c.DbName = value
+	default:
+		driver, err := db.LookupDriver(c.Type)
+		if err != nil {
+			return err
+		}
+		if _, ok := driver.Option(name); !ok {
+			items := append(append([]string{}, ConfigFields...), driver.OptionNames()...)
+			return fmt.Errorf("未知的配置项: %s，%s 数据库可设置: %s", name, driver.Name, strings.Join(items, ", "))
+		}
+		if err := driver.ValidateOption(name, value); err != nil {
+			return err
+		}
+		if value == "" {
+			delete(c.Options, name)
+			return nil
+		}
+		if c.Options == nil {
+			c.Options = make(map[string]string)
+		}
+		c.Options[name] = value
+	}
+	return nil
+}
+
+// Resolution 逐层合并后的连接配置
+type Resolution struct {
+	// Profile 使用的配置名，没有选择配置时为空
+	Profile string
+	// Config 用于连接的配置，密码已解密、占位符已展开
+	Config *Config
+	// Stored 合并后尚未解密和展开的值，用于显示
+	Stored *Config
+	// Sources 每个字段的值来自哪一层
+	Sources map[string]string
+}
+
+// FindProjectConfig 从当前目录向上查找项目配置文件，找不到时返回空
+func FindProjectConfig() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProjectConfig 读取项目配置文件，格式与用户配置文件相同，找不到时返回 nil
+func LoadProjectConfig() (*ConfigFile, string, error) {
+	path, err := FindProjectConfig()
+	if err != nil || path == "" {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	file := &ConfigFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, "", fmt.Errorf("项目配置 %s 格式错误: %v", path, err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]*Config)
+	}
+	return file, path, nil
+}
+
+// guardedFields 用户配置保存了密码时，未信任的项目配置不能修改的字段，否则密码会被发送到项目配置指定的服务器
+var guardedFields = []string{"type", "host", "port", "dbname"}
+
+// projectDigest 返回项目配置文件内容的 SHA-256
+func projectDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isTrustedProject 判断项目配置文件是否已信任且信任后未被修改
+func (f *ConfigFile) isTrustedProject(path string) bool {
+	trusted, ok := f.TrustedProjects[path]
+	if !ok {
+		return false
+	}
+	digest, err := projectDigest(path)
+	return err == nil && digest == trusted
+}
+
+// TrustProject 信任或取消信任项目配置文件，path 为空时使用从当前目录向上找到的文件，返回文件的绝对路径。
+// 信任记录包含文件内容的哈希，文件被修改后需要重新信任
+func TrustProject(path string, trusted bool) (string, error) {
+	if path == "" {
+		found, err := FindProjectConfig()
+		if err != nil {
+			return "", err
+		}
+		if found == "" {
+			return "", fmt.Errorf("当前目录及上级目录中没有 %s", ProjectConfigFileName)
+		}
+		path = found
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	file, err := LoadConfigFile()
+	if err != nil {
+		return "", err
+	}
+	if !trusted {
+		if _, ok := file.TrustedProjects[path]; !ok {
+			return "", fmt.Errorf("项目配置 %s 未被信任", path)
+		}
+		delete(file.TrustedProjects, path)
+		return path, SaveConfigFile(file)
+	}
+	digest, err := projectDigest(path)
+	if err != nil {
+		return "", err
+	}
+	if file.TrustedProjects == nil {
+		file.TrustedProjects = make(map[string]string)
+	}
+	file.TrustedProjects[path] = digest
+	return path, SaveConfigFile(file)
+}
+
+// checkProjectProfile 检查项目配置中的配置能否应用到同名的用户配置之上。项目配置中的值不展开占位符、不解密，
+// 以免仓库中的文件读取本地文件或环境变量并发送出去；未信任的项目配置不能修改保存了密码的用户配置的连接目标
+func checkProjectProfile(project, user *Config, trusted bool, path string) error {
+	for _, field := range ConfigFields {
+		value := project.Field(field)
+		if HasPlaceholder(value) {
+			return fmt.Errorf("项目配置 %s 中的 %s 不能使用占位符，请在用户配置、环境变量或命令行参数中提供", path, field)
+		}
+		if field == "password" && IsEncrypted(value) {
+			return fmt.Errorf("项目配置 %s 中不能使用加密的密码", path)
+		}
+	}
+	if trusted || user == nil || user.Password == "" {
+		return nil
+	}
+	for _, field := range guardedFields {
+		if value := project.Field(field); value != "" && value != user.Field(field) {
+			return fmt.Errorf("项目配置 %s 修改了 %s，而用户配置中保存了该配置的密码；确认该文件可信后执行 'config trust %s'", path, field, path)
+		}
+	}
+	return nil
+}
+
+// ResolveConfig 按 默认值 < 用户配置 < 项目配置 < DATAMGR_* 环境变量 < 命令行参数 的顺序合并连接配置，
+// 并解密密码、展开占位符。name 为空时依次使用 DATAMGR_PROFILE、项目配置和用户配置的默认配置名，
+// flags 为命令行中指定的字段。没有选择任何配置且环境变量和参数都未提供连接信息时返回 ErrNoDefaultConfig
+func ResolveConfig(name string, flags map[string]string) (*Resolution, error) {
+	res, userFile, err := resolveLayers(name, flags)
+	if err != nil {
+		return nil, err
+	}
+	config, err := userFile.resolve(res.Stored)
+	if err != nil {
+		return nil, err
+	}
+	res.Config = config
+	return res, nil
+}
+
+// ResolveSources 与 ResolveConfig 相同地合并各层配置，但不解密密码，用于显示每个值的来源
+func ResolveSources(name string, flags map[string]string) (*Resolution, error) {
+	res, _, err := resolveLayers(name, flags)
+	return res, err
+}
+
+// resolveLayers 逐层合并配置，返回结果和用户配置文件
+func resolveLayers(name string, flags map[string]string) (*Resolution, *ConfigFile, error) {
+	userFile, err := LoadConfigFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	projectFile, projectPath, err := LoadProjectConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+	if name == "" && projectFile != nil {
+		name = projectFile.Default
+	}
+	if name == "" {
+		name = userFile.Default
+	}
+
+	res := &Resolution{
+		Profile: name,
+		Stored:  &Config{Type: db.DefaultDriver},
+		Sources: map[string]string{"type": LayerDefault},
+	}
+	apply := func(config *Config, source string) error {
+		fields := append(append([]string{}, ConfigFields...), config.OptionNames()...)
+		for _, field := range fields {
+			if value := config.Field(field); value != "" {
+				if err := res.Stored.SetField(field, value); err != nil {
+					return fmt.Errorf("%s: %v", source, err)
+				}
+				res.Sources[field] = source
+			}
+		}
+		if config.ReadOnly {
+			// 任一层标记为只读即为只读，后面的层不能取消
+			res.Stored.ReadOnly = true
+			res.Sources["readonly"] = source
+		}
+		return nil
+	}
+
+	found := false
+	userConfig, ok := userFile.Profiles[name]
+	if ok && name != "" {
+		if err := apply(userConfig, fmt.Sprintf("%s (%s)", LayerUser, name)); err != nil {
+			return nil, nil, err
+		}
+		found = true
+	}
+	if projectFile != nil {
+		if config, ok := projectFile.Profiles[name]; ok && name != "" {
+			if err := checkProjectProfile(config, userConfig, userFile.isTrustedProject(projectPath), projectPath); err != nil {
+				return nil, nil, err
+			}
+			if err := apply(config, fmt.Sprintf("%s %s (%s)", LayerProject, projectPath, name)); err != nil {
+				return nil, nil, err
+			}
+			found = true
+		}
+	}
+	if name != "" && !found {
+		if name == userFile.Default || (projectFile != nil && name == projectFile.Default) {
+			return nil, nil, ErrNoDefaultConfig
+		}
+		return nil, nil, fmt.Errorf("配置 %s 不存在，可用 'config profile list' 查看", name)
+	}
+
+	overridden := false
+	for _, field := range ConfigFields {
+		env := envPrefix + strings.ToUpper(field)
+		if value, ok := os.LookupEnv(env); ok && value != "" {
+			if err := res.Stored.SetField(field, value); err != nil {
+				return nil, nil, fmt.Errorf("环境变量 %s: %v", env, err)
+			}
+			res.Sources[field] = fmt.Sprintf("%s %s", LayerEnv, env)
+			overridden = true
+		}
+	}
+	for _, field := range ConfigFields {
+		if value, ok := flags[field]; ok {
+			if err := res.Stored.SetField(field, value); err != nil {
+				return nil, nil, err
+			}
+			res.Sources[field] = fmt.Sprintf("%s --%s", LayerFlag, field)
+			overridden = true
+		}
+	}
+	// 其余的键为连接选项，在数据库类型确定后设置
+	var options []string
+	for name := range flags {
+		if !isConfigField(name) {
+			options = append(options, name)
+		}
+	}
+	sort.Strings(options)
+	for _, name := range options {
+		if err := res.Stored.SetField(name, flags[name]); err != nil {
+			return nil, nil, err
+		}
+		res.Sources[name] = fmt.Sprintf("%s --option %s", LayerFlag, name)
+	}
+	if !found && !overridden {
+		return nil, nil, ErrNoDefaultConfig
+	}
+	if res.Stored.Port == 0 {
+		// 各层都未指定端口时使用数据库类型的默认端口
+		driver, err := db.LookupDriver(res.Stored.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		if driver.DefaultPort > 0 {
+			res.Stored.Port = driver.DefaultPort
+			res.Sources["port"] = LayerDefault
+		}
+	}
+	return res, userFile, nil
+}
+
+// PrintResolution 输出每个字段的值及其来源
+func PrintResolution(w io.Writer, r *Resolution) {
+	if r.Profile != "" {
+		fmt.Fprintf(w, "使用配置: %s\n", r.Profile)
+	}
+	for _, field := range ConfigFields {
+		source := r.Sources[field]
+		if source == "" {
+			source = "未设置"
+		}
+		fmt.Fprintf(w, "  %-9s %-24s %s\n", field, r.DisplayValue(field), source)
+	}
+	for _, name := range r.Stored.OptionNames() {
+		fmt.Fprintf(w, "  %-9s %-24s %s\n", name, r.Stored.Options[name], r.Sources[name])
+	}
+	if r.Stored.ReadOnly {
+		fmt.Fprintf(w, "  %-9s %-24s %s\n", "readonly", "true", r.Sources["readonly"])
+	}
+}
+
+// DisplayValue 返回字段用于显示的值，密码只显示占位符
+func (r *Resolution) DisplayValue(field string) string {
+	value := r.Stored.Field(field)
+	if field != "password" || value == "" || HasPlaceholder(value) {
+		return value
+	}
+	return "********"
+}
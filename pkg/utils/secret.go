@@ -0,0 +1,391 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// MasterPasswordEnv 提供主密码的环境变量，适用于无法交互输入的场景
+	MasterPasswordEnv = "DATAMGR_MASTER_PASSWORD"
+
+	// 加密方式：由主密码派生密钥，或读取密钥文件
+	KDFScrypt  = "scrypt"
+	KDFKeyFile = "keyfile"
+
+	encryptedPrefix = "enc:v1:"
+	keyFileName     = "master.key"
+	keyCheckText    = "datamgr-cli"
+	keySize         = 32
+)
+
+var (
+	// placeholderPattern 配置值中的占位符，如 ${env:DB_PASSWORD}、${file:/run/secrets/db}
+	placeholderPattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+	// cachedKeys 本次运行中已解锁的密钥，按校验值缓存，避免重复输入主密码
+	cachedKeys = make(map[string][]byte)
+)
+
+// Encryption 配置文件中已保存密码的加密信息
+type Encryption struct {
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt,omitempty"`
+	KeyFile string `json:"keyfile,omitempty"`
+	// Check 加密的固定文本，用于校验主密码或密钥文件是否正确
+	Check string `json:"check"`
+}
+
+// IsEncrypted 判断配置值是否为加密后的值
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// HasPlaceholder 判断配置值是否包含 ${env:...} 或 ${file:...} 占位符
+func HasPlaceholder(value string) bool {
+	return placeholderPattern.MatchString(value)
+}
+
+// ExpandPlaceholders 将 ${env:VAR} 替换为环境变量的值，${file:/path} 替换为文件内容（去除末尾换行）
+func ExpandPlaceholders(value string) (string, error) {
+	var expandErr error
+	expanded := placeholderPattern.ReplaceAllStringFunc(value, func(m string) string {
+		parts := placeholderPattern.FindStringSubmatch(m)
+		switch parts[1] {
+		case "env":
+			v, ok := os.LookupEnv(parts[2])
+			if !ok && expandErr == nil {
+				expandErr = fmt.Errorf("环境变量 %s 未设置", parts[2])
+			}
+			return v
+		default:
+			data, err := os.ReadFile(parts[2])
+			if err != nil && expandErr == nil {
+				expandErr = fmt.Errorf("无法读取 %s: %v", parts[2], err)
+			}
+			return strings.TrimRight(string(data), "\r\n")
+		}
+	})
+	return expanded, expandErr
+}
+
+// encryptValue 使用 AES-GCM 加密，结果为 enc:v1:<base64(nonce|密文)>
+func encryptValue(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue 解密 encryptValue 加密的值
+func decryptValue(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", errors.New("加密的值已损坏")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("加密的值已损坏")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("解密失败，主密码或密钥文件不正确")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey 由主密码派生密钥
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, keySize)
+}
+
+// KeyFilePath 返回密钥文件的绝对路径，path 为空时为配置目录下的 master.key
+func KeyFilePath(path string) (string, error) {
+	if path != "" {
+		return filepath.Abs(path)
+	}
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, keyFileName), nil
+}
+
+// readKeyFile 读取密钥文件，文件对其他用户可读时拒绝使用
+func readKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取密钥文件: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("密钥文件 %s 的权限为 %04o，请设置为 0600", path, info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取密钥文件: %v", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("密钥文件 %s 为空", path)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// createKeyFile 生成权限为 0600 的随机密钥文件，文件已存在时直接使用
+func createKeyFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return fmt.Errorf("无法创建密钥文件: %v", err)
+	}
+	return nil
+}
+
+// OpenKeyFile 读取密钥文件并返回派生的密钥，create 为 true 时文件不存在则先生成
+func OpenKeyFile(path string, create bool) ([]byte, error) {
+	if create {
+		if err := createKeyFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return readKeyFile(path)
+}
+
+// readPassphrase 读取主密码，优先使用环境变量，否则在终端提示输入
+func readPassphrase(prompt string) (string, error) {
+	if passphrase, ok := os.LookupEnv(MasterPasswordEnv); ok {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("需要主密码，请设置环境变量 %s", MasterPasswordEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// key 返回解密已保存密码使用的密钥，使用主密码时需要输入
+func (e *Encryption) key() ([]byte, error) {
+	if key, ok := cachedKeys[e.Check]; ok {
+		return key, nil
+	}
+
+	var key []byte
+	switch e.KDF {
+	case KDFKeyFile:
+		k, err := readKeyFile(e.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	case KDFScrypt:
+		salt, err := base64.StdEncoding.DecodeString(e.Salt)
+		if err != nil {
+			return nil, errors.New("配置文件中的加密信息已损坏")
+		}
+		passphrase, err := readPassphrase("主密码: ")
+		if err != nil {
+			return nil, err
+		}
+		if key, err = deriveKey(passphrase, salt); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的加密方式: %s", e.KDF)
+	}
+
+	if _, err := decryptValue(key, e.Check); err != nil {
+		if e.KDF == KDFScrypt {
+			return nil, errors.New("主密码不正确")
+		}
+		return nil, errors.New("密钥文件与加密配置不匹配")
+	}
+	cachedKeys[e.Check] = key
+	return key, nil
+}
+
+// newEncryption 创建加密信息，keyFile 为空时由主密码派生密钥
+func newEncryption(keyFile string) (*Encryption, []byte, error) {
+	enc := &Encryption{}
+	var key []byte
+	if keyFile != "" {
+		if err := createKeyFile(keyFile); err != nil {
+			return nil, nil, err
+		}
+		k, err := readKeyFile(keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc.KDF, enc.KeyFile, key = KDFKeyFile, keyFile, k
+	} else {
+		passphrase, err := readPassphrase("设置主密码: ")
+		if err != nil {
+			return nil, nil, err
+		}
+		if passphrase == "" {
+			return nil, nil, errors.New("主密码不能为空")
+		}
+		if _, fromEnv := os.LookupEnv(MasterPasswordEnv); !fromEnv {
+			confirm, err := readPassphrase("再次输入主密码: ")
+			if err != nil {
+				return nil, nil, err
+			}
+			if confirm != passphrase {
+				return nil, nil, errors.New("两次输入的主密码不一致")
+			}
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, nil, err
+		}
+		k, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return nil, nil, err
+		}
+		enc.KDF, enc.Salt, key = KDFScrypt, base64.StdEncoding.EncodeToString(salt), k
+	}
+
+	check, err := encryptValue(key, keyCheckText)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc.Check = check
+	cachedKeys[check] = key
+	return enc, key, nil
+}
+
+// storedPassword 返回保存到文件时的密码：配置已加密时加密明文密码，占位符原样保存
+func (f *ConfigFile) storedPassword(password string) (string, error) {
+	if f.Encryption == nil || password == "" || IsEncrypted(password) || HasPlaceholder(password) {
+		return password, nil
+	}
+	key, err := f.Encryption.key()
+	if err != nil {
+		return "", err
+	}
+	return encryptValue(key, password)
+}
+
+// resolve 返回用于连接的配置：解密密码并展开占位符
+func (f *ConfigFile) resolve(config *Config) (*Config, error) {
+	resolved := *config
+	if IsEncrypted(resolved.Password) {
+		if f.Encryption == nil {
+			return nil, errors.New("密码已加密，但配置文件中没有加密信息")
+		}
+		key, err := f.Encryption.key()
+		if err != nil {
+			return nil, err
+		}
+		if resolved.Password, err = decryptValue(key, resolved.Password); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range []*string{&resolved.Host, &resolved.User, &resolved.Password, &resolved.DbName} {
+		value, err := ExpandPlaceholders(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &resolved, nil
+}
+
+// LockConfig 加密配置文件中所有明文保存的密码，keyFile 为空时使用主密码
+func LockConfig(keyFile string) (int, error) {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return 0, err
+	}
+	if file.Encryption != nil {
+		return 0, errors.New("配置已加密，如需更换主密码或密钥文件请先执行 config unlock")
+	}
+	enc, key, err := newEncryption(keyFile)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, config := range file.Profiles {
+		if config.Password == "" || IsEncrypted(config.Password) || HasPlaceholder(config.Password) {
+			continue
+		}
+		if config.Password, err = encryptValue(key, config.Password); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	file.Encryption = enc
+	return count, SaveConfigFile(file)
+}
+
+// UnlockConfig 解密配置文件中的密码并以明文保存，不再使用主密码或密钥文件
+func UnlockConfig() (int, error) {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return 0, err
+	}
+	if file.Encryption == nil {
+		return 0, errors.New("配置未加密")
+	}
+	key, err := file.Encryption.key()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, config := range file.Profiles {
+		if !IsEncrypted(config.Password) {
+			continue
+		}
+		if config.Password, err = decryptValue(key, config.Password); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	file.Encryption = nil
+	return count, SaveConfigFile(file)
+}
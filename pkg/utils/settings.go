@@ -0,0 +1,41 @@
+package utils
+
+import "fmt"
+
+// LoadSettings 返回配置文件中保存的全局设置和指定配置的设置，profile 为空或配置不存在时只返回全局设置
+func LoadSettings(profile string) (global, local map[string]string, err error) {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	if config, ok := file.Profiles[profile]; ok && profile != "" {
+		local = config.Settings
+	}
+	return file.Settings, local, nil
+}
+
+// SaveSetting 保存设置，profile 为空时保存为全局设置，value 为空时删除已保存的值
+func SaveSetting(profile, name, value string) error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	target := &file.Settings
+	if profile != "" {
+		config, ok := file.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("配置 %s 不存在", profile)
+		}
+		target = &config.Settings
+	}
+
+	if value == "" {
+		delete(*target, name)
+	} else {
+		if *target == nil {
+			*target = make(map[string]string)
+		}
+		(*target)[name] = value
+	}
+	return SaveConfigFile(file)
+}
@@ -0,0 +1,219 @@
+package audit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+)
+
+// useTempHome 将配置目录指向临时目录，返回审计日志目录
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(audit.KeyFileEnv, "")
+	return filepath.Join(home, ".datamgr-cli", "audit")
+}
+
+// appendEntries 追加语句分别为 statements 的记录，时间从 start 起每条间隔一小时
+func appendEntries(t *testing.T, start time.Time, statements ...string) {
+	t.Helper()
+	for i, stmt := range statements {
+		entry := &audit.Entry{Time: start.Add(time.Duration(i) * time.Hour), User: "alice", Statement: stmt, Rows: int64(i)}
+		if err := audit.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMaskStatement(t *testing.T) {
+	cases := map[string]string{
+		"select * from users where name = 'bob' and id = 42": "select * from users where name = ? and id = ?",
+		"update t set secret = 'p''w' where id = :id":        "update t set secret = ? where id = :id",
+		"  delete from t  ": "delete from t",
+	}
+	for sql, want := range cases {
+		if got := audit.MaskStatement(sql); got != want {
+			t.Errorf("MaskStatement(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestLogAndLoad(t *testing.T) {
+	dir := useTempHome(t)
+	audit.SetProfile("prod")
+	defer audit.SetProfile("")
+
+	start := time.Now().Add(-time.Second)
+	if err := audit.Log("delete from t where id = 7", start, 3, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("audit log permissions = %o, want 600", perm)
+	}
+
+	entries, err := audit.Load(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Statement != "delete from t where id = ?" || e.Rows != 3 || e.Error != "boom" || e.Profile != "prod" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e.DurationMs < 1000 {
+		t.Errorf("DurationMs = %v, want at least 1000", e.DurationMs)
+	}
+}
+
+func TestLoadSince(t *testing.T) {
+	useTempHome(t)
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	appendEntries(t, start, "select 1", "select 2", "select 3")
+
+	entries, err := audit.Load(start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Statement != "select 2" || entries[1].Statement != "select 3" {
+		t.Errorf("unexpected entries since 09:00: %+v", entries)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := useTempHome(t)
+	appendEntries(t, time.Now(), "select 1", "select 2", "select 3")
+
+	count, err := audit.Verify()
+	if err != nil || count != 3 {
+		t.Fatalf("Verify() = %d, %v, want 3, nil", count, err)
+	}
+
+	file := filepath.Join(dir, "audit.jsonl")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	// 修改一条记录
+	edited := strings.Replace(string(data), `"rows":1`, `"rows":0`, 1)
+	if err := os.WriteFile(file, []byte(edited), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Verify() after editing = %v, want ErrTampered", err)
+	}
+
+	// 删除中间的记录
+	removed := lines[0] + lines[2]
+	if err := os.WriteFile(file, []byte(removed), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Verify() after removing = %v, want ErrTampered", err)
+	}
+}
+
+func TestVerifyTruncated(t *testing.T) {
+	dir := useTempHome(t)
+	appendEntries(t, time.Now(), "select 1", "select 2", "select 3")
+	file := filepath.Join(dir, "audit.jsonl")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	// 删除末尾的记录，剩下的记录本身的哈希链仍然完整
+	for _, kept := range []string{lines[0] + lines[1], lines[1] + lines[2], ""} {
+		if err := os.WriteFile(file, []byte(kept), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+			t.Errorf("Verify() with %d of 3 lines = %v, want ErrTampered", strings.Count(kept, "\n"), err)
+		}
+	}
+
+	// 删除头记录
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(); err != nil {
+		t.Fatalf("Verify() after restoring = %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "head.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Verify() without head = %v, want ErrTampered", err)
+	}
+}
+
+func TestVerifyRewritten(t *testing.T) {
+	dir := useTempHome(t)
+	appendEntries(t, time.Now(), "delete from orders", "select 2")
+
+	// 没有密钥的人删除整个审计日志后用自己的密钥写入新的记录
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(audit.KeyFileEnv, filepath.Join(t.TempDir(), "forged.key"))
+	appendEntries(t, time.Now(), "select 1", "select 2")
+	if count, err := audit.Verify(); err != nil || count != 2 {
+		t.Fatalf("Verify() with the forged key = %d, %v", count, err)
+	}
+
+	t.Setenv(audit.KeyFileEnv, "")
+	if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Verify() of a rewritten log = %v, want ErrTampered", err)
+	}
+	if err := audit.Append(&audit.Entry{Time: time.Now(), Statement: "select 3"}); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Append() to a rewritten log = %v, want ErrTampered", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := useTempHome(t)
+	// 每条记录约 1MB，写入超过 MaxFileSize 的内容后当前文件轮转
+	large := strings.Repeat("x", 1<<20)
+	n := audit.MaxFileSize/(1<<20) + 2
+	var statements []string
+	for i := 0; i < n; i++ {
+		statements = append(statements, large)
+	}
+	appendEntries(t, time.Now(), statements...)
+
+	rotated, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 {
+		t.Fatalf("got %d rotated files, want 1", len(rotated))
+	}
+	// 哈希链跨越轮转的文件
+	count, err := audit.Verify()
+	if err != nil || count != n {
+		t.Errorf("Verify() = %d, %v, want %d, nil", count, err, n)
+	}
+
+	// 删除轮转的文件
+	if err := os.Remove(rotated[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("Verify() without the rotated file = %v, want ErrTampered", err)
+	}
+}
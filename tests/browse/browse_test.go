@@ -0,0 +1,279 @@
+package browse_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/browse"
+)
+
+// fakeConnection 返回固定数据的连接
+type fakeConnection struct {
+	db.Connection
+	columns []string
+	keys    []string
+	rows    []map[string]interface{}
+
+	query string
+}
+
+func (c *fakeConnection) Query(query string) ([]map[string]interface{}, error) {
+	c.query = query
+	return c.rows, nil
+}
+
+func (c *fakeConnection) GetTableColumns(string) ([]string, error) {
+	return c.columns, nil
+}
+
+func (c *fakeConnection) DescribeTable(string) ([]map[string]interface{}, error) {
+	var info []map[string]interface{}
+	for _, column := range c.columns {
+		constraint := ""
+		for _, key := range c.keys {
+			if key == column {
+				constraint = "PRIMARY KEY"
+			}
+		}
+		info = append(info, map[string]interface{}{"COLUMN_NAME": column, "CONSTRAINT_TYPE": constraint})
+	}
+	return info, nil
+}
+
+// recorder 记录保存的编辑，err 不为空时保存失败
+type recorder struct {
+	updates []*browse.Update
+	err     error
+}
+
+func (r *recorder) write(u *browse.Update) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.updates = append(r.updates, u)
+	return nil
+}
+
+// last 返回最近保存的编辑
+func (r *recorder) last() *browse.Update {
+	if len(r.updates) == 0 {
+		return &browse.Update{}
+	}
+	return r.updates[len(r.updates)-1]
+}
+
+// newOrders 返回有 id 主键和 name、amount 两列的连接
+func newOrders() *fakeConnection {
+	return &fakeConnection{
+		columns: []string{"id", "name", "amount"},
+		keys:    []string{"id"},
+		rows: []map[string]interface{}{
+			{"id": int64(1), "name": "Alice", "amount": "10"},
+			{"id": int64(2), "name": "bob", "amount": nil},
+			{"id": int64(3), "name": "Carol", "amount": "9"},
+			{"id": int64(4), "name": "alex", "amount": "9.5"},
+		},
+	}
+}
+
+func TestOpenQuery(t *testing.T) {
+	tests := []struct {
+		dbType string
+		keys   []string
+		want   string
+	}{
+		{"mysql", []string{"id"}, "SELECT * FROM orders ORDER BY id LIMIT 4"},
+		{"postgresql", []string{"id", "name"}, "SELECT * FROM orders ORDER BY id, name LIMIT 4"},
+		{"dameng", nil, "SELECT * FROM orders LIMIT 4"},
+		{"oracle", []string{"id"}, "SELECT * FROM (SELECT * FROM orders ORDER BY id) WHERE ROWNUM <= 4"},
+		{"mssql", []string{"id"}, "SELECT * FROM orders ORDER BY id OFFSET 0 ROWS FETCH NEXT 4 ROWS ONLY"},
+		{"mssql", nil, "SELECT * FROM orders ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 4 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		conn := newOrders()
+		conn.keys = tt.keys
+		b, err := browse.Open(conn, tt.dbType, "orders", 3, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.query != tt.want {
+			t.Errorf("%s 的查询 = %q, want %q", tt.dbType, conn.query, tt.want)
+		}
+		// 多读的一行表示表中还有更多数据
+		if rows := b.Rows(); len(rows) != 3 || !b.Truncated() {
+			t.Errorf("%s: 加载 %d 行, truncated = %v, want 3 行且截断", tt.dbType, len(rows), b.Truncated())
+		}
+	}
+
+	b, err := browse.Open(newOrders(), "mysql", "orders", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Rows()) != 4 || b.Truncated() {
+		t.Errorf("limit 为 0 时应加载全部 4 行且不截断, 得到 %d 行, truncated = %v", len(b.Rows()), b.Truncated())
+	}
+}
+
+func TestUpdateCell(t *testing.T) {
+	conn := newOrders()
+	conn.keys = []string{"id", "name"}
+	rec := &recorder{}
+	b, err := browse.Open(conn, "postgresql", "orders", 10, rec.write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(2, 2, "12.5"); err != nil {
+		t.Fatal(err)
+	}
+	want := &browse.Update{
+		Table: "orders", Column: "amount", Keys: []string{"id", "name"},
+		SQL: "UPDATE orders SET amount = $1 WHERE id = $2 AND name = $3", Args: []interface{}{"12.5", int64(3), "Carol"},
+		// 查询修改前的行和确认行存在时使用的条件，占位符从 1 开始编号
+		Where: "id = $1 AND name = $2", WhereArgs: []interface{}{int64(3), "Carol"},
+	}
+	if got := rec.last(); !reflect.DeepEqual(got, want) {
+		t.Errorf("编辑 = %+v, want %+v", got, want)
+	}
+	if got := b.Rows()[2][2]; got != "12.5" {
+		t.Errorf("保存后单元格 = %v, want 12.5", got)
+	}
+
+	// \N 表示 NULL
+	if err := b.UpdateCell(0, 2, `\N`); err != nil {
+		t.Fatal(err)
+	}
+	if rec.last().Args[0] != nil || b.Rows()[0][2] != nil {
+		t.Errorf("输入 \\N 应保存为 NULL, 参数 %v, 单元格 %v", rec.last().Args[0], b.Rows()[0][2])
+	}
+
+	// 问号占位符
+	rec = &recorder{}
+	b, err = browse.Open(newOrders(), "mysql", "orders", 10, rec.write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(1, 1, "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.last(); got.SQL != "UPDATE orders SET name = ? WHERE id = ?" || got.Where != "id = ?" {
+		t.Errorf("UPDATE = %q, 条件 = %q", got.SQL, got.Where)
+	}
+}
+
+func TestUpdateCellRefused(t *testing.T) {
+	rec := &recorder{}
+	b, err := browse.Open(newOrders(), "mysql", "orders", 10, rec.write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(0, 0, "9"); err == nil || !strings.Contains(err.Error(), "主键") {
+		t.Errorf("修改主键列应返回错误, 得到 %v", err)
+	}
+
+	rec.err = errors.New("按主键找到 0 行")
+	if err := b.UpdateCell(0, 1, "Alicia"); err == nil {
+		t.Error("保存失败时应返回错误")
+	}
+	if got := b.Rows()[0][1]; got != "Alice" {
+		t.Errorf("保存失败后单元格 = %v, want Alice", got)
+	}
+
+	// 主键值为 NULL 时无法定位行
+	conn := newOrders()
+	conn.rows[1]["id"] = nil
+	rec = &recorder{}
+	b, err = browse.Open(conn, "mysql", "orders", 10, rec.write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(1, 1, "Bob"); err == nil || len(rec.updates) != 0 {
+		t.Errorf("主键为 NULL 时应在保存前返回错误, 得到 %v, 保存了 %d 次", err, len(rec.updates))
+	}
+
+	// 没有主键的表不能编辑
+	conn = newOrders()
+	conn.keys = nil
+	b, err = browse.Open(conn, "mysql", "orders", 10, rec.write)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(0, 1, "Alicia"); err == nil || len(rec.updates) != 0 {
+		t.Errorf("没有主键时应返回错误, 得到 %v, 保存了 %d 次", err, len(rec.updates))
+	}
+
+	// 没有保存函数时不能编辑
+	b, err = browse.Open(newOrders(), "mysql", "orders", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UpdateCell(0, 1, "Alicia"); err == nil {
+		t.Error("没有保存函数时应返回错误")
+	}
+}
+
+// ids 返回可见行的 id 列
+func ids(b *browse.Browser) []int64 {
+	var list []int64
+	for _, row := range b.Rows() {
+		list = append(list, row[0].(int64))
+	}
+	return list
+}
+
+func TestSortAndFilter(t *testing.T) {
+	b, err := browse.Open(newOrders(), "mysql", "orders", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NULL 最小，数字按数值比较
+	b.SetSort(2, false)
+	if got, want := ids(b), []int64{2, 3, 4, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("按 amount 升序 = %v, want %v", got, want)
+	}
+	b.SetSort(2, true)
+	if got, want := ids(b), []int64{1, 4, 3, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("按 amount 降序 = %v, want %v", got, want)
+	}
+
+	// 过滤不区分大小写，与排序同时生效
+	b.SetFilter(1, "AL")
+	if got, want := ids(b), []int64{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("过滤 name ~ AL = %v, want %v", got, want)
+	}
+	b.SetFilter(2, "null")
+	if got, want := ids(b), []int64{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NULL 按文本 NULL 过滤 = %v, want %v", got, want)
+	}
+
+	b.SetFilter(1, "")
+	b.SetSort(-1, false)
+	if got, want := ids(b), []int64{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("清除过滤和排序后 = %v, want %v", got, want)
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want int
+	}{
+		{nil, nil, 0},
+		{nil, "a", -1},
+		{int64(0), nil, 1},
+		{"9", "10", -1},
+		{int64(10), 9.5, 1},
+		{"1e3", "999", 1},
+		{"b", "a", 1},
+		{"10", "abc", -1},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		if got := browse.CompareValues(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareValues(%v, %v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package completion_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/completion"
+)
+
+// fakeCatalog 测试用的元数据
+type fakeCatalog map[string][]string
+
+func (c fakeCatalog) Tables() []string {
+	var tables []string
+	for table := range c {
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+func (c fakeCatalog) Columns(table string) []string {
+	for name, columns := range c {
+		if strings.EqualFold(name, table) {
+			return columns
+		}
+	}
+	return nil
+}
+
+var catalog = fakeCatalog{
+	"users":  {"id", "name", "email"},
+	"orders": {"id", "user_id", "amount"},
+}
+
+// texts 返回候选的文本，用于比较
+func texts(suggestions []completion.Suggestion) []string {
+	var result []string
+	for _, s := range suggestions {
+		result = append(result, s.Text)
+	}
+	return result
+}
+
+func contains(list []string, want string) bool {
+	for _, item := range list {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTableContexts(t *testing.T) {
+	cases := []string{
+		"select * from ",
+		"select * from users u join ",
+		"select * from users, ",
+		"insert into ",
+		"update ",
+		"delete from ",
+		"import ",
+		"export ",
+		"desc table ",
+		"browse ",
+	}
+	for _, before := range cases {
+		got := texts(completion.Complete(catalog, "mysql", before, ""))
+		if !contains(got, "users") || !contains(got, "orders") || contains(got, "SELECT") {
+			t.Errorf("Complete(%q) = %v, want table names", before, got)
+		}
+	}
+
+	got := texts(completion.Complete(catalog, "mysql", "select * from us", ""))
+	if len(got) != 1 || got[0] != "users" {
+		t.Errorf("expected prefix filtered tables, got %v", got)
+	}
+}
+
+func TestColumnContexts(t *testing.T) {
+	cases := []struct {
+		before, after string
+	}{
+		{"select ", " from users"},
+		{"select * from users where ", ""},
+		{"select * from users where id = 1 and ", ""},
+		{"update users set ", ""},
+		{"select * from users order by ", ""},
+		{"select id, ", " from users"},
+		{"insert into users (", ""},
+	}
+	for _, c := range cases {
+		got := texts(completion.Complete(catalog, "mysql", c.before, c.after))
+		if !contains(got, "email") || contains(got, "amount") {
+			t.Errorf("Complete(%q, %q) = %v, want users columns", c.before, c.after, got)
+		}
+	}
+}
+
+func TestAliasResolution(t *testing.T) {
+	before := "select * from users u join orders as o on u.id = o."
+	got := texts(completion.Complete(catalog, "postgresql", before, ""))
+	if !contains(got, "o.amount") || contains(got, "o.email") {
+		t.Errorf("expected orders columns qualified by alias, got %v", got)
+	}
+
+	got = texts(completion.Complete(catalog, "postgresql", "select u.na", " from users u"))
+	if len(got) != 1 || got[0] != "u.name" {
+		t.Errorf("expected u.name, got %v", got)
+	}
+}
+
+func TestKeywordsPerDialect(t *testing.T) {
+	got := texts(completion.Complete(catalog, "mssql", "select * from users ", ""))
+	if !contains(got, "WHERE") || !contains(got, "TOP") || contains(got, "users") {
+		t.Errorf("unexpected keyword suggestions: %v", got)
+	}
+	got = texts(completion.Complete(catalog, "mysql", "select * from users lim", ""))
+	if len(got) != 1 || got[0] != "limit" {
+		t.Errorf("expected lower case limit, got %v", got)
+	}
+	got = texts(completion.Complete(catalog, "oracle", "select nv", ""))
+	if !contains(got, "nvl(") || contains(got, "ifnull(") {
+		t.Errorf("expected oracle functions, got %v", got)
+	}
+}
+
+func TestNoSuggestionsInsideString(t *testing.T) {
+	if got := completion.Complete(catalog, "mysql", "select * from users where name = 'fr", ""); len(got) != 0 {
+		t.Errorf("expected no suggestions inside string literal, got %v", texts(got))
+	}
+}
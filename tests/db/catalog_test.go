@@ -0,0 +1,25 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+func TestCatalogQuery(t *testing.T) {
+	kinds := []string{db.CatalogIndexes, db.CatalogViews, db.CatalogSchemas, db.CatalogDatabases}
+	for _, dbType := range []string{"mysql", "postgresql", "mssql", "oracle", "dameng"} {
+		for _, kind := range kinds {
+			if query, err := db.CatalogQuery(dbType, kind); err != nil || query == "" {
+				t.Errorf("CatalogQuery(%q, %q) 返回 %q, %v", dbType, kind, query, err)
+			}
+		}
+	}
+
+	if _, err := db.CatalogQuery("unknown", db.CatalogViews); err == nil {
+		t.Error("不支持的数据库类型应返回错误")
+	}
+	if _, err := db.CatalogQuery("mysql", "sequences"); err == nil {
+		t.Error("不支持的目录类型应返回错误")
+	}
+}
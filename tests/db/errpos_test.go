@@ -0,0 +1,48 @@
+package db_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+func TestErrorPositionPostgres(t *testing.T) {
+	query := "select 名称 fromm users"
+	err := fmt.Errorf("查询失败: %w", &pq.Error{Message: `syntax error at or near "users"`, Position: "17"})
+	pos, ok := db.ErrorPosition(err, query)
+	if !ok || query[pos:] != "users" {
+		t.Errorf("ErrorPosition = %d, %v; want offset of users", pos, ok)
+	}
+}
+
+func TestErrorPositionMySQL(t *testing.T) {
+	query := "select *\nfrom users\nwher id = 1"
+	err := errors.New("Error 1064 (42000): You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near 'id = 1' at line 3")
+	pos, ok := db.ErrorPosition(err, query)
+	if !ok || query[pos:] != "id = 1" {
+		t.Errorf("ErrorPosition = %d, %v; want offset of id = 1", pos, ok)
+	}
+
+	err = errors.New("Error 1064 (42000): You have an error in your SQL syntax; check the manual for the right syntax to use near '' at line 1")
+	if pos, ok := db.ErrorPosition(err, "select * from;"); !ok || pos != len("select * from") {
+		t.Errorf("expected end of statement, got %d, %v", pos, ok)
+	}
+}
+
+func TestErrorPositionOracle(t *testing.T) {
+	query := "select nme from emp"
+	err := errors.New("ORA-00904: \"NME\": invalid identifier error occur at position: 7")
+	pos, ok := db.ErrorPosition(err, query)
+	if !ok || pos != 7 {
+		t.Errorf("ErrorPosition = %d, %v; want 7", pos, ok)
+	}
+}
+
+func TestErrorPositionUnknown(t *testing.T) {
+	if _, ok := db.ErrorPosition(errors.New("connection refused"), "select 1"); ok {
+		t.Error("expected no position for errors without position information")
+	}
+}
@@ -0,0 +1,111 @@
+package db_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+func TestBuiltinDrivers(t *testing.T) {
+	ports := map[string]int{"dameng": 5236, "mysql": 3306, "postgresql": 5432, "oracle": 1521, "mssql": 1433}
+	for name, port := range ports {
+		driver, err := db.LookupDriver(name)
+		if err != nil {
+			t.Fatalf("LookupDriver(%q): %v", name, err)
+		}
+		if driver.DefaultPort != port {
+			t.Errorf("%s 默认端口为 %d，应为 %d", name, driver.DefaultPort, port)
+		}
+		if !driver.IsRequired(db.FieldHost) || driver.IsRequired(db.FieldPassword) {
+			t.Errorf("%s 必填参数不正确: %v", name, driver.Required)
+		}
+	}
+	if names := db.DriverNames(); len(names) == 0 || names[0] != db.DefaultDriver {
+		t.Errorf("DriverNames() = %v，默认驱动应在最前", names)
+	}
+	if _, err := db.LookupDriver("unknown"); err == nil {
+		t.Error("未注册的数据库类型应返回错误")
+	}
+}
+
+func TestDriverValidate(t *testing.T) {
+	driver, _ := db.LookupDriver("postgresql")
+
+	err := driver.Validate(&db.DbConfig{Type: "postgresql", Host: "pg"})
+	if err == nil {
+		t.Fatal("缺少用户名和数据库名时应返回错误")
+	}
+
+	config := &db.DbConfig{Type: "postgresql", Host: "pg", User: "app", DbName: "app"}
+	if err := driver.Validate(config); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if got := config.Option("sslmode"); got != "disable" {
+		t.Errorf("sslmode 默认值为 %q，应为 disable", got)
+	}
+
+	config.Options = map[string]string{"sslmode": "require"}
+	if err := driver.Validate(config); err != nil || config.Option("sslmode") != "require" {
+		t.Errorf("sslmode=require: %v", err)
+	}
+	config.Options = map[string]string{"sslmode": "sometimes"}
+	if err := driver.Validate(config); err == nil {
+		t.Error("无效的选项值应返回错误")
+	}
+	config.Options = map[string]string{"charset": "utf8"}
+	if err := driver.Validate(config); err == nil {
+		t.Error("驱动不支持的选项应返回错误")
+	}
+}
+
+// fakeConnection 第三方驱动测试使用的连接，Connect 直接返回错误
+type fakeConnection struct {
+	db.Connection
+}
+
+var errFakeConnect = errors.New("fake connect")
+
+func (fakeConnection) Connect() error { return errFakeConnect }
+
+func TestRegisterDriver(t *testing.T) {
+	var got *db.DbConfig
+	db.Register(&db.Driver{
+		Name:        "fakedb",
+		Title:       "Fake",
+		DefaultPort: 7777,
+		Required:    []string{db.FieldDbName},
+		Catalog:     map[string]string{db.CatalogViews: "SELECT 1"},
+		Placeholder: func(n int) string { return "$x" },
+		New: func(config *db.DbConfig) (db.Connection, error) {
+			got = config
+			return fakeConnection{}, nil
+		},
+	})
+
+	if err := db.Connect("fakedb", "", 0, "", "", ""); err == nil || errors.Is(err, errFakeConnect) {
+		t.Errorf("缺少数据库名时应在连接前返回错误，得到 %v", err)
+	}
+	if err := db.Connect("fakedb", "", 0, "", "", "data.db"); !errors.Is(err, errFakeConnect) {
+		t.Errorf("Connect = %v，应调用第三方驱动", err)
+	}
+	if got == nil || got.Port != 7777 {
+		t.Errorf("未指定端口时应使用驱动的默认端口: %+v", got)
+	}
+	if p := db.Placeholder("fakedb", 1); p != "$x" {
+		t.Errorf("Placeholder = %q", p)
+	}
+	if q := db.LimitQuery("fakedb", "SELECT * FROM t", 10); q != "SELECT * FROM t LIMIT 10" {
+		t.Errorf("LimitQuery = %q", q)
+	}
+	if q, err := db.CatalogQuery("fakedb", db.CatalogViews); err != nil || q != "SELECT 1" {
+		t.Errorf("CatalogQuery = %q, %v", q, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("重复注册应 panic")
+		}
+	}()
+	db.Register(&db.Driver{Name: "fakedb", New: func(*db.DbConfig) (db.Connection, error) { return nil, nil }})
+}
@@ -0,0 +1,170 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+)
+
+// fakeTable database/sql 测试驱动返回的结果集
+type fakeTable struct {
+	columns []string
+	types   []string // 各列的数据库类型名
+	rows    [][]driver.Value
+	err     error // 读完所有行后返回的错误
+}
+
+// fakeTables 按数据源名称查找结果集
+var fakeTables = map[string]*fakeTable{}
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return fakeSQLConn{table: fakeTables[name]}, nil
+}
+
+type fakeSQLConn struct{ table *fakeTable }
+
+func (c fakeSQLConn) Prepare(string) (driver.Stmt, error) { return fakeSQLStmt(c), nil }
+func (fakeSQLConn) Close() error                          { return nil }
+func (fakeSQLConn) Begin() (driver.Tx, error)             { return nil, errors.New("不支持事务") }
+
+type fakeSQLStmt struct{ table *fakeTable }
+
+func (fakeSQLStmt) Close() error  { return nil }
+func (fakeSQLStmt) NumInput() int { return -1 }
+func (fakeSQLStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("不支持执行")
+}
+func (s fakeSQLStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeSQLRows{table: s.table}, nil
+}
+
+type fakeSQLRows struct {
+	table *fakeTable
+	next  int
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.table.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+func (r *fakeSQLRows) ColumnTypeDatabaseTypeName(i int) string {
+	return r.table.types[i]
+}
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.next == len(r.table.rows) {
+		if r.table.err != nil {
+			return r.table.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.table.rows[r.next])
+	r.next++
+	return nil
+}
+
+func init() {
+	sql.Register("streamfake", fakeSQLDriver{})
+}
+
+// openIterator 以 name 注册结果集并返回读取它的行迭代器
+func openIterator(t *testing.T, name string, table *fakeTable) *db.RowIterator {
+	t.Helper()
+	fakeTables[name] = table
+	conn, err := sql.Open("streamfake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	rows, err := conn.QueryContext(context.Background(), "SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	it, err := db.NewRowIterator(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { it.Close() })
+	return it
+}
+
+func TestRowIterator(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 30, 0, 123000000, time.FixedZone("CST", 8*3600))
+	it := openIterator(t, "rows", &fakeTable{
+		columns: []string{"id", "name", "created", "note"},
+		types:   []string{"INT", "VARCHAR", "TIMESTAMP", "TEXT"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("张三"), created, nil},
+			{int64(2), []byte("bob"), created, []byte("")},
+		},
+	})
+
+	if got := it.Columns(); !reflect.DeepEqual(got, []string{"id", "name", "created", "note"}) {
+		t.Errorf("Columns() = %v", got)
+	}
+	want := []map[string]interface{}{
+		{"id": int64(1), "name": "张三", "created": created, "note": nil},
+		{"id": int64(2), "name": "bob", "created": created, "note": ""},
+	}
+	for i, w := range want {
+		row, ok, err := it.Next()
+		if err != nil || !ok {
+			t.Fatalf("第 %d 行: ok = %v, err = %v", i+1, ok, err)
+		}
+		if !reflect.DeepEqual(row, w) {
+			t.Errorf("第 %d 行 = %#v, want %#v", i+1, row, w)
+		}
+	}
+	if row, ok, err := it.Next(); ok || err != nil || row != nil {
+		t.Errorf("读完后 Next() = %v, %v, %v", row, ok, err)
+	}
+}
+
+func TestRowIteratorBinary(t *testing.T) {
+	// 按列类型而不是内容判断是否为二进制数据
+	types := []string{"BLOB", "bytea", "VARBINARY", "LONG RAW", "TEXT", "VARCHAR", "JSON", ""}
+	var columns []string
+	var values []driver.Value
+	for i := range types {
+		columns = append(columns, fmt.Sprintf("c%d", i))
+		values = append(values, []byte("abc"))
+	}
+	it := openIterator(t, "binary", &fakeTable{columns: columns, types: types, rows: [][]driver.Value{values}})
+
+	row, ok, err := it.Next()
+	if err != nil || !ok {
+		t.Fatalf("Next() = %v, %v", ok, err)
+	}
+	for i, typ := range types {
+		var want interface{} = "abc"
+		if i < 4 {
+			want = []byte("abc")
+		}
+		if got := row[columns[i]]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q 列的值 = %#v, want %#v", typ, got, want)
+		}
+	}
+}
+
+func TestRowIteratorError(t *testing.T) {
+	errBroken := errors.New("连接中断")
+	it := openIterator(t, "broken", &fakeTable{
+		columns: []string{"id"},
+		types:   []string{"INT"},
+		rows:    [][]driver.Value{{int64(1)}},
+		err:     errBroken,
+	})
+	if _, ok, err := it.Next(); !ok || err != nil {
+		t.Fatalf("第 1 行: ok = %v, err = %v", ok, err)
+	}
+	if _, ok, err := it.Next(); ok || !errors.Is(err, errBroken) {
+		t.Errorf("读取出错时 Next() = %v, %v, want 连接中断", ok, err)
+	}
+}
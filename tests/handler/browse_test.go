@@ -0,0 +1,167 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/browse"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/undo"
+)
+
+// txState 事务测试驱动的状态：按主键找到的行数、UPDATE 报告的影响行数和执行记录
+type txState struct {
+	count    int64
+	affected int64
+	executed []string
+	commits  int
+}
+
+var txFake = &txState{}
+
+type txDriver struct{}
+
+func (txDriver) Open(string) (driver.Conn, error) { return txConn{}, nil }
+
+type txConn struct{}
+
+func (txConn) Prepare(query string) (driver.Stmt, error) { return txStmt(query), nil }
+func (txConn) Close() error                              { return nil }
+func (txConn) Begin() (driver.Tx, error)                 { return txConn{}, nil }
+func (txConn) Commit() error {
+	txFake.commits++
+	return nil
+}
+func (txConn) Rollback() error { return nil }
+
+type txStmt string
+
+func (txStmt) Close() error  { return nil }
+func (txStmt) NumInput() int { return -1 }
+func (s txStmt) Exec([]driver.Value) (driver.Result, error) {
+	txFake.executed = append(txFake.executed, string(s))
+	return driver.RowsAffected(txFake.affected), nil
+}
+func (s txStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.HasPrefix(string(s), "SELECT COUNT(*)") {
+		return &txRows{columns: []string{"n"}, rows: [][]driver.Value{{txFake.count}}}, nil
+	}
+	return &txRows{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "Alice"}}}, nil
+}
+
+type txRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *txRows) Columns() []string { return r.columns }
+func (r *txRows) Close() error      { return nil }
+func (r *txRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// txConnection 通过 database/sql 开始事务的连接
+type txConnection struct {
+	db.Connection
+	sqlDB *sql.DB
+}
+
+func (txConnection) Connect() error    { return nil }
+func (txConnection) Disconnect() error { return nil }
+func (c txConnection) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return c.sqlDB.BeginTx(ctx, nil)
+}
+
+func init() {
+	sql.Register("handlertxfake", txDriver{})
+}
+
+func TestBrowseWriter(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(audit.KeyFileEnv, "")
+
+	sqlDB, err := sql.Open("handlertxfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	conn := txConnection{sqlDB: sqlDB}
+	db.Register(&db.Driver{
+		Name: "browsetxfake",
+		New:  func(*db.DbConfig) (db.Connection, error) { return conn, nil },
+	})
+	connect := func(readOnly bool) {
+		t.Helper()
+		if err := db.ConnectConfig(&db.DbConfig{Type: "browsetxfake", ReadOnly: readOnly}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connect(false)
+	defer db.Disconnect()
+	if err := handler.SetSetting(handler.ScopeSession, "undo", "on"); err != nil {
+		t.Fatal(err)
+	}
+	defer handler.SetSetting(handler.ScopeSession, "undo", "off")
+
+	write := handler.BrowseWriter(conn)
+	update := func() *browse.Update {
+		return &browse.Update{
+			Table: "users", Column: "name", Keys: []string{"id"},
+			SQL: "UPDATE users SET name = ? WHERE id = ?", Args: []interface{}{"Bob", int64(1)},
+			Where: "id = ?", WhereArgs: []interface{}{int64(1)},
+		}
+	}
+
+	// MySQL 在值未改变时报告修改了 0 行，行存在时仍然保存
+	*txFake = txState{count: 1, affected: 0}
+	if err := write(update()); err != nil {
+		t.Fatal(err)
+	}
+	if txFake.commits != 1 || len(txFake.executed) != 1 {
+		t.Errorf("提交 %d 次, 执行 %q", txFake.commits, txFake.executed)
+	}
+	entries, err := undo.Load()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("撤销日志 = %v, %v", entries, err)
+	}
+	if e := entries[0]; !reflect.DeepEqual(e.SetColumns, []string{"name"}) || len(e.Rows) != 1 || e.Statement != "UPDATE users SET name = ? WHERE id = ?" {
+		t.Errorf("撤销记录 = %+v", e)
+	}
+
+	tests := []struct {
+		name            string
+		count, affected int64
+	}{
+		{"行不存在", 0, 0},
+		{"修改了多行", 1, 2},
+	}
+	for _, tt := range tests {
+		*txFake = txState{count: tt.count, affected: tt.affected}
+		if err := write(update()); err == nil || txFake.commits != 0 {
+			t.Errorf("%s: err = %v, 提交 %d 次, want 错误且不提交", tt.name, err, txFake.commits)
+		}
+	}
+
+	// 只读连接在执行前拒绝
+	db.Disconnect()
+	connect(true)
+	*txFake = txState{count: 1, affected: 1}
+	if err := write(update()); !errors.Is(err, handler.ErrReadOnly) || len(txFake.executed) != 0 {
+		t.Errorf("只读连接: err = %v, 执行 %q", err, txFake.executed)
+	}
+}
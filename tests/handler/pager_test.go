@@ -0,0 +1,186 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestSetMaxRows(t *testing.T) {
+	defer handler.SetMaxRows(handler.DefaultMaxRows)
+
+	if err := handler.SetMaxRows(-1); err == nil {
+		t.Error("SetMaxRows(-1) 应返回错误")
+	}
+	if handler.MaxRows() != handler.DefaultMaxRows {
+		t.Errorf("设置失败后 MaxRows() = %d", handler.MaxRows())
+	}
+	for _, n := range []int{0, 50} {
+		if err := handler.SetMaxRows(n); err != nil || handler.MaxRows() != n {
+			t.Errorf("SetMaxRows(%d) = %v, MaxRows() = %d", n, err, handler.MaxRows())
+		}
+	}
+}
+
+func TestPagerCommand(t *testing.T) {
+	t.Setenv("PAGER", "  most -s ")
+	if got := handler.PagerCommand(); !reflect.DeepEqual(got, []string{"most", "-s"}) {
+		t.Errorf("PAGER 设置时 PagerCommand() = %q", got)
+	}
+
+	t.Setenv("PAGER", "")
+	if got := handler.PagerCommand(); len(got) == 0 || got[0] != "less" && got[0] != "more" {
+		t.Errorf("PAGER 为空时 PagerCommand() = %q, want less 或 more", got)
+	}
+}
+
+func TestShouldPage(t *testing.T) {
+	defer handler.SetPagerMode(handler.PagerAuto)
+	defer handler.SetTerminalSize(nil)
+
+	short, tall := "a\nb\n", "a\nb\nc\nd\n"
+	tests := []struct {
+		mode     string
+		terminal bool
+		text     string
+		want     bool
+	}{
+		{handler.PagerOff, true, tall, false},
+		{handler.PagerOn, true, short, true},
+		{handler.PagerOn, false, tall, false},
+		// 终端高 5 行，预留一行给提示符
+		{handler.PagerAuto, true, short, false},
+		{handler.PagerAuto, true, "a\nb\nc\n", false},
+		{handler.PagerAuto, true, tall, true},
+		{handler.PagerAuto, false, tall, false},
+	}
+	for _, tt := range tests {
+		terminal := tt.terminal
+		handler.SetTerminalSize(func() (int, int, bool) { return 80, 5, terminal })
+		if err := handler.SetPagerMode(tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := handler.ShouldPage(tt.text); got != tt.want {
+			t.Errorf("模式 %s, 终端 %v, %d 行: ShouldPage() = %v, want %v", tt.mode, tt.terminal, strings.Count(tt.text, "\n"), got, tt.want)
+		}
+	}
+
+	if err := handler.SetPagerMode("always"); err == nil {
+		t.Error("SetPagerMode(always) 应返回错误")
+	}
+}
+
+// fakeRows database/sql 测试驱动返回的结果集，只有一个 n 列
+type fakeRows struct {
+	n, count int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.n == r.count {
+		return io.EOF
+	}
+	r.n++
+	dest[0] = int64(r.n)
+	return nil
+}
+
+// countingDriver 数据源名称为返回的行数
+type countingDriver struct{}
+
+func (countingDriver) Open(name string) (driver.Conn, error) {
+	var count int
+	if _, err := fmt.Sscan(name, &count); err != nil {
+		return nil, err
+	}
+	return countingConn(count), nil
+}
+
+type countingConn int
+
+func (c countingConn) Prepare(string) (driver.Stmt, error) { return c, nil }
+func (countingConn) Close() error                          { return nil }
+func (countingConn) Begin() (driver.Tx, error)             { return nil, errors.New("不支持事务") }
+func (countingConn) NumInput() int                         { return -1 }
+func (countingConn) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("不支持执行")
+}
+func (c countingConn) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{count: int(c)}, nil
+}
+
+func init() {
+	sql.Register("handlerfake", countingDriver{})
+}
+
+// streamConnection 通过 database/sql 逐行读取结果的连接
+type streamConnection struct {
+	db.Connection
+	sqlDB *sql.DB
+}
+
+func (c streamConnection) QueryStream(ctx context.Context, query string, args ...interface{}) (*db.RowIterator, error) {
+	rows, err := c.sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return db.NewRowIterator(rows)
+}
+
+func TestFetchResultLimit(t *testing.T) {
+	sqlDB, err := sql.Open("handlerfake", "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	conn := streamConnection{sqlDB: sqlDB}
+	yes, no := true, false
+
+	tests := []struct {
+		name      string
+		limit     int
+		answer    *bool // 为 nil 时不提供 confirm
+		rows      int
+		truncated bool
+		asked     []int
+	}{
+		{"不限制", 0, nil, 5, false, nil},
+		{"没有确认函数时截断", 2, nil, 2, true, nil},
+		{"拒绝继续", 3, &no, 3, true, []int{3}},
+		{"继续获取", 2, &yes, 5, false, []int{2}},
+		// 行数恰好等于上限时不询问，也不算截断
+		{"恰好等于上限", 5, &no, 5, false, nil},
+	}
+	for _, tt := range tests {
+		var asked []int
+		var confirm func(int) bool
+		if tt.answer != nil {
+			answer := *tt.answer
+			confirm = func(fetched int) bool {
+				asked = append(asked, fetched)
+				return answer
+			}
+		}
+		result, truncated, err := handler.FetchResult(context.Background(), conn, "select n from t", nil, tt.limit, confirm)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(result.Rows) != tt.rows || truncated != tt.truncated || !reflect.DeepEqual(asked, tt.asked) {
+			t.Errorf("%s: 得到 %d 行, truncated = %v, 询问 %v; want %d 行, truncated = %v, 询问 %v",
+				tt.name, len(result.Rows), truncated, asked, tt.rows, tt.truncated, tt.asked)
+		}
+		if len(result.Rows) > 0 && result.Rows[0][0] != int64(1) {
+			t.Errorf("%s: 第一行 = %v", tt.name, result.Rows[0])
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package handler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestIsReadOnlyStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"select * from orders where status = 'delete'", true},
+		{"select update_time from t", true},
+		{"show tables", true},
+		{"use shop", true},
+		{"set search_path to app", true},
+		{"SET NAMES utf8mb4", true},
+		{"set session sql_mode = 'ANSI'", true},
+		{"SET PASSWORD = 'secret'", false},
+		{"set password for bob = 'secret'", false},
+		{"SET GLOBAL read_only = 0", false},
+		{"set @@global.read_only = 0", false},
+		{"SET PERSIST max_connections = 500", false},
+		{"set transaction read write", false},
+		{"SET SESSION CHARACTERISTICS AS TRANSACTION READ WRITE", false},
+		{"set default_transaction_read_only = off", false},
+		{"SET IDENTITY_INSERT orders ON", false},
+		{"set role admin", false},
+		{"", true},
+		{"insert into t values (1)", false},
+		{"update t set a = 1", false},
+		{"create table t (id int)", false},
+		{"with old as (delete from t returning *) select * from old", false},
+		{"select * into backup from t", false},
+		{"select * from t for update", false},
+		{"explain analyze delete from t", false},
+		{"merge into t using s on (t.id = s.id) when matched then update set a = s.a", false},
+	}
+	for _, tt := range tests {
+		if got := handler.IsReadOnlyStatement(tt.sql); got != tt.want {
+			t.Errorf("IsReadOnlyStatement(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeWrite(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want handler.WriteImpact
+	}{
+		{"update orders set status = 'x', note = (select 1 from dual) where id = :id order by id limit 5;",
+			handler.WriteImpact{Keyword: "UPDATE", Table: "orders", HasWhere: true, From: "orders where id = :id", Limited: true, SetColumns: []string{"status", "note"}}},
+		{"UPDATE app.orders o SET o.status = 1",
+			handler.WriteImpact{Keyword: "UPDATE", Table: "app.orders", From: "app.orders o", SetColumns: []string{"status"}}},
+		{"update t set id = id + 1, name = 'x' where a = 1",
+			handler.WriteImpact{Keyword: "UPDATE", Table: "t", HasWhere: true, From: "t where a = 1", SetColumns: []string{"id", "name"}}},
+		{"delete from logs where level = 'debug' order by id limit 5",
+			handler.WriteImpact{Keyword: "DELETE", Table: "logs", HasWhere: true, From: "logs where level = 'debug'", Limited: true}},
+		{"delete from logs where rownum <= 10",
+			handler.WriteImpact{Keyword: "DELETE", Table: "logs", HasWhere: true, From: "logs where rownum <= 10", Limited: true}},
+		{"delete from orders where created_at < '2020-01-01' returning id",
+			handler.WriteImpact{Keyword: "DELETE", Table: "orders", HasWhere: true, From: "orders where created_at < '2020-01-01'"}},
+		{"DELETE orders WHERE id IN (SELECT id FROM old)",
+			handler.WriteImpact{Keyword: "DELETE", Table: "orders", HasWhere: true, From: "orders WHERE id IN (SELECT id FROM old)"}},
+		{"delete from `orders`",
+			handler.WriteImpact{Keyword: "DELETE", Table: "`orders`", From: "`orders`"}},
+		{"update a set x = b.x from b where a.id = b.id",
+			handler.WriteImpact{Keyword: "UPDATE", Table: "a", HasWhere: true, SetColumns: []string{"x"}}},
+		{"delete from a using b where a.id = b.id",
+			handler.WriteImpact{Keyword: "DELETE", Table: "a", HasWhere: true}},
+		{"update a join b on a.id = b.id set a.x = 1",
+			handler.WriteImpact{Keyword: "UPDATE", Table: "a", SetColumns: []string{"x"}}},
+		{"delete top (10) from logs",
+			handler.WriteImpact{Keyword: "DELETE", Limited: true}},
+		{"drop table if exists orders",
+			handler.WriteImpact{Keyword: "DROP", Table: "orders", From: "orders"}},
+		{"drop index idx_orders",
+			handler.WriteImpact{Keyword: "DROP", Table: "idx_orders"}},
+		{"truncate table logs",
+			handler.WriteImpact{Keyword: "TRUNCATE", Table: "logs", From: "logs"}},
+	}
+	for _, tt := range tests {
+		got, ok := handler.AnalyzeWrite(tt.sql)
+		if !ok || !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("AnalyzeWrite(%q) = %+v, %v, want %+v", tt.sql, got, ok, tt.want)
+		}
+	}
+
+	for _, sql := range []string{"insert into t values (1)", "select 1", "alter table t add c int", ""} {
+		if _, ok := handler.AnalyzeWrite(sql); ok {
+			t.Errorf("AnalyzeWrite(%q) should not analyze", sql)
+		}
+	}
+}
+
+func TestWriteImpactConfirm(t *testing.T) {
+	where := handler.WriteImpact{Keyword: "DELETE", Table: "orders", HasWhere: true}
+	if reasons := where.Reasons(); len(reasons) != 0 {
+		t.Errorf("DELETE with WHERE should not need typed confirmation: %v", reasons)
+	}
+	if where.CountSQL() != "" {
+		t.Errorf("CountSQL() without From = %q, want empty", where.CountSQL())
+	}
+	all := handler.WriteImpact{Keyword: "UPDATE", Table: "orders", From: "orders o"}
+	if all.CountSQL() != "SELECT COUNT(*) FROM orders o" {
+		t.Errorf("CountSQL() = %q", all.CountSQL())
+	}
+	if len(all.Reasons()) != 1 || all.ConfirmText() != "orders" {
+		t.Errorf("UPDATE without WHERE: %v %q", all.Reasons(), all.ConfirmText())
+	}
+	unknown := handler.WriteImpact{Keyword: "TRUNCATE"}
+	if len(unknown.Reasons()) != 1 || unknown.ConfirmText() != "TRUNCATE" {
+		t.Errorf("TRUNCATE without table: %v %q", unknown.Reasons(), unknown.ConfirmText())
+	}
+}
@@ -0,0 +1,145 @@
+package handler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// useTempHome 将配置目录指向临时目录，测试结束后恢复默认设置
+func useTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Cleanup(func() {
+		for _, name := range handler.SettingNames() {
+			handler.SetSetting(handler.ScopeSession, name, "default")
+		}
+		handler.LoadSettings("")
+	})
+}
+
+func TestSettingNormalize(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+		wantErr           bool
+	}{
+		{"timing", "true", "on", false},
+		{"timing", "0", "off", false},
+		{"timing", "maybe", "", true},
+		{"maxrows", "200", "200", false},
+		{"maxrows", "-1", "", true},
+		{"pager", "OFF", "off", false},
+		{"pager", "always", "", true},
+		{"format", "md", output.FormatMarkdown, false},
+		{"format", "xlsx", "", true},
+		{"timezone", "Asia/Shanghai", "Asia/Shanghai", false},
+		{"timezone", "Mars/Olympus", "", true},
+		{"datetimeformat", "RFC3339", "rfc3339", false},
+		{"datetimeformat", "yyyy-mm-dd", "", true},
+		{"locale", "de_DE", "de_DE", false},
+		{"locale", "german", "", true},
+	}
+	for _, tt := range tests {
+		s, err := handler.LookupSetting(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Normalize(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s.Normalize(%q) = %q, %v; 期望 %q, 出错 %v", tt.name, tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if _, err := handler.LookupSetting("colour"); err == nil {
+		t.Error("未知的设置应返回错误")
+	}
+}
+
+func TestSetSettingAppliesValue(t *testing.T) {
+	useTempHome(t)
+	if err := handler.SetSetting(handler.ScopeSession, "format", "json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.SetSetting(handler.ScopeSession, "maxrows", "5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.SetSetting(handler.ScopeSession, "nullstring", "<null>"); err != nil {
+		t.Fatal(err)
+	}
+	if handler.OutputFormat() != output.FormatJSON || handler.MaxRows() != 5 {
+		t.Errorf("设置未生效: format=%s maxrows=%d", handler.OutputFormat(), handler.MaxRows())
+	}
+	if got := handler.RenderOptions().NullString; got != "<null>" {
+		t.Errorf("NullString = %q，期望 <null>", got)
+	}
+
+	// 时间按 datetimeformat 和 timezone 显示
+	handler.SetSetting(handler.ScopeSession, "datetimeformat", "2006-01-02T15:04:05.000Z07:00")
+	handler.SetSetting(handler.ScopeSession, "timezone", "UTC")
+	ts := time.Date(2024, 3, 1, 8, 30, 0, 250e6, time.FixedZone("CST", 8*3600))
+	if got, _ := output.FormatValue(ts); got != "2024-03-01T00:30:00.250Z" {
+		t.Errorf("FormatValue = %q", got)
+	}
+
+	// default 恢复为默认值
+	handler.SetSetting(handler.ScopeSession, "format", "default")
+	s, _ := handler.LookupSetting("format")
+	if value, source := s.Value(); value != output.FormatTable || source != "默认值" || handler.OutputFormat() != output.FormatTable {
+		t.Errorf("恢复后 format = %s (%s)", value, source)
+	}
+}
+
+func TestSettingLayers(t *testing.T) {
+	useTempHome(t)
+	if err := utils.AddProfile("prod", &utils.Config{Type: "mysql", Host: "db", Port: 3306, User: "u", DbName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.LoadSettings("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.SetSetting(handler.ScopeGlobal, "maxrows", "50"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.SetSetting(handler.ScopeProfile, "maxrows", "20"); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := handler.LookupSetting("maxrows")
+	if value, source := s.Value(); value != "20" || source != "配置 prod" || handler.MaxRows() != 20 {
+		t.Errorf("maxrows = %s (%s)，期望 20 (配置 prod)", value, source)
+	}
+
+	// 会话中的值优先，保存到配置文件后清除会话中的值
+	handler.SetSetting(handler.ScopeSession, "maxrows", "7")
+	if handler.MaxRows() != 7 {
+		t.Errorf("会话设置未生效: %d", handler.MaxRows())
+	}
+	handler.SetSetting(handler.ScopeSession, "maxrows", "default")
+
+	// 不使用该配置时只有全局设置
+	if err := handler.LoadSettings(""); err != nil {
+		t.Fatal(err)
+	}
+	if value, source := s.Value(); value != "50" || source != "全局" {
+		t.Errorf("maxrows = %s (%s)，期望 50 (全局)", value, source)
+	}
+	if err := handler.SetSetting(handler.ScopeProfile, "maxrows", "1"); err == nil {
+		t.Error("未使用命名配置时保存到配置应返回错误")
+	}
+}
+
+func TestInvalidSavedSetting(t *testing.T) {
+	useTempHome(t)
+	if err := utils.SaveSetting("", "pager", "sometimes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := handler.LoadSettings(""); err == nil {
+		t.Error("保存的值无效时应返回错误")
+	}
+	if handler.PagerMode() != handler.PagerAuto {
+		t.Errorf("无效的值应使用默认值，得到 %s", handler.PagerMode())
+	}
+}
@@ -0,0 +1,45 @@
+package handler_test
+
+import (
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestSplitPipe(t *testing.T) {
+	tests := []struct {
+		sql     string
+		query   string
+		command string
+		ok      bool
+	}{
+		{"select * from logs | grep ERROR", "select * from logs", "grep ERROR", true},
+		{"select name from t | sort | uniq -c", "select name from t | sort", "uniq -c", true},
+		{"select a || b from t", "", "", false},
+		{"select a | b from t", "", "", false},
+		{"select '|' from t", "", "", false},
+		{"select 1 -- | grep x", "", "", false},
+		{"select 1 |", "", "", false},
+		{"| sort", "", "", false},
+		{"select 1 | no-such-command-datamgr", "", "", false},
+	}
+	for _, tt := range tests {
+		query, command, ok := handler.SplitPipe(tt.sql)
+		if ok != tt.ok || query != tt.query || command != tt.command {
+			t.Errorf("SplitPipe(%q) = %q, %q, %v", tt.sql, query, command, ok)
+		}
+	}
+}
+
+func TestSetPipeFormat(t *testing.T) {
+	defer handler.SetPipeFormat(handler.PipeFormat())
+
+	if err := handler.SetPipeFormat("CSV"); err != nil || handler.PipeFormat() != "csv" {
+		t.Errorf("SetPipeFormat(CSV) = %v, format %s", err, handler.PipeFormat())
+	}
+	for _, format := range []string{"xlsx", "yaml"} {
+		if err := handler.SetPipeFormat(format); err == nil {
+			t.Errorf("SetPipeFormat(%s) should fail", format)
+		}
+	}
+}
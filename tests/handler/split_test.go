@@ -0,0 +1,76 @@
+package handler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"多条语句", "select 1; select 2;\n\n;select 3", []string{"select 1", "select 2", "select 3"}},
+		{"字符串中的分号", "insert into t values ('a;b', 'it''s;'); select 2", []string{"insert into t values ('a;b', 'it''s;')", "select 2"}},
+		{"带引号的标识符", "select \"a;b\", `c;d` from t; select 2", []string{"select \"a;b\", `c;d` from t", "select 2"}},
+		{"行注释", "-- 清理数据; 注意\ndelete from t; -- 结束;\nselect 1", []string{"delete from t", "select 1"}},
+		{"块注释", "select 1 /* a; b */ from t; /* 说明; */ select 2", []string{"select 1 /* a; b */ from t", "select 2"}},
+		{"优化器提示", "/*!40101 SET NAMES utf8 */; select /*+ INDEX(t i) */ * from t", []string{"/*!40101 SET NAMES utf8 */", "select /*+ INDEX(t i) */ * from t"}},
+		{"开始事务", "begin; update t set a = 1; commit;\nBEGIN TRANSACTION; delete from t; COMMIT",
+			[]string{"begin", "update t set a = 1", "commit", "BEGIN TRANSACTION", "delete from t", "COMMIT"}},
+		{"匿名块", "BEGIN\n  update t set a = 1;\n  delete from s;\nEND;\nselect 1",
+			[]string{"BEGIN\n  update t set a = 1;\n  delete from s;\nEND;", "select 1"}},
+		{"DECLARE 块", "DECLARE\n  v NUMBER;\nBEGIN\n  select count(*) into v from t;\nEND;\n/\nselect 1 from dual",
+			[]string{"DECLARE\n  v NUMBER;\nBEGIN\n  select count(*) into v from t;\nEND;", "select 1 from dual"}},
+		{"T-SQL 变量", "DECLARE @n int; SET @n = 1; select @n", []string{"DECLARE @n int", "SET @n = 1", "select @n"}},
+		{"Oracle 存储过程", "CREATE OR REPLACE PROCEDURE p(a IN NUMBER) IS\n  v NUMBER;\nBEGIN\n  IF a > 0 THEN\n    BEGIN\n      update t set b = a;\n    END;\n  END IF;\n  v := CASE a WHEN 1 THEN 2 ELSE 3 END;\nEND p;\n/\nselect 1 from dual",
+			[]string{"CREATE OR REPLACE PROCEDURE p(a IN NUMBER) IS\n  v NUMBER;\nBEGIN\n  IF a > 0 THEN\n    BEGIN\n      update t set b = a;\n    END;\n  END IF;\n  v := CASE a WHEN 1 THEN 2 ELSE 3 END;\nEND p;", "select 1 from dual"}},
+		{"Oracle 包", "CREATE PACKAGE BODY pk AS\n  PROCEDURE a IS BEGIN NULL; END a;\n  PROCEDURE b IS BEGIN NULL; END b;\nEND pk;\n/\nselect 1 from dual",
+			[]string{"CREATE PACKAGE BODY pk AS\n  PROCEDURE a IS BEGIN NULL; END a;\n  PROCEDURE b IS BEGIN NULL; END b;\nEND pk;", "select 1 from dual"}},
+		{"Oracle 触发器", "CREATE TRIGGER trg BEFORE INSERT OR UPDATE ON t FOR EACH ROW\nDECLARE\n  n NUMBER;\nBEGIN\n  :new.a := 1;\nEND;\n/",
+			[]string{"CREATE TRIGGER trg BEFORE INSERT OR UPDATE ON t FOR EACH ROW\nDECLARE\n  n NUMBER;\nBEGIN\n  :new.a := 1;\nEND;"}},
+		{"T-SQL 存储过程", "CREATE PROCEDURE p AS\nBEGIN\n  SET NOCOUNT ON;\n  BEGIN TRY\n    update t set a = 1;\n  END TRY\n  BEGIN CATCH\n    select 0;\n  END CATCH\nEND\nGO\nexec p",
+			[]string{"CREATE PROCEDURE p AS\nBEGIN\n  SET NOCOUNT ON;\n  BEGIN TRY\n    update t set a = 1;\n  END TRY\n  BEGIN CATCH\n    select 0;\n  END CATCH\nEND", "exec p"}},
+		{"T-SQL 触发器", "CREATE TRIGGER trg ON t AFTER INSERT AS\n  update s set n = n + 1;\n  delete from log;\nGO",
+			[]string{"CREATE TRIGGER trg ON t AFTER INSERT AS\n  update s set n = n + 1;\n  delete from log;"}},
+		{"MySQL 存储过程", "CREATE DEFINER=`root`@`%` PROCEDURE p()\nBEGIN\n  DECLARE n INT;\n  WHILE n < 3 DO\n    SET n = n + 1;\n  END WHILE;\nEND;\ncall p()",
+			[]string{"CREATE DEFINER=`root`@`%` PROCEDURE p()\nBEGIN\n  DECLARE n INT;\n  WHILE n < 3 DO\n    SET n = n + 1;\n  END WHILE;\nEND;", "call p()"}},
+		{"MySQL 单语句触发器", "CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1; select 1",
+			[]string{"CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1;", "select 1"}},
+		{"PostgreSQL 函数", "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql; select f()",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql", "select f()"}},
+		{"PostgreSQL DO", "DO $body$ BEGIN PERFORM 1; END $body$; select 1", []string{"DO $body$ BEGIN PERFORM 1; END $body$", "select 1"}},
+		{"除号", "select a\n/ b from t; select 2", []string{"select a\n/ b from t", "select 2"}},
+		{"空文本", " ;\n-- 只有注释\n", nil},
+	}
+	for _, tt := range tests {
+		if got := handler.SplitStatements(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SplitStatements(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsBlockStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"BEGIN\n  update t set a = 1;\nEND;", true},
+		{"DECLARE\n  v NUMBER;\nBEGIN\n  NULL;\nEND;", true},
+		{"CREATE OR REPLACE PROCEDURE p IS\nBEGIN\n  NULL;\nEND p;", true},
+		{"begin", false},
+		{"BEGIN TRANSACTION;", false},
+		{"DECLARE @n int;", false},
+		{"call p();", false},
+		{"select 1;", false},
+		{"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;", false},
+	}
+	for _, tt := range tests {
+		if got := handler.IsBlockStatement(tt.sql); got != tt.want {
+			t.Errorf("IsBlockStatement(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
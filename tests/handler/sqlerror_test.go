@@ -0,0 +1,42 @@
+package handler_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestAnnotateSQLErrorMarksPosition(t *testing.T) {
+	sql := "select 名称\nfromm users"
+	pqErr := &pq.Error{Message: `syntax error at or near "fromm"`, Position: "11"}
+	err := handler.AnnotateSQLError(sql, pqErr)
+
+	if !errors.Is(err, pqErr) {
+		t.Errorf("annotated error should wrap the original error")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 || lines[1] != "LINE 2: fromm users" || lines[2] != "        ^" {
+		t.Errorf("unexpected marker:\n%s", err)
+	}
+}
+
+func TestAnnotateSQLErrorWideCharacters(t *testing.T) {
+	sql := "select 名称 fromm users"
+	err := handler.AnnotateSQLError(sql, &pq.Error{Message: "syntax error", Position: "11"})
+	lines := strings.Split(err.Error(), "\n")
+	// 中文字符占两列，^ 应对齐到 fromm 的起始列
+	want := strings.Repeat(" ", len("LINE 1: select ")+4+1) + "^"
+	if len(lines) != 3 || lines[2] != want {
+		t.Errorf("unexpected marker:\n%s", err)
+	}
+}
+
+func TestAnnotateSQLErrorWithoutPosition(t *testing.T) {
+	orig := errors.New("connection reset")
+	if err := handler.AnnotateSQLError("select 1", orig); err != orig {
+		t.Errorf("errors without position should be returned unchanged, got %v", err)
+	}
+}
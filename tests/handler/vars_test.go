@@ -0,0 +1,47 @@
+package handler_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestBindVariables(t *testing.T) {
+	vars := map[string]string{"id": "42", "since": "2024-01-01", "name": "O'Brien"}
+	tests := []struct {
+		dbType, sql, want string
+		args              []interface{}
+	}{
+		{"postgresql", "select * from t where id = :id and d >= :'since'",
+			"select * from t where id = $1 and d >= $2", []interface{}{"42", "2024-01-01"}},
+		{"mysql", "select :id, :id", "select ?, ?", []interface{}{"42", "42"}},
+		{"oracle", "select * from t where name = :'name'", "select * from t where name = :1", []interface{}{"O'Brien"}},
+		{"mssql", "select * from t where id=:id", "select * from t where id=@p1", []interface{}{"42"}},
+		// 类型转换、字符串、注释和未定义的变量保持不变
+		{"postgresql", "select x::int, ':id', :missing -- :id\nfrom t", "select x::int, ':id', :missing -- :id\nfrom t", nil},
+		{"postgresql", "select '2024'::date, :id", "select '2024'::date, $1", []interface{}{"42"}},
+	}
+	for _, tt := range tests {
+		got, args := handler.BindVariables(tt.sql, tt.dbType, vars)
+		if got != tt.want || !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("BindVariables(%q) = %q, %v; 期望 %q, %v", tt.sql, got, args, tt.want, tt.args)
+		}
+	}
+}
+
+func TestSetVariable(t *testing.T) {
+	if err := handler.SetVariable("1abc", "x"); err == nil {
+		t.Error("以数字开头的变量名应返回错误")
+	}
+	if err := handler.HandleSetVariable("greeting", "'hello  world'"); err != nil {
+		t.Fatal(err)
+	}
+	if got := handler.Variables()["greeting"]; got != "hello  world" {
+		t.Errorf("greeting = %q", got)
+	}
+	handler.UnsetVariable("greeting")
+	if _, ok := handler.Variables()["greeting"]; ok {
+		t.Error("删除后变量仍然存在")
+	}
+}
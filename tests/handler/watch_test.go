@@ -0,0 +1,168 @@
+package handler_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+func TestParseWatchArgs(t *testing.T) {
+	tests := []struct {
+		args     string
+		interval time.Duration
+		sql      string
+		until    string
+	}{
+		{"select count(*) from jobs", handler.DefaultWatchInterval, "select count(*) from jobs", ""},
+		{"5 select count(*) from jobs", 5 * time.Second, "select count(*) from jobs", ""},
+		{"500ms select 1", 500 * time.Millisecond, "select 1", ""},
+		{`10 select count(*) as n from jobs --until "n = 0"`, 10 * time.Second, "select count(*) as n from jobs", "n = 0"},
+		{"select status from jobs --until empty", handler.DefaultWatchInterval, "select status from jobs", "empty"},
+	}
+	for _, tt := range tests {
+		interval, sql, until, err := handler.ParseWatchArgs(tt.args)
+		if err != nil {
+			t.Errorf("ParseWatchArgs(%q) failed: %v", tt.args, err)
+			continue
+		}
+		if interval != tt.interval || sql != tt.sql {
+			t.Errorf("ParseWatchArgs(%q) = %v, %q", tt.args, interval, sql)
+		}
+		got := ""
+		if until != nil {
+			got = until.String()
+		}
+		if got != tt.until {
+			t.Errorf("ParseWatchArgs(%q) until = %q, want %q", tt.args, got, tt.until)
+		}
+	}
+
+	for _, args := range []string{"", "5", "0.01 select 1", "delete from jobs", "select 1 --until n ~ 0"} {
+		if _, _, _, err := handler.ParseWatchArgs(args); err == nil {
+			t.Errorf("ParseWatchArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestWatchConditionSatisfied(t *testing.T) {
+	result := &output.Result{
+		Columns: []string{"status", "remaining"},
+		Rows:    [][]interface{}{{"running", int64(3)}},
+	}
+	tests := []struct {
+		cond string
+		want bool
+	}{
+		{"remaining = 3", true},
+		{"REMAINING > 2", true},
+		{"remaining <= 2", false},
+		{"remaining <> 3", false},
+		{"status == running", true},
+		{"status = 'done'", false},
+		{"empty", false},
+	}
+	for _, tt := range tests {
+		cond, err := handler.ParseWatchCondition(tt.cond)
+		if err != nil {
+			t.Fatalf("ParseWatchCondition(%q) failed: %v", tt.cond, err)
+		}
+		got, err := cond.Satisfied(result)
+		if err != nil {
+			t.Errorf("%q: %v", tt.cond, err)
+		} else if got != tt.want {
+			t.Errorf("%q satisfied = %v, want %v", tt.cond, got, tt.want)
+		}
+	}
+
+	cond, _ := handler.ParseWatchCondition("= 0")
+	if _, err := cond.Satisfied(result); err == nil {
+		t.Error("condition without column should fail on multi-column result")
+	}
+	single := &output.Result{Columns: []string{"n"}, Rows: [][]interface{}{{"0"}}}
+	if ok, err := cond.Satisfied(single); err != nil || !ok {
+		t.Errorf("condition without column should use the only column, got %v, %v", ok, err)
+	}
+	cond, _ = handler.ParseWatchCondition("missing = 1")
+	if _, err := cond.Satisfied(result); err == nil {
+		t.Error("unknown column should fail")
+	}
+	cond, _ = handler.ParseWatchCondition("empty")
+	if ok, _ := cond.Satisfied(&output.Result{Columns: []string{"n"}}); !ok {
+		t.Error("empty should be satisfied by a result without rows")
+	}
+}
+
+func TestHandleWatchTruncated(t *testing.T) {
+	sqlDB, err := sql.Open("handlerfake", "5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db.Register(&db.Driver{
+		Name: "watchfake",
+		New:  func(*db.DbConfig) (db.Connection, error) { return watchConnection{streamConnection{sqlDB: sqlDB}}, nil },
+	})
+	if err := db.ConnectConfig(&db.DbConfig{Type: "watchfake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Disconnect()
+
+	var console bytes.Buffer
+	handler.SetConsole(&console)
+	defer handler.SetConsole(nil)
+	handler.SetMaxRows(2)
+	defer handler.SetMaxRows(handler.DefaultMaxRows)
+
+	if err := handler.HandleWatch(`select n from t --until "n = 1"`); err != nil {
+		t.Fatal(err)
+	}
+	frame := console.String()
+	for _, want := range []string{"共 2 行结果", "已达到行数上限 2"} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("监视输出中缺少 %q:\n%s", want, frame)
+		}
+	}
+}
+
+// watchConnection 可以连接和断开的 streamConnection
+type watchConnection struct {
+	streamConnection
+}
+
+func (watchConnection) Connect() error    { return nil }
+func (watchConnection) Disconnect() error { return nil }
+
+func TestReadOnlyQueries(t *testing.T) {
+	sqlDB, err := sql.Open("handlerfake", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db.Register(&db.Driver{
+		Name: "readonlyfake",
+		New:  func(*db.DbConfig) (db.Connection, error) { return watchConnection{streamConnection{sqlDB: sqlDB}}, nil },
+	})
+	if err := db.ConnectConfig(&db.DbConfig{Type: "readonlyfake", ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Disconnect()
+
+	// 带锁定子句的查询在只读连接中同样被拒绝
+	if err := handler.HandleWatch("select n from t for update"); !errors.Is(err, handler.ErrReadOnly) {
+		t.Errorf("只读连接中 watch ... for update 应返回错误, 得到 %v", err)
+	}
+	if err := handler.HandleSetVariable("n", "select n from t for update"); !errors.Is(err, handler.ErrReadOnly) {
+		t.Errorf("只读连接中 \\set n <for update 查询> 应返回错误, 得到 %v", err)
+	}
+	if err := handler.HandleSetVariable("n", "select n from t"); err != nil {
+		t.Errorf("只读连接中 \\set n <查询> = %v", err)
+	}
+	handler.UnsetVariable("n")
+}
@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+)
+
+func TestOutputAndTee(t *testing.T) {
+	var console bytes.Buffer
+	handler.SetConsole(&console)
+	defer handler.SetConsole(nil)
+
+	logPath := filepath.Join(t.TempDir(), "session.log")
+	if err := handler.StartTee(logPath); err != nil {
+		t.Fatal(err)
+	}
+	handler.LogCommand("datamgr> ", `\set x 1`)
+	fmt.Fprintln(handler.Output(), "\x1b[31m✗ \x1b[0m出错了")
+	if got := handler.TeeFileName(); got != logPath {
+		t.Errorf("TeeFileName = %q", got)
+	}
+	handler.StopTee()
+	fmt.Fprintln(handler.Output(), "停止记录后的输出")
+
+	if !strings.Contains(console.String(), "\x1b[31m✗ \x1b[0m出错了") || !strings.Contains(console.String(), "停止记录后的输出") {
+		t.Errorf("终端输出 = %q", console.String())
+	}
+	if strings.Contains(console.String(), `\set x 1`) {
+		t.Error("命令不应输出到终端")
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{"datamgr> \\set x 1\n", "✗ 出错了\n", "会话日志开始于", "会话日志结束于"} {
+		if !strings.Contains(log, want) {
+			t.Errorf("日志中缺少 %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "\x1b") || strings.Contains(log, "停止记录后的输出") {
+		t.Errorf("日志内容有误:\n%s", log)
+	}
+}
+
+func TestSetOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := handler.SetOutputFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if handler.OutputFileName() != path {
+		t.Errorf("OutputFileName = %q", handler.OutputFileName())
+	}
+	if err := handler.SetOutputFile(""); err != nil {
+		t.Fatal(err)
+	}
+	if handler.OutputFileName() != "" {
+		t.Error("重置后仍有输出文件")
+	}
+	if err := handler.SetOutputFile(filepath.Join(t.TempDir(), "missing", "out.txt")); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+}
@@ -0,0 +1,170 @@
+package history_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/history"
+)
+
+// openTemp 在临时HOME目录下打开历史
+func openTemp(t *testing.T, profile string) *history.History {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	h, err := history.Open(profile)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return h
+}
+
+func TestAddPersistsAndDeduplicates(t *testing.T) {
+	h := openTemp(t, "pg_admin@localhost_5432_test")
+	for _, cmd := range []string{"show tables", "select * from users", "show tables"} {
+		if err := h.Add(cmd); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	reopened, err := history.Open("pg_admin@localhost_5432_test")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	got := strings.Join(reopened.Entries(), ";")
+	if got != "select * from users;show tables" {
+		t.Errorf("unexpected entries: %q", got)
+	}
+}
+
+func TestProfilesAreSeparate(t *testing.T) {
+	h := openTemp(t, "mysql_root@db1_3306_app")
+	if err := h.Add("select 1"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	other, err := history.Open("mysql_root@db2_3306_app")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if other.Len() != 0 {
+		t.Errorf("expected empty history for another profile, got %v", other.Entries())
+	}
+}
+
+func TestMultilineEntryRoundTrip(t *testing.T) {
+	h := openTemp(t, "default")
+	cmd := "select 'a\\b'\nfrom dual"
+	if err := h.Add(cmd); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	reopened, err := history.Open("default")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if reopened.Len() != 1 || reopened.At(0) != cmd {
+		t.Errorf("unexpected entries: %q", reopened.Entries())
+	}
+}
+
+func TestHistoryFileIsPrivate(t *testing.T) {
+	h := openTemp(t, "default")
+	if err := h.Add("select 1"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	info, err := os.Stat(h.Path())
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("history file should not be readable by others, got %v", perm)
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	h := openTemp(t, "default")
+	for i := 0; i < history.DefaultMaxEntries+5; i++ {
+		if err := h.Add("select " + strings.Repeat("x", i%7) + string(rune('a'+i%26)) + strings.Repeat("y", i/26)); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if h.Len() != history.DefaultMaxEntries {
+		t.Errorf("expected %d entries, got %d", history.DefaultMaxEntries, h.Len())
+	}
+	if err := h.Add(strings.Repeat("x", history.MaxEntryLength+1)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if h.Len() != history.DefaultMaxEntries || len(h.At(h.Len()-1)) > history.MaxEntryLength {
+		t.Errorf("oversized entry should not be recorded")
+	}
+}
+
+func TestFindAndSearchBackward(t *testing.T) {
+	h := openTemp(t, "default")
+	for _, cmd := range []string{"select * from users", "show tables", "SELECT id FROM orders"} {
+		if err := h.Add(cmd); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	found := h.Find("select")
+	if len(found) != 2 || found[0].Index != 1 || found[1].Index != 3 {
+		t.Errorf("unexpected Find result: %+v", found)
+	}
+	if i := h.SearchBackward("select", h.Len()); i != 2 {
+		t.Errorf("expected newest match at 2, got %d", i)
+	}
+	if i := h.SearchBackward("select", 2); i != 0 {
+		t.Errorf("expected older match at 0, got %d", i)
+	}
+	if i := h.SearchBackward("select", 0); i != -1 {
+		t.Errorf("expected no match, got %d", i)
+	}
+}
+
+func TestMaskPasswords(t *testing.T) {
+	cases := map[string]string{
+		"connect --type mysql -H db -u root -p s3cret -D app": "connect --type mysql -H db -u root -p ******** -D app",
+		"connect -H db --password=s3cret -D app":              "connect -H db --password=******** -D app",
+		`\c -H db -u root -p s3cret -D app`:                   `\c -H db -u root -p ******** -D app`,
+		`\connect --password=s3cret -D app`:                   `\connect --password=******** -D app`,
+		"config set password my secret":                       "config set password ********",
+		"CONFIG SET PASSWORD x":                               "CONFIG SET PASSWORD ********",
+		"select * from t where p = '-p x'":                    "select * from t where p = '-p x'",
+		"config set user admin":                               "config set user admin",
+	}
+	for in, want := range cases {
+		if got := history.MaskPasswords(in); got != want {
+			t.Errorf("MaskPasswords(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAddMasksPasswords(t *testing.T) {
+	h := openTemp(t, "default")
+	if err := h.Add("connect -H db -u root -p s3cret -D app"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	data, err := os.ReadFile(h.Path())
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.Contains(string(data), "s3cret") {
+		t.Errorf("password stored in history file: %s", data)
+	}
+}
+
+func TestHasMaskedPassword(t *testing.T) {
+	cases := map[string]bool{
+		"connect -H db -u root -p ******** -D app": true,
+		"config set password ********":             true,
+		`\c -H db -p ********`:                     true,
+		"connect -H db -u root -p s3cret -D app":   false,
+		"select '********' from dual":              false,
+	}
+	for in, want := range cases {
+		if got := history.HasMaskedPassword(in); got != want {
+			t.Errorf("HasMaskedPassword(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package output_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+// sampleResult 返回包含NULL、换行和二进制数据的测试结果
+func sampleResult() *output.Result {
+	return &output.Result{
+		Columns: []string{"id", "name", "data"},
+		Rows: [][]interface{}{
+			{int64(1), "line1\nline2", nil},
+			{int64(2), "a|b", string([]byte{0xff, 0x00})},
+		},
+	}
+}
+
+func renderString(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := output.Render(&buf, format, sampleResult(), output.DefaultOptions()); err != nil {
+		t.Fatalf("Render(%s) failed: %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestRenderJSON(t *testing.T) {
+	got := renderString(t, output.FormatJSON)
+	want := "[\n  {\"id\":1,\"name\":\"line1\\nline2\",\"data\":null},\n  {\"id\":2,\"name\":\"a|b\",\"data\":\"\\\\xff00\"}\n]\n"
+	if got != want {
+		t.Errorf("unexpected JSON output:\n%s", got)
+	}
+}
+
+func TestRenderJSONSpecialFloats(t *testing.T) {
+	// JSON 不能表示 NaN 和无穷大，输出为字符串
+	result := &output.Result{
+		Columns: []string{"a", "b", "c", "d"},
+		Rows:    [][]interface{}{{math.NaN(), math.Inf(1), float32(math.Inf(-1)), 1.5}},
+	}
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.FormatNDJSON, result, output.DefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `{"a":"NaN","b":"Infinity","c":"-Infinity","d":1.5}`+"\n"; got != want {
+		t.Errorf("NDJSON = %q, want %q", got, want)
+	}
+}
+
+func TestRenderNDJSON(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(renderString(t, output.FormatNDJSON)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[0] != `{"id":1,"name":"line1\nline2","data":null}` {
+		t.Errorf("unexpected first line: %s", lines[0])
+	}
+}
+
+func TestRenderTSV(t *testing.T) {
+	got := renderString(t, output.FormatTSV)
+	want := "id\tname\tdata\n1\tline1\\nline2\t\\N\n2\ta|b\t\\\\xff00\n"
+	if got != want {
+		t.Errorf("unexpected TSV output:\n%q", got)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	got := renderString(t, output.FormatCSV)
+	if !strings.Contains(got, "1,\"line1\nline2\",\n") {
+		t.Errorf("CSV should quote multi-line values and leave NULL empty:\n%s", got)
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	got := renderString(t, output.FormatMarkdown)
+	if !strings.Contains(got, `| 2 | a\|b |`) {
+		t.Errorf("Markdown should escape pipes:\n%s", got)
+	}
+	if !strings.Contains(got, "line1<br>line2 | NULL |") {
+		t.Errorf("Markdown should convert newlines and show NULL:\n%s", got)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	got := renderString(t, output.FormatHTML)
+	if !strings.Contains(got, "<td>line1<br>line2</td>") || !strings.Contains(got, `<td class="null">NULL</td>`) {
+		t.Errorf("unexpected HTML output:\n%s", got)
+	}
+}
+
+func TestRenderVertical(t *testing.T) {
+	got := renderString(t, output.FormatVertical)
+	if !strings.Contains(got, "-[ RECORD 1 ]") || !strings.Contains(got, "name | line1\n     | line2\n") {
+		t.Errorf("unexpected vertical output:\n%s", got)
+	}
+}
+
+func TestFormatAliases(t *testing.T) {
+	for alias, want := range map[string]string{"md": output.FormatMarkdown, "JSONL": output.FormatNDJSON} {
+		if got := output.Normalize(alias); got != want {
+			t.Errorf("Normalize(%s) = %s, want %s", alias, got, want)
+		}
+	}
+	if output.IsValidFormat("yaml") {
+		t.Error("yaml should not be a valid format")
+	}
+}
+
+func TestFormatBinary(t *testing.T) {
+	// 二进制列的值即使是有效的UTF-8也以十六进制显示，字符串只有无效UTF-8时才是
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{[]byte("abc"), `\x616263`},
+		{[]byte{}, `\x`},
+		{"abc", "abc"},
+		{string([]byte{0xff}), `\xff`},
+	}
+	for _, tt := range tests {
+		if got, ok := output.FormatValue(tt.val); !ok || got != tt.want {
+			t.Errorf("FormatValue(%#v) = %q, %v, want %q", tt.val, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+func renderTable(t *testing.T, result *output.Result, opts output.Options) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.FormatTable, result, opts); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestTableAlignsCJK(t *testing.T) {
+	result := &output.Result{
+		Columns: []string{"name", "amount"},
+		Rows: [][]interface{}{
+			{"张三", int64(5)},
+			{"abc", "1200.50"},
+		},
+	}
+	lines := renderTable(t, result, output.DefaultOptions())
+	want := []string{
+		" name | amount",
+		"------+---------",
+		" 张三 |       5",
+		" abc  | 1200.50",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected table:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestTableNullAndEmpty(t *testing.T) {
+	result := &output.Result{
+		Columns: []string{"a", "b"},
+		Rows:    [][]interface{}{{nil, ""}},
+	}
+	opts := output.DefaultOptions()
+	opts.NullString = "<null>"
+	lines := renderTable(t, result, opts)
+	if lines[2] != " <null> |" {
+		t.Errorf("NULL should be shown distinctly from empty string, got %q", lines[2])
+	}
+}
+
+func TestTableTruncatesToWidth(t *testing.T) {
+	result := &output.Result{
+		Columns: []string{"id", "remark"},
+		Rows:    [][]interface{}{{int64(1), strings.Repeat("数据", 30)}},
+	}
+	opts := output.DefaultOptions()
+	opts.MaxWidth = 30
+	for _, line := range renderTable(t, result, opts) {
+		if w := runewidth.StringWidth(line); w > 30 {
+			t.Errorf("line exceeds max width (%d): %q", w, line)
+		}
+	}
+	if lines := renderTable(t, result, opts); !strings.HasSuffix(lines[2], "…") {
+		t.Errorf("truncated value should end with ellipsis: %q", lines[2])
+	}
+}
+
+func TestTableWrapsToWidth(t *testing.T) {
+	result := &output.Result{
+		Columns: []string{"id", "remark"},
+		Rows:    [][]interface{}{{int64(1), strings.Repeat("x", 50)}},
+	}
+	opts := output.DefaultOptions()
+	opts.MaxWidth = 30
+	opts.Overflow = output.OverflowWrap
+	lines := renderTable(t, result, opts)
+	if len(lines) < 4 {
+		t.Fatalf("expected wrapped rows, got:\n%s", strings.Join(lines, "\n"))
+	}
+	total := 0
+	for _, line := range lines[2:] {
+		if runewidth.StringWidth(line) > 30 {
+			t.Errorf("line exceeds max width: %q", line)
+		}
+		total += strings.Count(line, "x")
+	}
+	if total != 50 {
+		t.Errorf("wrapped content lost characters: %d", total)
+	}
+}
+
+func TestTableHighlight(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	result := &output.Result{
+		Columns: []string{"a", "b"},
+		Rows:    [][]interface{}{{"x", "y"}},
+	}
+	opts := output.DefaultOptions()
+	opts.Color = true
+	opts.Highlight = func(row, col int) bool { return col == 1 }
+	lines := renderTable(t, result, opts)
+	if strings.Contains(lines[2][:4], "\x1b[") || !strings.Contains(lines[2], "\x1b[") {
+		t.Errorf("only the changed cell should be highlighted, got %q", lines[2])
+	}
+
+	opts.Color = false
+	lines = renderTable(t, result, opts)
+	if strings.Contains(lines[2], "\x1b[") {
+		t.Errorf("highlight should need color, got %q", lines[2])
+	}
+}
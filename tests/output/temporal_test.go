@@ -0,0 +1,88 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+// useTimeFormat 设置时间格式，测试结束后恢复默认
+func useTimeFormat(t *testing.T, layout string, loc *time.Location) {
+	t.Helper()
+	output.SetTimeFormat(layout, loc)
+	t.Cleanup(func() { output.SetTimeFormat(output.DateTimeLayout, nil) })
+}
+
+func TestFormatTime(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	ts := time.Date(2024, 3, 1, 8, 30, 15, 123456000, cst)
+
+	// 默认格式保留小数秒，整秒时不显示小数
+	if got := output.FormatTime(ts); got != "2024-03-01 08:30:15.123456" {
+		t.Errorf("默认格式 = %q", got)
+	}
+	if got := output.FormatTime(ts.Truncate(time.Second)); got != "2024-03-01 08:30:15" {
+		t.Errorf("整秒 = %q", got)
+	}
+
+	useTimeFormat(t, time.RFC3339Nano, time.UTC)
+	if got := output.FormatTime(ts); got != "2024-03-01T00:30:15.123456Z" {
+		t.Errorf("UTC = %q", got)
+	}
+	if got, _ := output.FormatValue(ts); got != "2024-03-01T00:30:15.123456Z" {
+		t.Errorf("FormatValue = %q", got)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	useTimeFormat(t, "02/01/2006 15:04", time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// 先按显示格式，不带时区时按显示时区解释
+		{"25/12/2024 18:30", time.Date(2024, 12, 25, 18, 30, 0, 0, time.UTC)},
+		{"2024-12-25T18:30:00.5+08:00", time.Date(2024, 12, 25, 10, 30, 0, 5e8, time.UTC)},
+		{"2024-12-25 18:30:00.123 +0800 +0800", time.Date(2024, 12, 25, 10, 30, 0, 123e6, time.UTC)},
+		{"2024-12-25 18:30:00.123456", time.Date(2024, 12, 25, 18, 30, 0, 123456000, time.UTC)},
+		{"2024-12-25", time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := output.ParseTime(tt.in)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; 期望 %v", tt.in, got, ok, tt.want)
+		}
+	}
+	if _, ok := output.ParseTime("not a date"); ok {
+		t.Error("无效的时间应解析失败")
+	}
+}
+
+func TestRenderXLSXDates(t *testing.T) {
+	useTimeFormat(t, output.DateTimeLayout, time.UTC)
+	result := &output.Result{
+		Columns: []string{"created"},
+		Rows:    [][]interface{}{{time.Date(2024, 3, 1, 8, 30, 15, 0, time.FixedZone("CST", 8*3600))}},
+	}
+	var buf bytes.Buffer
+	if err := output.Render(&buf, output.FormatXLSX, result, output.DefaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// 写为日期单元格，按显示时区的时间保存
+	sheet := f.GetSheetName(0)
+	if typ, _ := f.GetCellType(sheet, "A2"); typ == excelize.CellTypeInlineString || typ == excelize.CellTypeSharedString {
+		t.Errorf("时间单元格类型为字符串")
+	}
+	if got, _ := f.GetCellValue(sheet, "A2"); got != "2024-03-01 00:30:15.000" {
+		t.Errorf("时间单元格 = %q", got)
+	}
+}
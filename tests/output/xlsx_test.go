@@ -0,0 +1,45 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"github.com/yuanpli/datamgr-cli/pkg/output"
+)
+
+func TestRenderXLSX(t *testing.T) {
+	data := renderString(t, "excel")
+
+	f, err := excelize.OpenReader(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatalf("无法读取生成的工作簿: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("行数 = %d, 期望 3", len(rows))
+	}
+	if rows[0][0] != "id" || rows[1][0] != "1" || rows[1][1] != "line1\nline2" {
+		t.Errorf("unexpected rows: %q", rows)
+	}
+	// NULL 输出为空单元格
+	if len(rows[1]) > 2 && rows[1][2] != "" {
+		t.Errorf("NULL 单元格 = %q", rows[1][2])
+	}
+}
+
+func TestBinaryFormats(t *testing.T) {
+	if !output.IsBinaryFormat(output.FormatXLSX) || output.IsBinaryFormat(output.FormatCSV) {
+		t.Error("IsBinaryFormat 判断错误")
+	}
+	for _, format := range output.TextFormats() {
+		if output.IsBinaryFormat(format) {
+			t.Errorf("TextFormats 包含二进制格式 %s", format)
+		}
+	}
+}
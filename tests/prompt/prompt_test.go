@@ -0,0 +1,44 @@
+package prompt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/prompt"
+)
+
+func TestExecuteBlock(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(audit.KeyFileEnv, "")
+
+	conn := &recordingConnection{}
+	db.Register(&db.Driver{
+		Name: "blockfake",
+		New:  func(*db.DbConfig) (db.Connection, error) { return conn, nil },
+	})
+	if err := db.ConnectConfig(&db.DbConfig{Type: "blockfake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Disconnect()
+
+	for _, cmd := range []string{
+		"BEGIN\n  update t set a = 1;\nEND;",
+		"DECLARE\n  v NUMBER;\nBEGIN\n  v := 1;\nEND;",
+		"call p(1);",
+	} {
+		prompt.ExecuteCommand(cmd)
+	}
+	// 过程块保留 END 之后的分号，其他语句去掉末尾的分号
+	want := []string{
+		"BEGIN\n  update t set a = 1;\nEND;",
+		"DECLARE\n  v NUMBER;\nBEGIN\n  v := 1;\nEND;",
+		"call p(1)",
+	}
+	if !reflect.DeepEqual(conn.executed, want) {
+		t.Errorf("执行的语句 = %q, want %q", conn.executed, want)
+	}
+}
@@ -0,0 +1,66 @@
+package prompt_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/audit"
+	"github.com/yuanpli/datamgr-cli/pkg/handler"
+	_ "github.com/yuanpli/datamgr-cli/pkg/prompt"
+)
+
+// recordingConnection 记录执行的语句的连接
+type recordingConnection struct {
+	db.Connection
+	executed []string
+}
+
+func (c *recordingConnection) Connect() error    { return nil }
+func (c *recordingConnection) Disconnect() error { return nil }
+
+func (c *recordingConnection) Execute(query string) (int64, error) {
+	c.executed = append(c.executed, query)
+	return 0, nil
+}
+
+func TestRCScript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(audit.KeyFileEnv, "")
+
+	rc := filepath.Join(home, ".datamgr-cli", "rc.sql")
+	if err := os.MkdirAll(filepath.Dir(rc), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "set timing on;\nSET search_path TO app;\nSET NAMES utf8mb4;\nUSE shop;\nSET timezone TO 'UTC';\n"
+	if err := os.WriteFile(rc, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := &recordingConnection{}
+	db.Register(&db.Driver{
+		Name: "rcfake",
+		New:  func(*db.DbConfig) (db.Connection, error) { return conn, nil },
+	})
+	if err := db.ConnectConfig(&db.DbConfig{Type: "rcfake"}); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Disconnect()
+	defer handler.SetSetting(handler.ScopeSession, "timing", "off")
+
+	handler.AfterConnect("")
+
+	// 不是命令行工具设置的 SET 和 USE 发送给数据库
+	want := []string{"SET search_path TO app", "SET NAMES utf8mb4", "USE shop", "SET timezone TO 'UTC'"}
+	if !reflect.DeepEqual(conn.executed, want) {
+		t.Errorf("执行的语句 = %q, want %q", conn.executed, want)
+	}
+	timing, _ := handler.LookupSetting("timing")
+	if value, _ := timing.Value(); value != "on" {
+		t.Errorf("启动脚本执行 set timing on 后 timing = %s", value)
+	}
+}
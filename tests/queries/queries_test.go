@@ -0,0 +1,111 @@
+package queries_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/queries"
+)
+
+func TestSaveLoadListDelete(t *testing.T) {
+	t.Setenv(queries.DirEnv, t.TempDir())
+
+	path, err := queries.Save("active_users", "活跃用户", "  select * from users where active = 1  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "active_users.sql" {
+		t.Errorf("path = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "-- description: 活跃用户\nselect * from users where active = 1\n" {
+		t.Errorf("内容 = %q", data)
+	}
+
+	q, err := queries.Load("active_users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Description != "活跃用户" || q.SQL != "select * from users where active = 1" {
+		t.Errorf("Load = %+v", q)
+	}
+
+	if _, err := queries.Save("a_first", "", "select 1"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := queries.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "a_first" || list[1].Name != "active_users" {
+		t.Errorf("List = %v", list)
+	}
+
+	if err := queries.Delete("a_first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := queries.Load("a_first"); err == nil {
+		t.Error("删除后仍能读取查询")
+	}
+	if err := queries.Delete("a_first"); err == nil {
+		t.Error("删除不存在的查询应返回错误")
+	}
+}
+
+func TestSaveInvalid(t *testing.T) {
+	t.Setenv(queries.DirEnv, t.TempDir())
+
+	for _, name := range []string{"", "../x", "a b", "a/b"} {
+		if _, err := queries.Save(name, "", "select 1"); err == nil {
+			t.Errorf("Save(%q) 应返回错误", name)
+		}
+	}
+	if _, err := queries.Save("empty", "", "  "); err == nil {
+		t.Error("空查询应返回错误")
+	}
+}
+
+func TestParamsAndRender(t *testing.T) {
+	q := &queries.Query{
+		Name: "monthly",
+		SQL:  "select * from {{.table}} where month = :month and region = :'region' and note <> ':skip'",
+	}
+	if got := q.Params(); !reflect.DeepEqual(got, []string{"table", "month", "region"}) {
+		t.Errorf("Params = %v", got)
+	}
+
+	sql, err := q.Render(map[string]string{"table": "sales", "month": "2024-05", "region": "east"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "select * from sales where month = :month and region = :'region' and note <> ':skip'" {
+		t.Errorf("Render = %q", sql)
+	}
+
+	if _, err := q.Render(map[string]string{"table": "sales"}); err == nil || !strings.Contains(err.Error(), "month, region") {
+		t.Errorf("缺少参数时应返回错误，实际为 %v", err)
+	}
+	if _, err := q.Render(map[string]string{"month": "1", "region": "x"}); err == nil {
+		t.Error("缺少模板参数时应返回错误")
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := queries.ParseParams([]string{"month=2024-05", "filter=a=b", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"month": "2024-05", "filter": "a=b", "empty": ""}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("ParseParams = %v", params)
+	}
+	if _, err := queries.ParseParams([]string{"novalue"}); err == nil {
+		t.Error("缺少等号的参数应返回错误")
+	}
+}
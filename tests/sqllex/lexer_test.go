@@ -0,0 +1,52 @@
+package sqllex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/sqllex"
+)
+
+func TestLexKinds(t *testing.T) {
+	input := "SELECT u.name, 'it''s' AS s, 3.5e2 -- note\nFROM \"User Table\" /* c */"
+	tokens := sqllex.Lex(input)
+
+	var sb strings.Builder
+	kinds := make(map[string]sqllex.Kind)
+	for _, tok := range tokens {
+		sb.WriteString(tok.Text)
+		kinds[tok.Text] = tok.Kind
+		if input[tok.Pos:tok.Pos+len(tok.Text)] != tok.Text {
+			t.Errorf("token %q has wrong position %d", tok.Text, tok.Pos)
+		}
+	}
+	if sb.String() != input {
+		t.Fatalf("tokens do not cover the input: %q", sb.String())
+	}
+
+	want := map[string]sqllex.Kind{
+		"SELECT":       sqllex.Keyword,
+		"u.name":       sqllex.Identifier,
+		"'it''s'":      sqllex.String,
+		"AS":           sqllex.Keyword,
+		"3.5e2":        sqllex.Number,
+		"-- note":      sqllex.Comment,
+		"FROM":         sqllex.Keyword,
+		`"User Table"`: sqllex.Identifier,
+		"/* c */":      sqllex.Comment,
+		",":            sqllex.Operator,
+	}
+	for text, kind := range want {
+		if got, ok := kinds[text]; !ok || got != kind {
+			t.Errorf("token %q: kind %v, want %v (found %v)", text, got, kind, ok)
+		}
+	}
+}
+
+func TestLexUnterminated(t *testing.T) {
+	tokens := sqllex.Lex("select 'abc")
+	last := tokens[len(tokens)-1]
+	if last.Kind != sqllex.String || !last.Unterminated {
+		t.Errorf("expected unterminated string, got %+v", last)
+	}
+}
@@ -0,0 +1,164 @@
+package undo_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yuanpli/datamgr-cli/db"
+	"github.com/yuanpli/datamgr-cli/pkg/undo"
+)
+
+// useTempHome 将配置目录指向临时目录
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	home := useTempHome(t)
+	created := time.Date(2024, 3, 1, 8, 30, 0, 123456000, time.FixedZone("CST", 8*3600))
+	entry := &undo.Entry{
+		Type: "postgresql", Host: "db", Port: 5432, DbName: "shop",
+		Statement: "delete from orders where id < 3", Keyword: "DELETE", Table: "orders",
+		Columns: []string{"id", "amount", "note", "created", "raw", "paid"},
+		Keys:    []string{"id"},
+		Rows: [][]undo.Value{
+			{{V: int64(1)}, {V: "12.50"}, {V: nil}, {V: created}, {V: "\xff\x00"}, {V: true}},
+		},
+	}
+	if err := undo.Append(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filepath.Join(home, ".datamgr-cli", "undo.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("journal mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entries, err := undo.Load()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Load() = %d entries, %v", len(entries), err)
+	}
+	got := entries[0].Rows[0]
+	want := []interface{}{int64(1), "12.50", nil, created, []byte("\xff\x00"), true}
+	for i, value := range got {
+		if tm, ok := value.V.(time.Time); ok {
+			if !tm.Equal(created) {
+				t.Errorf("time = %v, want %v", tm, created)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value.V, want[i]) {
+			t.Errorf("column %s = %#v, want %#v", entry.Columns[i], value.V, want[i])
+		}
+	}
+	if !entries[0].Matches(&db.DbConfig{Type: "postgresql", Host: "db", Port: 5432, DbName: "shop"}) {
+		t.Error("entry should match its own connection")
+	}
+	if entries[0].Matches(&db.DbConfig{Type: "postgresql", Host: "db", Port: 5432, DbName: "other"}) {
+		t.Error("entry should not match another database")
+	}
+}
+
+func TestJournalLimit(t *testing.T) {
+	useTempHome(t)
+	for i := 0; i < undo.MaxEntries+5; i++ {
+		if err := undo.Append(&undo.Entry{Keyword: "DELETE", Statement: time.Duration(i).String()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := undo.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != undo.MaxEntries || entries[0].Statement != time.Duration(5).String() {
+		t.Errorf("kept %d entries starting at %q", len(entries), entries[0].Statement)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	deleted := &undo.Entry{
+		Type: "postgresql", Keyword: "DELETE", Table: "orders",
+		Columns: []string{"id", "note"}, Keys: []string{"id"},
+		Rows: [][]undo.Value{{{V: int64(1)}, {V: "it's"}}},
+	}
+	statements, err := deleted.Statements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []undo.Statement{{SQL: "INSERT INTO orders (id, note) VALUES ($1, $2)", Args: []interface{}{int64(1), "it's"}}}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("DELETE Statements() = %+v, want %+v", statements, want)
+	}
+	if script, _ := deleted.Script(); script != "INSERT INTO orders (id, note) VALUES ($1, $2); -- 1, 'it''s'\n" {
+		t.Errorf("DELETE Script() = %q", script)
+	}
+
+	updated := &undo.Entry{
+		Type: "mysql", Keyword: "UPDATE", Table: "order_items",
+		Columns: []string{"ORDER_ID", "LINE", "QTY", "NOTE"}, Keys: []string{"order_id", "line"},
+		Rows: [][]undo.Value{{{V: int64(7)}, {V: int64(2)}, {V: int64(5)}, {V: nil}}},
+	}
+	statements, err = updated.Statements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []undo.Statement{{
+		SQL:  "UPDATE order_items SET QTY = ?, NOTE = ? WHERE ORDER_ID = ? AND LINE = ?",
+		Args: []interface{}{int64(5), nil, int64(7), int64(2)},
+		// 执行前按主键确认行仍然存在
+		Check: &undo.Statement{
+			SQL:  "SELECT COUNT(*) FROM order_items WHERE ORDER_ID = ? AND LINE = ?",
+			Args: []interface{}{int64(7), int64(2)},
+		},
+	}}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("UPDATE Statements() = %+v, want %+v", statements, want)
+	}
+	if script, _ := updated.Script(); script != "UPDATE order_items SET QTY = ?, NOTE = ? WHERE ORDER_ID = ? AND LINE = ?; -- 5, NULL, 7, 2\n" {
+		t.Errorf("UPDATE Script() = %q", script)
+	}
+
+	// 只恢复原语句赋值的列，其他列可能已被其他语句修改
+	updated.SetColumns = []string{"`qty`"}
+	statements, err = updated.Statements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []undo.Statement{{
+		SQL:  "UPDATE order_items SET QTY = ? WHERE ORDER_ID = ? AND LINE = ?",
+		Args: []interface{}{int64(5), int64(7), int64(2)},
+		Check: &undo.Statement{
+			SQL:  "SELECT COUNT(*) FROM order_items WHERE ORDER_ID = ? AND LINE = ?",
+			Args: []interface{}{int64(7), int64(2)},
+		},
+	}}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("UPDATE SET qty Statements() = %+v, want %+v", statements, want)
+	}
+
+	// 时间保留时区偏移，注释中的换行被转义
+	changed := time.Date(2024, 3, 1, 8, 30, 0, 500000000, time.FixedZone("CST", 8*3600))
+	timed := &undo.Entry{
+		Type: "postgresql", Keyword: "DELETE", Table: "events",
+		Columns: []string{"at", "note"}, Keys: []string{"at"},
+		Rows: [][]undo.Value{{{V: changed}, {V: "a\nb"}}},
+	}
+	if script, _ := timed.Script(); script != "INSERT INTO events (at, note) VALUES ($1, $2); -- '2024-03-01 08:30:00.5+08:00', 'a\\nb'\n" {
+		t.Errorf("Script() with a time = %q", script)
+	}
+
+	updated.Keys = nil
+	if _, err := updated.Statements(); err == nil {
+		t.Error("UPDATE without primary key should fail")
+	}
+}
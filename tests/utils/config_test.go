@@ -0,0 +1,115 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// useTempHome 将配置目录指向临时目录
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+func TestMigrateSingleConfig(t *testing.T) {
+	home := useTempHome(t)
+	legacy := utils.Config{Type: "mysql", Host: "db1", Port: 3306, User: "root", Password: "pw", DbName: "app"}
+	data, _ := json.Marshal(legacy)
+	dir := filepath.Join(home, ".datamgr-cli")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "datamgr-cli-config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := utils.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(*config, legacy) {
+		t.Errorf("migrated config = %+v, want %+v", *config, legacy)
+	}
+
+	file, err := utils.LoadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Default != utils.DefaultProfileName || !reflect.DeepEqual(file.Names(), []string{utils.DefaultProfileName}) {
+		t.Errorf("unexpected migrated file: default %q, profiles %v", file.Default, file.Names())
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	useTempHome(t)
+
+	if _, err := utils.LoadConfig(); err == nil {
+		t.Error("LoadConfig should fail without config file")
+	}
+
+	prod := &utils.Config{Type: "dameng", Host: "prod", Port: 5236, User: "SYSDBA", DbName: "PROD"}
+	test := &utils.Config{Type: "oracle", Host: "test", Port: 1521, User: "scott", DbName: "ORCL"}
+	if err := utils.AddProfile("prod", prod); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.AddProfile("test", test); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.AddProfile("test", test); err == nil {
+		t.Error("adding an existing profile should fail")
+	}
+	if err := utils.AddProfile("bad name", test); err == nil {
+		t.Error("invalid profile name should fail")
+	}
+
+	// 第一个添加的配置成为默认配置
+	if config, err := utils.LoadConfig(); err != nil || config.Host != "prod" {
+		t.Errorf("default profile = %+v, %v", config, err)
+	}
+
+	if err := utils.SetDefaultProfile("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.RenameProfile("test", "qa"); err != nil {
+		t.Fatal(err)
+	}
+	if config, err := utils.LoadConfig(); err != nil || config.Host != "test" {
+		t.Errorf("renamed default profile = %+v, %v", config, err)
+	}
+	if _, err := utils.LoadProfile("test"); err == nil {
+		t.Error("old profile name should no longer exist")
+	}
+	if err := utils.RenameProfile("qa", "prod"); err == nil {
+		t.Error("renaming onto an existing profile should fail")
+	}
+
+	if err := utils.RemoveProfile("qa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.LoadConfig(); err == nil {
+		t.Error("removing the default profile should leave no default")
+	}
+	if config, err := utils.LoadProfile("prod"); err != nil || config.Host != "prod" {
+		t.Errorf("LoadProfile(prod) = %+v, %v", config, err)
+	}
+	if err := utils.RemoveProfile("missing"); err == nil {
+		t.Error("removing a missing profile should fail")
+	}
+
+	// SaveConfig 在没有默认配置时保存为 default 并设为默认
+	if err := utils.SaveConfig(test); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := utils.LoadConfigFile()
+	if file.Default != utils.DefaultProfileName || !reflect.DeepEqual(file.Names(), []string{"default", "prod"}) {
+		t.Errorf("unexpected file after SaveConfig: default %q, profiles %v", file.Default, file.Names())
+	}
+}
@@ -0,0 +1,41 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"", "users", true},
+		{"users", "USERS", true},
+		{"user", "users", false},
+		{"user*", "users", true},
+		{"user%", "user_roles", true},
+		{"*role*", "USER_ROLES", true},
+		{"t?", "t1", true},
+		{"t?", "t12", false},
+		{"a.b", "axb", false},
+		{"a.b", "a.b", true},
+		{"订单*", "订单明细", true},
+	}
+	for _, tt := range tests {
+		if got := utils.MatchPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, 期望 %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHasWildcard(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"users": false, "user*": true, "u%": true, "t?": true, "public.users": false,
+	} {
+		if got := utils.HasWildcard(pattern); got != want {
+			t.Errorf("HasWildcard(%q) = %v, 期望 %v", pattern, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+func TestRCFiles(t *testing.T) {
+	home := useTempHome(t)
+	dir := filepath.Join(home, ".datamgr-cli")
+
+	files, err := utils.RCFiles("prod")
+	if err != nil || len(files) != 0 {
+		t.Fatalf("没有启动脚本时 RCFiles = %v, %v", files, err)
+	}
+
+	global := filepath.Join(dir, "rc.sql")
+	profile := filepath.Join(dir, "rc", "prod.sql")
+	if err := os.MkdirAll(filepath.Dir(profile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{global, profile} {
+		if err := os.WriteFile(path, []byte("set timing on\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// rc.sql 在前，配置的脚本在后
+	if files, _ := utils.RCFiles("prod"); !reflect.DeepEqual(files, []string{global, profile}) {
+		t.Errorf("RCFiles(prod) = %v", files)
+	}
+	if files, _ := utils.RCFiles(""); !reflect.DeepEqual(files, []string{global}) {
+		t.Errorf("RCFiles(\"\") = %v", files)
+	}
+	if _, err := utils.RCFilePath("../x"); err == nil {
+		t.Error("无效的配置名应返回错误")
+	}
+
+	// 重命名配置时一并重命名启动脚本
+	if err := utils.AddProfile("prod", &utils.Config{Type: "mysql", Host: "h", Port: 3306, User: "u", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.RenameProfile("prod", "live"); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := utils.RCFiles("live"); !reflect.DeepEqual(files, []string{global, filepath.Join(dir, "rc", "live.sql")}) {
+		t.Errorf("重命名后 RCFiles(live) = %v", files)
+	}
+}
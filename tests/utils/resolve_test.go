@@ -0,0 +1,289 @@
+package utils_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+// useProjectDir 切换到临时项目目录，写入 .datamgr.json，并清除连接相关的环境变量
+func useProjectDir(t *testing.T, content string) string {
+	t.Helper()
+	for _, field := range utils.ConfigFields {
+		t.Setenv("DATAMGR_"+strings.ToUpper(field), "")
+	}
+	t.Setenv(utils.ProfileEnv, "")
+
+	dir := t.TempDir()
+	if content != "" {
+		if err := os.WriteFile(filepath.Join(dir, utils.ProjectConfigFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sub := filepath.Join(dir, "src", "app")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestResolveLayers(t *testing.T) {
+	useTempHome(t)
+	dir := useProjectDir(t, `{"default": "dev", "profiles": {"dev": {"host": "project-host", "dbname": "shop"}}}`)
+	if err := utils.AddProfile("dev", &utils.Config{Type: "mysql", Host: "user-host", Port: 3306, User: "root", Password: "pw", DbName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATAMGR_USER", "env-user")
+	// 用户配置保存了密码，项目配置修改 host 需要先信任
+	if _, err := utils.TrustProject("", true); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := utils.ResolveConfig("", map[string]string{"port": "3307"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Profile != "dev" {
+		t.Errorf("Profile = %q, want dev", res.Profile)
+	}
+	want := utils.Config{Type: "mysql", Host: "project-host", Port: 3307, User: "env-user", Password: "pw", DbName: "shop"}
+	if !reflect.DeepEqual(*res.Config, want) {
+		t.Errorf("Config = %+v, want %+v", *res.Config, want)
+	}
+
+	project := filepath.Join(dir, utils.ProjectConfigFileName)
+	sources := map[string]string{
+		"type":     utils.LayerUser,
+		"host":     utils.LayerProject + " " + project,
+		"port":     utils.LayerFlag,
+		"user":     utils.LayerEnv + " DATAMGR_USER",
+		"password": utils.LayerUser,
+		"dbname":   utils.LayerProject,
+	}
+	for field, prefix := range sources {
+		if !strings.HasPrefix(res.Sources[field], prefix) {
+			t.Errorf("Sources[%s] = %q, want prefix %q", field, res.Sources[field], prefix)
+		}
+	}
+}
+
+func TestResolveDefaults(t *testing.T) {
+	useTempHome(t)
+	useProjectDir(t, "")
+
+	if _, err := utils.ResolveConfig("", nil); !errors.Is(err, utils.ErrNoDefaultConfig) {
+		t.Fatalf("without any config err = %v, want ErrNoDefaultConfig", err)
+	}
+	if _, err := utils.ResolveConfig("missing", nil); err == nil {
+		t.Fatal("resolving a missing profile should fail")
+	}
+
+	t.Setenv("DATAMGR_HOST", "env-host")
+	res, err := utils.ResolveSources("", map[string]string{"user": "u", "dbname": "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stored.Type != "dameng" || res.Stored.Port != 5236 || res.Sources["port"] != utils.LayerDefault {
+		t.Errorf("defaults not applied: %+v %v", *res.Stored, res.Sources)
+	}
+	if res.Stored.Host != "env-host" {
+		t.Errorf("Host = %q, want env-host", res.Stored.Host)
+	}
+
+	t.Setenv("DATAMGR_PORT", "abc")
+	if _, err := utils.ResolveSources("", nil); err == nil {
+		t.Error("an invalid DATAMGR_PORT should fail")
+	}
+}
+
+func TestResolveProfileEnv(t *testing.T) {
+	useTempHome(t)
+	useProjectDir(t, `{"profiles": {"ci": {"type": "postgresql", "host": "pg", "port": 5432, "user": "ci", "dbname": "ci"}}}`)
+	t.Setenv(utils.ProfileEnv, "ci")
+	t.Setenv("DATAMGR_TEST_CI_PW", "s3cret")
+	t.Setenv("DATAMGR_PASSWORD", "${env:DATAMGR_TEST_CI_PW}")
+
+	res, err := utils.ResolveConfig("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Profile != "ci" || res.Config.Password != "s3cret" {
+		t.Errorf("got profile %q password %q", res.Profile, res.Config.Password)
+	}
+	if res.DisplayValue("password") != "${env:DATAMGR_TEST_CI_PW}" {
+		t.Errorf("DisplayValue(password) = %q, want placeholder", res.DisplayValue("password"))
+	}
+}
+
+func TestResolveUntrustedProject(t *testing.T) {
+	useTempHome(t)
+	dir := useProjectDir(t, `{"profiles": {"dev": {"host": "evil-host", "port": 3306}}}`)
+	project := filepath.Join(dir, utils.ProjectConfigFileName)
+	if err := utils.AddProfile("dev", &utils.Config{Type: "mysql", Host: "user-host", Port: 3306, User: "root", Password: "pw", DbName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未信任的项目配置不能把保存的密码发送到其他服务器
+	if _, err := utils.ResolveConfig("dev", nil); err == nil || !strings.Contains(err.Error(), "config trust") {
+		t.Fatalf("untrusted project overriding host: err = %v, want a trust error", err)
+	}
+	if _, err := utils.ResolveSources("dev", nil); err == nil {
+		t.Error("ResolveSources should report the untrusted override too")
+	}
+
+	path, err := utils.TrustProject("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != project {
+		t.Errorf("TrustProject() = %q, want %q", path, project)
+	}
+	res, err := utils.ResolveConfig("dev", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Config.Host != "evil-host" || res.Config.Password != "pw" {
+		t.Errorf("trusted project not applied: %+v", *res.Config)
+	}
+
+	// 文件修改后信任失效
+	if err := os.WriteFile(project, []byte(`{"profiles": {"dev": {"host": "other-host"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ResolveConfig("dev", nil); err == nil {
+		t.Error("a modified project file should no longer be trusted")
+	}
+	if _, err := utils.TrustProject(project, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.TrustProject(project, false); err == nil {
+		t.Error("untrusting a project twice should fail")
+	}
+
+	// 没有保存密码的配置可以被项目配置修改
+	if err := utils.RemoveProfile("dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.AddProfile("dev", &utils.Config{Type: "mysql", Host: "user-host", Port: 3306, User: "root", DbName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	if res, err = utils.ResolveConfig("dev", nil); err != nil || res.Config.Host != "other-host" {
+		t.Errorf("profile without password: host = %v, err = %v", res, err)
+	}
+}
+
+func TestResolveProjectPlaceholders(t *testing.T) {
+	useTempHome(t)
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	if err := os.WriteFile(secret, []byte("PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DATAMGR_TEST_SECRET", "s3cret")
+	for _, value := range []string{`"user": "${file:` + filepath.ToSlash(secret) + `}"`, `"password": "${env:DATAMGR_TEST_SECRET}"`} {
+		useProjectDir(t, `{"profiles": {"ci": {"type": "mysql", "host": "h", "dbname": "d", `+value+`}}}`)
+		if _, err := utils.TrustProject("", true); err != nil {
+			t.Fatal(err)
+		}
+		// 即使信任了项目配置，其中的占位符也不展开
+		res, err := utils.ResolveConfig("ci", nil)
+		if err == nil {
+			t.Errorf("project placeholder %s should be rejected, got %+v", value, *res.Config)
+		}
+	}
+}
+
+func TestResolveOptions(t *testing.T) {
+	useTempHome(t)
+	useProjectDir(t, `{"profiles": {"pg": {"options": {"sslmode": "require"}}}}`)
+	if err := utils.AddProfile("pg", &utils.Config{Type: "postgresql", Host: "pg", User: "app", DbName: "app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := utils.ResolveConfig("pg", map[string]string{"connect_timeout": "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Config.Port != 5432 || res.Sources["port"] != utils.LayerDefault {
+		t.Errorf("port = %d (%s), want postgresql default 5432", res.Config.Port, res.Sources["port"])
+	}
+	want := map[string]string{"sslmode": "require", "connect_timeout": "5"}
+	if !reflect.DeepEqual(res.Config.Options, want) {
+		t.Errorf("Options = %v, want %v", res.Config.Options, want)
+	}
+	if !strings.HasPrefix(res.Sources["sslmode"], utils.LayerProject) || !strings.HasPrefix(res.Sources["connect_timeout"], utils.LayerFlag) {
+		t.Errorf("option sources = %v", res.Sources)
+	}
+
+	if _, err := utils.ResolveConfig("pg", map[string]string{"charset": "utf8"}); err == nil {
+		t.Error("an option the driver does not declare should fail")
+	}
+}
+
+func TestConfigSetType(t *testing.T) {
+	config := utils.NewConfig()
+	if config.Type != "dameng" || config.Port != 5236 {
+		t.Fatalf("NewConfig() = %+v", *config)
+	}
+	if err := config.Apply(map[string]string{"type": "mysql", "charset": "utf8mb4"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 3306 || config.Options["charset"] != "utf8mb4" {
+		t.Errorf("after switching to mysql: %+v", *config)
+	}
+
+	// 自定义端口在修改类型时保留，新类型不支持的选项被去掉
+	config.Port = 13306
+	if err := config.SetType("postgresql"); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 13306 || len(config.Options) != 0 {
+		t.Errorf("after switching to postgresql: %+v", *config)
+	}
+	if err := config.SetType("nosuchdb"); err == nil {
+		t.Error("an unknown type should fail")
+	}
+	if err := config.SetField("sslmode", "sometimes"); err == nil {
+		t.Error("an invalid option value should fail")
+	}
+}
+
+func TestResolveReadOnly(t *testing.T) {
+	useTempHome(t)
+	useProjectDir(t, `{"profiles": {"prod": {"readonly": true}}}`)
+	for _, name := range []string{"prod", "dev"} {
+		if err := utils.AddProfile(name, &utils.Config{Type: "mysql", Host: "h", User: "u", DbName: "d"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := utils.ResolveConfig("prod", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Config.ReadOnly || !res.Config.DbConfig().ReadOnly || !strings.HasPrefix(res.Sources["readonly"], utils.LayerProject) {
+		t.Errorf("project layer should mark prod read-only: %+v %q", *res.Config, res.Sources["readonly"])
+	}
+
+	if res, err = utils.ResolveConfig("dev", nil); err != nil || res.Config.ReadOnly {
+		t.Fatalf("dev should be writable: %v", err)
+	}
+	if err := utils.SetProfileReadOnly("dev", true); err != nil {
+		t.Fatal(err)
+	}
+	if res, err = utils.ResolveConfig("dev", nil); err != nil || !res.Config.ReadOnly {
+		t.Errorf("dev should be read-only after SetProfileReadOnly: %v", err)
+	}
+	if err := utils.SetProfileReadOnly("missing", true); err == nil {
+		t.Error("SetProfileReadOnly on a missing profile should fail")
+	}
+}
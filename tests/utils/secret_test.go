@@ -0,0 +1,132 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	t.Setenv("DATAMGR_TEST_SECRET", "s3cret")
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"plain":                          "plain",
+		"${env:DATAMGR_TEST_SECRET}":     "s3cret",
+		"${file:" + secretFile + "}":     "from-file",
+		"x-${env:DATAMGR_TEST_SECRET}-y": "x-s3cret-y",
+	}
+	for value, want := range tests {
+		got, err := utils.ExpandPlaceholders(value)
+		if err != nil || got != want {
+			t.Errorf("ExpandPlaceholders(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"${env:DATAMGR_TEST_MISSING}", "${file:/nonexistent/datamgr}"} {
+		if _, err := utils.ExpandPlaceholders(value); err == nil {
+			t.Errorf("ExpandPlaceholders(%q) should fail", value)
+		}
+	}
+}
+
+func TestLockWithPassphrase(t *testing.T) {
+	home := useTempHome(t)
+	t.Setenv(utils.MasterPasswordEnv, "correct horse")
+	t.Setenv("DATAMGR_TEST_SECRET", "from-env")
+
+	if err := utils.AddProfile("prod", &utils.Config{Type: "dameng", Host: "h", User: "u", Password: "pw", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.AddProfile("ci", &utils.Config{Type: "mysql", Host: "h", User: "u", Password: "${env:DATAMGR_TEST_SECRET}", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := utils.LockConfig("")
+	if err != nil || count != 1 {
+		t.Fatalf("LockConfig = %d, %v; want 1 encrypted password", count, err)
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".datamgr-cli", "datamgr-cli-config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"pw"`) {
+		t.Error("password should not be stored in plain text after lock")
+	}
+	if !strings.Contains(string(data), "${env:DATAMGR_TEST_SECRET}") {
+		t.Error("placeholders should be stored as is")
+	}
+	if runtime.GOOS != "windows" {
+		info, _ := os.Stat(filepath.Join(home, ".datamgr-cli", "datamgr-cli-config.json"))
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("config file permissions = %04o, want 0600", perm)
+		}
+	}
+
+	if config, err := utils.LoadProfile("prod"); err != nil || config.Password != "pw" {
+		t.Errorf("LoadProfile(prod) = %+v, %v", config, err)
+	}
+	if config, err := utils.LoadProfile("ci"); err != nil || config.Password != "from-env" {
+		t.Errorf("LoadProfile(ci) = %+v, %v", config, err)
+	}
+	stored, err := utils.LoadStoredProfile("prod")
+	if err != nil || !utils.IsEncrypted(stored.Password) {
+		t.Errorf("LoadStoredProfile should keep the encrypted password, got %+v, %v", stored, err)
+	}
+
+	// 加密后保存的新密码同样加密
+	if err := utils.SaveProfile("prod", &utils.Config{Type: "dameng", Host: "h", User: "u", Password: "new", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := utils.LoadStoredProfile("prod"); !utils.IsEncrypted(stored.Password) {
+		t.Error("passwords saved after lock should be encrypted")
+	}
+	if _, err := utils.LockConfig(""); err == nil {
+		t.Error("locking twice should fail")
+	}
+
+	if count, err := utils.UnlockConfig(); err != nil || count != 1 {
+		t.Fatalf("UnlockConfig = %d, %v", count, err)
+	}
+	if stored, _ := utils.LoadStoredProfile("prod"); stored.Password != "new" {
+		t.Errorf("password after unlock = %q, want new", stored.Password)
+	}
+	if _, err := utils.UnlockConfig(); err == nil {
+		t.Error("unlocking an unlocked config should fail")
+	}
+}
+
+func TestLockWithKeyFile(t *testing.T) {
+	useTempHome(t)
+	if err := utils.AddProfile("prod", &utils.Config{Type: "dameng", Host: "h", User: "u", Password: "pw", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "master.key")
+	if runtime.GOOS != "windows" {
+		if err := os.WriteFile(keyFile, []byte("shared key"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := utils.LockConfig(keyFile); err == nil {
+			t.Error("key file readable by others should be rejected")
+		}
+		os.Remove(keyFile)
+	}
+
+	if _, err := utils.LockConfig(keyFile); err != nil {
+		t.Fatalf("LockConfig with key file failed: %v", err)
+	}
+	if _, err := os.Stat(keyFile); err != nil {
+		t.Fatalf("key file should be generated: %v", err)
+	}
+	if config, err := utils.LoadProfile("prod"); err != nil || config.Password != "pw" {
+		t.Errorf("LoadProfile(prod) = %+v, %v", config, err)
+	}
+}
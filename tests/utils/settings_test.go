@@ -0,0 +1,45 @@
+package utils_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuanpli/datamgr-cli/pkg/utils"
+)
+
+func TestSaveAndLoadSettings(t *testing.T) {
+	useTempHome(t)
+	if err := utils.AddProfile("dev", &utils.Config{Type: "mysql", Host: "h", Port: 3306, User: "u", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.SaveSetting("", "timing", "on"); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.SaveSetting("dev", "timezone", "UTC"); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.SaveSetting("missing", "timing", "on"); err == nil {
+		t.Error("配置不存在时应返回错误")
+	}
+
+	global, local, err := utils.LoadSettings("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(global, map[string]string{"timing": "on"}) || !reflect.DeepEqual(local, map[string]string{"timezone": "UTC"}) {
+		t.Errorf("LoadSettings = %v, %v", global, local)
+	}
+
+	// 用当前连接覆盖配置时保留该配置的设置
+	if err := utils.SaveProfile("dev", &utils.Config{Type: "mysql", Host: "h2", Port: 3306, User: "u", DbName: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	// 空值删除已保存的设置
+	if err := utils.SaveSetting("", "timing", ""); err != nil {
+		t.Fatal(err)
+	}
+	global, local, _ = utils.LoadSettings("dev")
+	if len(global) != 0 || local["timezone"] != "UTC" {
+		t.Errorf("LoadSettings = %v, %v", global, local)
+	}
+}